@@ -3,17 +3,56 @@ package main
 import (
 	"app/internal/application"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
 )
 
 func main() {
 	// env
-	// ...
+	// - AUTH_FILE_PATH is the file with the credentials accepted, see docs/auth.example.json
+	authFilePath := os.Getenv("AUTH_FILE_PATH")
+	// - TENANTS is the vehicles file of each tenant other than the default one: {tenant}={path},{tenant}={path}
+	tenants := make(map[string]string)
+	for _, tenant := range strings.Split(os.Getenv("TENANTS"), ",") {
+		if id, path, ok := strings.Cut(tenant, "="); ok {
+			tenants[strings.TrimSpace(id)] = strings.TrimSpace(path)
+		}
+	}
+	// - DAILY_QUOTA is the number of requests a client may make per day, none when unset
+	dailyQuota, _ := strconv.Atoi(os.Getenv("DAILY_QUOTA"))
+	// - VEHICLE_ID_STRATEGY is the strategy of the vehicle ids: sequence, uuidv7 or ulid, sequence when unset
+	vehicleIDStrategy := os.Getenv("VEHICLE_ID_STRATEGY")
+	// - CLIENT_IDS is whether the clients may choose the ids of the vehicles they create
+	clientIDs, _ := strconv.ParseBool(os.Getenv("CLIENT_IDS"))
+	// - GRAPHQL_MAX_DEPTH and GRAPHQL_MAX_COMPLEXITY are the limits of the GraphQL operations, the defaults when unset
+	graphqlMaxDepth, _ := strconv.Atoi(os.Getenv("GRAPHQL_MAX_DEPTH"))
+	graphqlMaxComplexity, _ := strconv.Atoi(os.Getenv("GRAPHQL_MAX_COMPLEXITY"))
+	// - GRAPHIQL is whether the IDE of the GraphQL API is served at /graphiql, off when unset
+	graphiQL, _ := strconv.ParseBool(os.Getenv("GRAPHIQL"))
 
 	// app
 	// - config
 	cfg := &application.ConfigServerChi{
 		ServerAddress: ":8080",
 		LoaderFilePath: "docs/db/vehicles_100.json",
+		TenantLoaderFilePaths: tenants,
+		CatalogFilePath: "docs/db/catalogs.json",
+		AuditFilePath: "docs/db/audit.log",
+		WALFilePath: "docs/db/vehicles.wal",
+		WebhookFilePath: "docs/db/webhooks.json",
+		WebhookDeadLetterFilePath: "docs/db/webhooks_dead_letters.log",
+		AuthFilePath: authFilePath,
+		DailyQuota: dailyQuota,
+		QuotaFilePath: "docs/db/quotas.json",
+		IdempotencyStore: application.IdempotencyStoreFile,
+		IdempotencyFilePath: "docs/db/idempotency.log",
+		VehicleIDStrategy: vehicleIDStrategy,
+		SequenceFilePath: "docs/db/vehicles.seq",
+		ClientIDs: clientIDs,
+		GraphQLMaxDepth: graphqlMaxDepth,
+		GraphQLMaxComplexity: graphqlMaxComplexity,
+		GraphiQL: graphiQL,
 	}
 	app := application.NewServerChi(cfg)
 	// - run
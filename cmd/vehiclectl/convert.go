@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// runConvert is a function that converts a fleet file between formats
+func runConvert(args []string) (err error) {
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	from := fs.String("from", "", "format of the input file, taken from the extension by default")
+	to := fs.String("to", "", "format of the output, taken from the extension of -o by default")
+	out := fs.String("o", "-", "output file, - for stdout")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		err = fmt.Errorf("usage: vehiclectl convert [-from f] [-to f] [-o file] <file>")
+		return
+	}
+
+	// read file
+	f, err := openFile(fs.Arg(0), *from)
+	if err != nil {
+		return
+	}
+	records, err := f.Records()
+	if err != nil {
+		return
+	}
+
+	// write output
+	format := *to
+	if format == "" {
+		format = "json"
+		if *out != "-" {
+			format = formatOf(*out)
+		}
+	}
+	var w io.Writer = os.Stdout
+	if *out != "-" {
+		var file *os.File
+		file, err = os.Create(*out)
+		if err != nil {
+			return
+		}
+		defer file.Close()
+		w = file
+	}
+	err = writeRecords(w, format, records)
+	return
+}
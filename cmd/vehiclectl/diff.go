@@ -0,0 +1,99 @@
+package main
+
+import (
+	"app/internal/loader"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+)
+
+// runDiff is a function that prints the vehicles added, removed and changed between two fleet files
+func runDiff(args []string) (err error) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		err = fmt.Errorf("usage: vehiclectl diff <old file> <new file>")
+		return
+	}
+
+	// read files
+	oldRecords, err := readByID(fs.Arg(0))
+	if err != nil {
+		return
+	}
+	newRecords, err := readByID(fs.Arg(1))
+	if err != nil {
+		return
+	}
+
+	// ids of both files in order
+	ids := make([]int, 0, len(oldRecords)+len(newRecords))
+	for id := range oldRecords {
+		ids = append(ids, id)
+	}
+	for id := range newRecords {
+		if _, ok := oldRecords[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+
+	// compare
+	var added, removed, changed int
+	for _, id := range ids {
+		o, inOld := oldRecords[id]
+		n, inNew := newRecords[id]
+		switch {
+		case !inOld:
+			fmt.Fprintf(os.Stdout, "+ %d %s %s\n", id, n.Brand, n.Model)
+			added++
+		case !inNew:
+			fmt.Fprintf(os.Stdout, "- %d %s %s\n", id, o.Brand, o.Model)
+			removed++
+		default:
+			fields := diffFields(o, n)
+			if len(fields) == 0 {
+				continue
+			}
+			fmt.Fprintf(os.Stdout, "~ %d %s %s\n", id, n.Brand, n.Model)
+			for _, f := range fields {
+				fmt.Fprintf(os.Stdout, "    %s\n", f)
+			}
+			changed++
+		}
+	}
+	fmt.Fprintf(os.Stdout, "%d added, %d removed, %d changed\n", added, removed, changed)
+	return
+}
+
+// readByID is a function that reads the records of a fleet file indexed by id
+func readByID(path string) (records map[int]loader.VehicleJSON, err error) {
+	f, err := openFile(path, "")
+	if err != nil {
+		return
+	}
+	list, err := f.Records()
+	if err != nil {
+		return
+	}
+	records = make(map[int]loader.VehicleJSON, len(list))
+	for _, vh := range list {
+		records[vh.Id] = vh
+	}
+	return
+}
+
+// diffFields is a function that returns a line for each field that differs between two vehicles
+func diffFields(o loader.VehicleJSON, n loader.VehicleJSON) (lines []string) {
+	ov, nv := reflect.ValueOf(o), reflect.ValueOf(n)
+	t := ov.Type()
+	for i := 0; i < t.NumField(); i++ {
+		a, b := ov.Field(i).Interface(), nv.Field(i).Interface()
+		if a != b {
+			lines = append(lines, fmt.Sprintf("%s: %v -> %v", t.Field(i).Tag.Get("json"), a, b))
+		}
+	}
+	return
+}
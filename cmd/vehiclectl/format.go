@@ -0,0 +1,130 @@
+package main
+
+import (
+	"app/internal"
+	"app/internal/loader"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// fleetFile is an interface that represents a fleet file of any format
+type fleetFile interface {
+	internal.VehicleLoader
+	// Records is a method that returns the vehicles of the file as they are written
+	Records() (v []loader.VehicleJSON, err error)
+}
+
+// openFile is a function that returns the loader for a file according to its format
+func openFile(path string, format string) (f fleetFile, err error) {
+	if format == "" {
+		format = formatOf(path)
+	}
+	switch format {
+	case "json":
+		f = loader.NewVehicleJSONFile(path)
+	case "csv":
+		f = loader.NewVehicleCSVFile(path)
+	case "ndjson":
+		f = loader.NewVehicleNDJSONFile(path)
+	default:
+		err = fmt.Errorf("unknown format %q for %s", format, path)
+	}
+	return
+}
+
+// formatOf is a function that returns the format of a file from its extension
+func formatOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return "csv"
+	case ".ndjson", ".jsonl":
+		return "ndjson"
+	}
+	return "json"
+}
+
+// toRecords is a function that converts a map of vehicles to records sorted by id
+func toRecords(v map[int]internal.Vehicle) (records []loader.VehicleJSON) {
+	for _, value := range v {
+		records = append(records, loader.VehicleJSON{
+			Id:              value.Id,
+			Brand:           value.Brand,
+			Model:           value.Model,
+			Registration:    value.Registration,
+			Color:           value.Color,
+			FabricationYear: value.FabricationYear,
+			Capacity:        value.Capacity,
+			MaxSpeed:        value.MaxSpeed,
+			FuelType:        value.FuelType,
+			Transmission:    value.Transmission,
+			Weight:          value.Weight,
+			Height:          value.Height,
+			Length:          value.Length,
+			Width:           value.Width,
+		})
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Id < records[j].Id })
+	return
+}
+
+// writeRecords is a function that writes the records to w in the given format
+func writeRecords(w io.Writer, format string, records []loader.VehicleJSON) (err error) {
+	switch format {
+	case "json":
+		if records == nil {
+			records = []loader.VehicleJSON{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(records)
+	case "ndjson":
+		enc := json.NewEncoder(w)
+		for _, vh := range records {
+			if err = enc.Encode(vh); err != nil {
+				return
+			}
+		}
+	case "csv":
+		wr := csv.NewWriter(w)
+		if err = wr.Write(loader.CSVHeader); err != nil {
+			return
+		}
+		for _, vh := range records {
+			err = wr.Write([]string{
+				strconv.Itoa(vh.Id),
+				vh.Brand,
+				vh.Model,
+				vh.Registration,
+				vh.Color,
+				strconv.Itoa(vh.FabricationYear),
+				strconv.Itoa(vh.Capacity),
+				formatFloat(vh.MaxSpeed),
+				vh.FuelType,
+				vh.Transmission,
+				formatFloat(vh.Weight),
+				formatFloat(vh.Height),
+				formatFloat(vh.Length),
+				formatFloat(vh.Width),
+			})
+			if err != nil {
+				return
+			}
+		}
+		wr.Flush()
+		err = wr.Error()
+	default:
+		err = fmt.Errorf("unknown format %q", format)
+	}
+	return
+}
+
+// formatFloat is a function that formats a float without trailing zeros
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"app/internal/handler"
+	"app/pkg/vehicleclient"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// runImport is a function that creates the vehicles of a fleet file in a running server, in batches
+func runImport(args []string) (err error) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	format := fs.String("format", "", "format of the file, taken from the extension by default")
+	url := fs.String("url", "http://localhost:8080", "address of the server")
+	size := fs.Int("batch", 50, "number of vehicles per request")
+	keepIDs := fs.Bool("keep-ids", false, "send the ids of the file, the server must accept client ids")
+	apiKey := fs.String("api-key", os.Getenv("VEHICLES_API_KEY"), "API key of the server, $VEHICLES_API_KEY by default")
+	token := fs.String("token", os.Getenv("VEHICLES_TOKEN"), "bearer token of the server, $VEHICLES_TOKEN by default")
+	tenant := fs.String("tenant", "", "tenant the vehicles are imported to, the one of the credentials or the default one when empty")
+	fs.Parse(args)
+	if fs.NArg() != 1 || *size <= 0 {
+		err = fmt.Errorf("usage: vehiclectl import [-url u] [-batch n] [-keep-ids] [-api-key k | -token t] [-tenant t] <file>")
+		return
+	}
+
+	// read file
+	f, err := openFile(fs.Arg(0), *format)
+	if err != nil {
+		return
+	}
+	records, err := f.Records()
+	if err != nil {
+		return
+	}
+
+	// send batches
+	cl := vehicleclient.NewClient(&vehicleclient.Config{BaseURL: *url, APIKey: *apiKey, BearerToken: *token, TenantID: *tenant})
+	ctx := context.Background()
+	imported := 0
+	for start := 0; start < len(records); start += *size {
+		end := min(start+*size, len(records))
+		batch := make([]handler.VehicleJSON, 0, end-start)
+		for _, vh := range records[start:end] {
+			// - the server assigns the ids, unless told otherwise
+			id := 0
+			if *keepIDs {
+				id = vh.Id
+			}
+			batch = append(batch, handler.VehicleJSON{
+				ID:              id,
+				Brand:           vh.Brand,
+				Model:           vh.Model,
+				Registration:    vh.Registration,
+				Color:           vh.Color,
+				FabricationYear: vh.FabricationYear,
+				Capacity:        vh.Capacity,
+				MaxSpeed:        vh.MaxSpeed,
+				FuelType:        vh.FuelType,
+				Transmission:    vh.Transmission,
+				Weight:          vh.Weight,
+				Height:          vh.Height,
+				Length:          vh.Length,
+				Width:           vh.Width,
+			})
+		}
+		if _, err = cl.CreateMultiple(ctx, batch); err != nil {
+			err = fmt.Errorf("vehicles %d to %d: %w", start+1, end, err)
+			break
+		}
+		imported += len(batch)
+	}
+	fmt.Fprintf(os.Stdout, "%d of %d vehicles imported\n", imported, len(records))
+	return
+}
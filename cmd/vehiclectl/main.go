@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// usage is the help message of the command
+const usage = `vehiclectl is a tool to work with fleet files offline and against a running server.
+
+Usage:
+  vehiclectl <command> [flags]
+
+Commands:
+  validate  check a fleet file for malformed, incomplete or duplicated vehicles
+  convert   convert a fleet file between json, csv and ndjson
+  query     filter the vehicles of a fleet file
+  stats     aggregate the vehicles of a fleet file by brand
+  diff      compare two fleet files
+  import    create the vehicles of a fleet file in a running server
+
+The format of a file is taken from its extension (.json, .csv, .ndjson).
+Run "vehiclectl <command> -h" for the flags of a command.
+`
+
+// commands is the list of subcommands by name
+var commands = map[string]func(args []string) error{
+	"validate": runValidate,
+	"convert":  runConvert,
+	"query":    runQuery,
+	"stats":    runStats,
+	"diff":     runDiff,
+	"import":   runImport,
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+	if err := cmd(os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
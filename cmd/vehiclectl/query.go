@@ -0,0 +1,203 @@
+package main
+
+import (
+	"app/internal"
+	"app/internal/repository"
+	"app/internal/service"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// loadService is a function that loads a fleet file into a vehicle service
+func loadService(path string, format string) (sv internal.VehicleService, err error) {
+	f, err := openFile(path, format)
+	if err != nil {
+		return
+	}
+	db, err := f.Load()
+	if err != nil {
+		return
+	}
+	sv = service.NewVehicleDefault(repository.NewVehicleMap(db), nil, nil)
+	return
+}
+
+// isNotFound is a function that reports whether err means that no vehicle matched
+func isNotFound(err error) bool {
+	return errors.Is(err, internal.ErrVehicleNotFound) ||
+		errors.Is(err, internal.ErrVehicleNotFoundByBrand) ||
+		errors.Is(err, internal.ErrVehicleNotFoundByTransmission) ||
+		errors.Is(err, internal.ErrVehicleNotFoundByDimensions) ||
+		errors.Is(err, internal.ErrVehicleNotFoundByWeight)
+}
+
+// parseRange is a function that parses a range with the format {min}-{max}
+func parseRange(s string) (min float64, max float64, err error) {
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 {
+		err = fmt.Errorf("range %q must have the format {min}-{max}", s)
+		return
+	}
+	if min, err = strconv.ParseFloat(parts[0], 64); err != nil {
+		return
+	}
+	max, err = strconv.ParseFloat(parts[1], 64)
+	return
+}
+
+// runQuery is a function that filters the vehicles of a fleet file, every filter given must match
+func runQuery(args []string) (err error) {
+	fs := flag.NewFlagSet("query", flag.ExitOnError)
+	format := fs.String("format", "", "format of the file, taken from the extension by default")
+	output := fs.String("o", "json", "output format (json, csv, ndjson)")
+	color := fs.String("color", "", "color of the vehicles, requires -year")
+	year := fs.Int("year", 0, "fabrication year of the vehicles, requires -color")
+	brand := fs.String("brand", "", "brand of the vehicles")
+	from := fs.Int("from", 0, "first fabrication year, used with -brand")
+	to := fs.Int("to", 9999, "last fabrication year, used with -brand")
+	fuel := fs.String("fuel", "", "fuel type of the vehicles")
+	transmission := fs.String("transmission", "", "transmission of the vehicles")
+	length := fs.String("length", "", "length range {min}-{max}")
+	width := fs.String("width", "", "width range {min}-{max}")
+	minWeight := fs.String("min-weight", "", "minimum weight")
+	maxWeight := fs.String("max-weight", "", "maximum weight")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		err = fmt.Errorf("usage: vehiclectl query [flags] <file>")
+		return
+	}
+	if (*color == "") != (*year == 0) {
+		err = fmt.Errorf("-color and -year must be used together")
+		return
+	}
+
+	sv, err := loadService(fs.Arg(0), *format)
+	if err != nil {
+		return
+	}
+
+	// filters
+	var filters []func() (map[int]internal.Vehicle, error)
+	if *color != "" {
+		filters = append(filters, func() (map[int]internal.Vehicle, error) { return sv.GetByColorAndYear(*color, *year) })
+	}
+	if *brand != "" {
+		filters = append(filters, func() (map[int]internal.Vehicle, error) { return sv.GetByBrandAndYearRange(*brand, *from, *to) })
+	}
+	if *fuel != "" {
+		filters = append(filters, func() (map[int]internal.Vehicle, error) { return sv.GetByFuelType(*fuel) })
+	}
+	if *transmission != "" {
+		filters = append(filters, func() (map[int]internal.Vehicle, error) { return sv.GetByTransmission(*transmission) })
+	}
+	if *length != "" || *width != "" {
+		dimensions := make(map[string]float64)
+		if *length != "" {
+			if dimensions["min_length"], dimensions["max_length"], err = parseRange(*length); err != nil {
+				return
+			}
+		}
+		if *width != "" {
+			if dimensions["min_width"], dimensions["max_width"], err = parseRange(*width); err != nil {
+				return
+			}
+		}
+		filters = append(filters, func() (map[int]internal.Vehicle, error) { return sv.GetByDimensions(dimensions) })
+	}
+	if *minWeight != "" || *maxWeight != "" {
+		weight := make(map[string]float64)
+		if *minWeight != "" {
+			if weight["min"], err = strconv.ParseFloat(*minWeight, 64); err != nil {
+				return
+			}
+		}
+		if *maxWeight != "" {
+			if weight["max"], err = strconv.ParseFloat(*maxWeight, 64); err != nil {
+				return
+			}
+		}
+		filters = append(filters, func() (map[int]internal.Vehicle, error) { return sv.GetByWeight(weight) })
+	}
+
+	// intersect the results of every filter
+	result, err := sv.FindAll()
+	if err != nil {
+		return
+	}
+	for _, filter := range filters {
+		var v map[int]internal.Vehicle
+		v, err = filter()
+		if err != nil && !isNotFound(err) {
+			return
+		}
+		err = nil
+		for id := range result {
+			if _, ok := v[id]; !ok {
+				delete(result, id)
+			}
+		}
+	}
+
+	err = writeRecords(os.Stdout, *output, toRecords(result))
+	return
+}
+
+// runStats is a function that prints the average speed and capacity of the vehicles by brand
+func runStats(args []string) (err error) {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	format := fs.String("format", "", "format of the file, taken from the extension by default")
+	brand := fs.String("brand", "", "brand to aggregate, every brand by default")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		err = fmt.Errorf("usage: vehiclectl stats [-brand b] <file>")
+		return
+	}
+
+	sv, err := loadService(fs.Arg(0), *format)
+	if err != nil {
+		return
+	}
+
+	// brands
+	all, err := sv.FindAll()
+	if err != nil {
+		return
+	}
+	counts := make(map[string]int)
+	for _, value := range all {
+		if *brand == "" || internal.SameValue("brand", value.Brand, *brand) {
+			counts[value.Brand]++
+		}
+	}
+	if len(counts) == 0 {
+		err = internal.ErrVehicleNotFoundByBrand
+		return
+	}
+	brands := make([]string, 0, len(counts))
+	for b := range counts {
+		brands = append(brands, b)
+	}
+	sort.Strings(brands)
+
+	// aggregates
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "BRAND\tVEHICLES\tAVG SPEED\tAVG CAPACITY")
+	for _, b := range brands {
+		var speed, capacity float64
+		if speed, err = sv.GetAverageSpeedByBrand(b); err != nil {
+			return
+		}
+		if capacity, err = sv.GetAverageCapacityByBrand(b); err != nil {
+			return
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%.2f\t%.2f\n", b, counts[b], speed, capacity)
+	}
+	err = tw.Flush()
+	return
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"app/internal/loader"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// runValidate is a function that checks a fleet file for malformed, incomplete or duplicated vehicles
+func runValidate(args []string) (err error) {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	format := fs.String("format", "", "format of the file (json, csv, ndjson), taken from the extension by default")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		err = fmt.Errorf("usage: vehiclectl validate [-format f] <file>")
+		return
+	}
+
+	// read file
+	f, err := openFile(fs.Arg(0), *format)
+	if err != nil {
+		return
+	}
+	records, err := f.Records()
+	if err != nil {
+		return
+	}
+
+	// validate records
+	problems := 0
+	seen := make(map[int]int)
+	for i, vh := range records {
+		if first, ok := seen[vh.Id]; ok {
+			fmt.Fprintf(os.Stdout, "record %d: id %d already used by record %d\n", i+1, vh.Id, first)
+			problems++
+		} else {
+			seen[vh.Id] = i + 1
+		}
+		for _, msg := range validateRecord(vh) {
+			fmt.Fprintf(os.Stdout, "record %d (id %d): %s\n", i+1, vh.Id, msg)
+			problems++
+		}
+	}
+
+	fmt.Fprintf(os.Stdout, "%d vehicles, %d problems\n", len(records), problems)
+	if problems > 0 {
+		err = fmt.Errorf("%s is not valid", fs.Arg(0))
+	}
+	return
+}
+
+// validateRecord is a function that returns the problems found in a vehicle
+func validateRecord(vh loader.VehicleJSON) (problems []string) {
+	required := map[string]string{
+		"brand":        vh.Brand,
+		"model":        vh.Model,
+		"registration": vh.Registration,
+		"color":        vh.Color,
+		"fuel_type":    vh.FuelType,
+		"transmission": vh.Transmission,
+	}
+	for _, field := range []string{"brand", "model", "registration", "color", "fuel_type", "transmission"} {
+		if required[field] == "" {
+			problems = append(problems, fmt.Sprintf("field %s: is required", field))
+		}
+	}
+	if vh.FabricationYear <= 0 {
+		problems = append(problems, "field year: must be positive")
+	}
+	if vh.Capacity <= 0 {
+		problems = append(problems, "field passengers: must be positive")
+	}
+	positive := map[string]float64{
+		"max_speed": vh.MaxSpeed,
+		"weight":    vh.Weight,
+		"height":    vh.Height,
+		"length":    vh.Length,
+		"width":     vh.Width,
+	}
+	for _, field := range []string{"max_speed", "weight", "height", "length", "width"} {
+		if positive[field] < 0 {
+			problems = append(problems, fmt.Sprintf("field %s: must not be negative", field))
+		}
+	}
+	return
+}
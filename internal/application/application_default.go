@@ -1,29 +1,151 @@
 package application
 
 import (
+	"app/internal"
 	"app/internal/handler"
 	"app/internal/loader"
 	"app/internal/repository"
 	"app/internal/service"
+	"app/platform/graphql"
+	"context"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 )
 
+// Idempotency stores
+const (
+	// IdempotencyStoreMemory is the store that keeps the responses of the idempotency keys in memory
+	IdempotencyStoreMemory = "memory"
+	// IdempotencyStoreFile is the store that also appends them to a file, so they survive restarts
+	IdempotencyStoreFile = "file"
+)
+
 // ConfigServerChi is a struct that represents the configuration for ServerChi
 type ConfigServerChi struct {
 	// ServerAddress is the address where the server will be listening
 	ServerAddress string
-	// LoaderFilePath is the path to the file that contains the vehicles
+	// LoaderFilePath is the path to the file that contains the vehicles of the default tenant
 	LoaderFilePath string
+	// TenantLoaderFilePaths is the path to the file that contains the vehicles of each other tenant, by tenant id
+	TenantLoaderFilePaths map[string]string
+	// CatalogLenient is whether unknown attributes of a vehicle are registered in the catalogs instead of rejected
+	CatalogLenient bool
+	// CatalogFilePath is the path to the file where the catalogs of the default tenant are kept across restarts, seeded with the
+	// attributes of its vehicles when it does not exist. The file of each other tenant is next to it, named after the tenant:
+	// catalogs.json and catalogs.{tenant}.json
+	CatalogFilePath string
+	// AuditFilePath is the path to the append-only file where the writes on vehicles are recorded
+	AuditFilePath string
+	// TrashRetention is how long deleted vehicles are kept in the trash before being purged
+	TrashRetention time.Duration
+	// HistoryRetention is how long the past of the vehicles is kept for the queries as_of, which are rejected before it
+	// and before the server started
+	HistoryRetention time.Duration
+	// WALFilePath is the path to the write-ahead log replayed on top of its last compaction, or of the loader file until then,
+	// empty to keep the writes in memory only.
+	// The log of each other tenant is next to it, named after the tenant: vehicles.wal and vehicles.{tenant}.wal
+	WALFilePath string
+	// WALSync is the policy the write-ahead log is synced to disk with: always, interval or never
+	WALSync string
+	// WALSyncInterval is how often the write-ahead log is synced to disk with the interval policy
+	WALSyncInterval time.Duration
+	// CompactInterval is how often the vehicles are written next to the write-ahead log, as {log}.snapshot, and the log truncated.
+	// The loader file is only read, until the first compaction
+	CompactInterval time.Duration
+	// EventBufferSize is the number of last vehicle events of each tenant kept for the clients of the change feed to resume from
+	EventBufferSize int
+	// EventSubscriberBufferSize is the number of vehicle events queued to a client of the change feed, it is dropped
+	// once they are full and resumes from the last event it received
+	EventSubscriberBufferSize int
+	// WebhookFilePath is the path to the JSON file where the webhooks and their deliveries awaiting a retry are kept
+	WebhookFilePath string
+	// WebhookDeadLetterFilePath is the path to the append-only file where the webhook deliveries that failed for good are kept
+	WebhookDeadLetterFilePath string
+	// WebhookMaxAttempts is the number of times an event is posted to a webhook before it goes to the dead-letter queue
+	WebhookMaxAttempts int
+	// WebhookBackoff is the wait before the first retry of a webhook delivery, doubled on each attempt
+	WebhookBackoff time.Duration
+	// AuthFilePath is the path to the file with the API keys and token keys accepted, empty to leave the API open
+	AuthFilePath string
+	// ReadRateLimit is the rate limit of the GET routes per client, a zero value keeps the default and negative requests disable it
+	ReadRateLimit internal.RateLimit
+	// WriteRateLimit is the rate limit of the routes that write, but batches, per client
+	WriteRateLimit internal.RateLimit
+	// BatchRateLimit is the rate limit of the batch routes per client
+	BatchRateLimit internal.RateLimit
+	// IPRateLimit is the rate limit of every request per IP, checked before the credentials
+	IPRateLimit internal.RateLimit
+	// DailyQuota is the number of requests a client may make per UTC day, 0 for no quota
+	DailyQuota int
+	// QuotaFilePath is the path to the file where the counters of the daily quotas are kept across restarts
+	QuotaFilePath string
+	// IdempotencyStore is the store of the responses of the idempotency keys: memory or file
+	IdempotencyStore string
+	// IdempotencyFilePath is the path to the file of the file store of the idempotency keys
+	IdempotencyFilePath string
+	// IdempotencyTTL is how long the response of a request with an idempotency key is replayed to its retries
+	IdempotencyTTL time.Duration
+	// VehicleIDStrategy is the strategy of the ids allocated to the vehicles: sequence, uuidv7 or ulid.
+	// With uuidv7 and ulid the vehicles also get a string id they can be addressed by
+	VehicleIDStrategy string
+	// SequenceFilePath is the path to the file where the last vehicle id allocated is kept across restarts.
+	// The file of each other tenant is next to it, named after the tenant: vehicles.seq and vehicles.{tenant}.seq
+	SequenceFilePath string
+	// ClientIDs is whether the vehicles may be created with an id chosen by the client instead of rejected
+	ClientIDs bool
+	// V1Sunset is the moment the vehicle routes of the version 1 of the API that the version 2 replaces, under /v1 and unprefixed,
+	// stop being served, zero for none
+	V1Sunset time.Time
+	// GraphQLMaxDepth is the deepest a GraphQL operation may nest its fields
+	GraphQLMaxDepth int
+	// GraphQLMaxComplexity is the highest complexity a GraphQL operation may have: each field costs 1 plus its subfields,
+	// and a page of vehicles its size times the subfields of a vehicle
+	GraphQLMaxComplexity int
+	// GraphiQL is whether the IDE of the GraphQL API is served at /graphiql, off by default
+	GraphiQL bool
+	// WebSocketOrigins is the list of the origins, as scheme://host[:port], of the browsers allowed to open the websocket API
+	// besides the host of the API itself, "*" for any
+	WebSocketOrigins []string
 }
 
 // NewServerChi is a function that returns a new instance of ServerChi
 func NewServerChi(cfg *ConfigServerChi) *ServerChi {
 	// default values
 	defaultConfig := &ConfigServerChi{
-		ServerAddress: ":8080",
+		ServerAddress:             ":8080",
+		CatalogFilePath:           "catalogs.json",
+		AuditFilePath:             "audit.log",
+		TrashRetention:            30 * 24 * time.Hour,
+		HistoryRetention:          30 * 24 * time.Hour,
+		WALSync:                   repository.WALSyncAlways,
+		WALSyncInterval:           time.Second,
+		CompactInterval:           time.Hour,
+		EventBufferSize:           1024,
+		EventSubscriberBufferSize: 64,
+		WebhookFilePath:           "webhooks.json",
+		WebhookDeadLetterFilePath: "webhooks_dead_letters.log",
+		WebhookMaxAttempts:        5,
+		WebhookBackoff:            time.Second,
+		ReadRateLimit:             internal.RateLimit{Requests: 100, Window: time.Second},
+		WriteRateLimit:            internal.RateLimit{Requests: 20, Window: time.Second},
+		BatchRateLimit:            internal.RateLimit{Requests: 5, Window: time.Minute},
+		IPRateLimit:               internal.RateLimit{Requests: 200, Window: time.Second},
+		QuotaFilePath:             "quotas.json",
+		IdempotencyStore:          IdempotencyStoreMemory,
+		IdempotencyFilePath:       "idempotency.log",
+		IdempotencyTTL:            24 * time.Hour,
+		VehicleIDStrategy:         repository.VehicleIDSequence,
+		SequenceFilePath:          "vehicles.seq",
+		GraphQLMaxDepth:           10,
+		GraphQLMaxComplexity:      2000,
 	}
 	if cfg != nil {
 		if cfg.ServerAddress != "" {
@@ -32,11 +154,129 @@ func NewServerChi(cfg *ConfigServerChi) *ServerChi {
 		if cfg.LoaderFilePath != "" {
 			defaultConfig.LoaderFilePath = cfg.LoaderFilePath
 		}
+		defaultConfig.TenantLoaderFilePaths = cfg.TenantLoaderFilePaths
+		defaultConfig.CatalogLenient = cfg.CatalogLenient
+		if cfg.CatalogFilePath != "" {
+			defaultConfig.CatalogFilePath = cfg.CatalogFilePath
+		}
+		if cfg.AuditFilePath != "" {
+			defaultConfig.AuditFilePath = cfg.AuditFilePath
+		}
+		if cfg.TrashRetention > 0 {
+			defaultConfig.TrashRetention = cfg.TrashRetention
+		}
+		if cfg.HistoryRetention > 0 {
+			defaultConfig.HistoryRetention = cfg.HistoryRetention
+		}
+		defaultConfig.WALFilePath = cfg.WALFilePath
+		if cfg.WALSync != "" {
+			defaultConfig.WALSync = cfg.WALSync
+		}
+		if cfg.WALSyncInterval > 0 {
+			defaultConfig.WALSyncInterval = cfg.WALSyncInterval
+		}
+		if cfg.CompactInterval > 0 {
+			defaultConfig.CompactInterval = cfg.CompactInterval
+		}
+		if cfg.EventBufferSize > 0 {
+			defaultConfig.EventBufferSize = cfg.EventBufferSize
+		}
+		if cfg.EventSubscriberBufferSize > 0 {
+			defaultConfig.EventSubscriberBufferSize = cfg.EventSubscriberBufferSize
+		}
+		if cfg.WebhookFilePath != "" {
+			defaultConfig.WebhookFilePath = cfg.WebhookFilePath
+		}
+		if cfg.WebhookDeadLetterFilePath != "" {
+			defaultConfig.WebhookDeadLetterFilePath = cfg.WebhookDeadLetterFilePath
+		}
+		if cfg.WebhookMaxAttempts > 0 {
+			defaultConfig.WebhookMaxAttempts = cfg.WebhookMaxAttempts
+		}
+		if cfg.WebhookBackoff > 0 {
+			defaultConfig.WebhookBackoff = cfg.WebhookBackoff
+		}
+		defaultConfig.AuthFilePath = cfg.AuthFilePath
+		if cfg.ReadRateLimit != (internal.RateLimit{}) {
+			defaultConfig.ReadRateLimit = cfg.ReadRateLimit
+		}
+		if cfg.WriteRateLimit != (internal.RateLimit{}) {
+			defaultConfig.WriteRateLimit = cfg.WriteRateLimit
+		}
+		if cfg.BatchRateLimit != (internal.RateLimit{}) {
+			defaultConfig.BatchRateLimit = cfg.BatchRateLimit
+		}
+		if cfg.IPRateLimit != (internal.RateLimit{}) {
+			defaultConfig.IPRateLimit = cfg.IPRateLimit
+		}
+		defaultConfig.DailyQuota = cfg.DailyQuota
+		if cfg.QuotaFilePath != "" {
+			defaultConfig.QuotaFilePath = cfg.QuotaFilePath
+		}
+		if cfg.IdempotencyStore != "" {
+			defaultConfig.IdempotencyStore = cfg.IdempotencyStore
+		}
+		if cfg.IdempotencyFilePath != "" {
+			defaultConfig.IdempotencyFilePath = cfg.IdempotencyFilePath
+		}
+		if cfg.IdempotencyTTL > 0 {
+			defaultConfig.IdempotencyTTL = cfg.IdempotencyTTL
+		}
+		if cfg.VehicleIDStrategy != "" {
+			defaultConfig.VehicleIDStrategy = cfg.VehicleIDStrategy
+		}
+		if cfg.SequenceFilePath != "" {
+			defaultConfig.SequenceFilePath = cfg.SequenceFilePath
+		}
+		defaultConfig.ClientIDs = cfg.ClientIDs
+		defaultConfig.V1Sunset = cfg.V1Sunset
+		if cfg.GraphQLMaxDepth > 0 {
+			defaultConfig.GraphQLMaxDepth = cfg.GraphQLMaxDepth
+		}
+		if cfg.GraphQLMaxComplexity > 0 {
+			defaultConfig.GraphQLMaxComplexity = cfg.GraphQLMaxComplexity
+		}
+		defaultConfig.GraphiQL = cfg.GraphiQL
+		defaultConfig.WebSocketOrigins = cfg.WebSocketOrigins
 	}
 
 	return &ServerChi{
-		serverAddress:  defaultConfig.ServerAddress,
-		loaderFilePath: defaultConfig.LoaderFilePath,
+		serverAddress:             defaultConfig.ServerAddress,
+		loaderFilePath:            defaultConfig.LoaderFilePath,
+		tenantLoaderFilePaths:     defaultConfig.TenantLoaderFilePaths,
+		catalogLenient:            defaultConfig.CatalogLenient,
+		catalogFilePath:           defaultConfig.CatalogFilePath,
+		auditFilePath:             defaultConfig.AuditFilePath,
+		trashRetention:            defaultConfig.TrashRetention,
+		historyRetention:          defaultConfig.HistoryRetention,
+		walFilePath:               defaultConfig.WALFilePath,
+		walSync:                   defaultConfig.WALSync,
+		walSyncInterval:           defaultConfig.WALSyncInterval,
+		compactInterval:           defaultConfig.CompactInterval,
+		eventBufferSize:           defaultConfig.EventBufferSize,
+		eventSubscriberBufferSize: defaultConfig.EventSubscriberBufferSize,
+		webhookFilePath:           defaultConfig.WebhookFilePath,
+		webhookDeadLetterFilePath: defaultConfig.WebhookDeadLetterFilePath,
+		webhookMaxAttempts:        defaultConfig.WebhookMaxAttempts,
+		webhookBackoff:            defaultConfig.WebhookBackoff,
+		authFilePath:              defaultConfig.AuthFilePath,
+		readRateLimit:             defaultConfig.ReadRateLimit,
+		writeRateLimit:            defaultConfig.WriteRateLimit,
+		batchRateLimit:            defaultConfig.BatchRateLimit,
+		ipRateLimit:               defaultConfig.IPRateLimit,
+		dailyQuota:                defaultConfig.DailyQuota,
+		quotaFilePath:             defaultConfig.QuotaFilePath,
+		idempotencyStore:          defaultConfig.IdempotencyStore,
+		idempotencyFilePath:       defaultConfig.IdempotencyFilePath,
+		idempotencyTTL:            defaultConfig.IdempotencyTTL,
+		vehicleIDStrategy:         defaultConfig.VehicleIDStrategy,
+		sequenceFilePath:          defaultConfig.SequenceFilePath,
+		clientIDs:                 defaultConfig.ClientIDs,
+		v1Sunset:                  defaultConfig.V1Sunset,
+		graphqlMaxDepth:           defaultConfig.GraphQLMaxDepth,
+		graphqlMaxComplexity:      defaultConfig.GraphQLMaxComplexity,
+		graphiQL:                  defaultConfig.GraphiQL,
+		webSocketOrigins:          defaultConfig.WebSocketOrigins,
 	}
 }
 
@@ -44,64 +284,510 @@ func NewServerChi(cfg *ConfigServerChi) *ServerChi {
 type ServerChi struct {
 	// serverAddress is the address where the server will be listening
 	serverAddress string
-	// loaderFilePath is the path to the file that contains the vehicles
+	// loaderFilePath is the path to the file that contains the vehicles of the default tenant
 	loaderFilePath string
+	// tenantLoaderFilePaths is the path to the file that contains the vehicles of each other tenant, by tenant id
+	tenantLoaderFilePaths map[string]string
+	// catalogLenient is whether unknown attributes of a vehicle are registered in the catalogs instead of rejected
+	catalogLenient bool
+	// catalogFilePath is the path to the file where the catalogs of the default tenant are kept
+	catalogFilePath string
+	// auditFilePath is the path to the append-only file where the writes on vehicles are recorded
+	auditFilePath string
+	// trashRetention is how long deleted vehicles are kept in the trash before being purged
+	trashRetention time.Duration
+	// historyRetention is how long the past of the vehicles is kept for the queries as_of
+	historyRetention time.Duration
+	// walFilePath is the path to the write-ahead log, empty to keep the writes in memory only
+	walFilePath string
+	// walSync is the policy the write-ahead log is synced to disk with
+	walSync string
+	// walSyncInterval is how often the write-ahead log is synced to disk with the interval policy
+	walSyncInterval time.Duration
+	// compactInterval is how often the vehicles are written next to the write-ahead log and the log truncated
+	compactInterval time.Duration
+	// eventBufferSize is the number of last vehicle events of each tenant kept for the clients of the change feed
+	eventBufferSize int
+	// eventSubscriberBufferSize is the number of vehicle events queued to a client of the change feed
+	eventSubscriberBufferSize int
+	// webhookFilePath is the path to the file where the webhooks and their deliveries awaiting a retry are kept
+	webhookFilePath string
+	// webhookDeadLetterFilePath is the path to the file where the webhook deliveries that failed for good are kept
+	webhookDeadLetterFilePath string
+	// webhookMaxAttempts is the number of times an event is posted to a webhook before it goes to the dead-letter queue
+	webhookMaxAttempts int
+	// webhookBackoff is the wait before the first retry of a webhook delivery
+	webhookBackoff time.Duration
+	// authFilePath is the path to the file with the credentials accepted, empty to leave the API open
+	authFilePath string
+	// readRateLimit is the rate limit of the GET routes per client
+	readRateLimit internal.RateLimit
+	// writeRateLimit is the rate limit of the routes that write, but batches, per client
+	writeRateLimit internal.RateLimit
+	// batchRateLimit is the rate limit of the batch routes per client
+	batchRateLimit internal.RateLimit
+	// ipRateLimit is the rate limit of every request per IP
+	ipRateLimit internal.RateLimit
+	// dailyQuota is the number of requests a client may make per UTC day, 0 for no quota
+	dailyQuota int
+	// quotaFilePath is the path to the file where the counters of the daily quotas are kept
+	quotaFilePath string
+	// idempotencyStore is the store of the responses of the idempotency keys
+	idempotencyStore string
+	// idempotencyFilePath is the path to the file of the file store of the idempotency keys
+	idempotencyFilePath string
+	// idempotencyTTL is how long the response of a request with an idempotency key is replayed
+	idempotencyTTL time.Duration
+	// vehicleIDStrategy is the strategy of the ids allocated to the vehicles
+	vehicleIDStrategy string
+	// sequenceFilePath is the path to the file where the last vehicle id allocated is kept
+	sequenceFilePath string
+	// clientIDs is whether the vehicles may be created with an id chosen by the client
+	clientIDs bool
+	// v1Sunset is the moment the vehicle routes of the version 1 replaced by the version 2 stop being served, zero for none
+	v1Sunset time.Time
+	// graphqlMaxDepth is the deepest a GraphQL operation may nest its fields
+	graphqlMaxDepth int
+	// graphqlMaxComplexity is the highest complexity a GraphQL operation may have
+	graphqlMaxComplexity int
+	// graphiQL is whether the IDE of the GraphQL API is served
+	graphiQL bool
+	// webSocketOrigins is the list of the origins of the browsers allowed to open the websocket API besides its host
+	webSocketOrigins []string
 }
 
 // Run is a method that runs the application
 func (a *ServerChi) Run() (err error) {
+	h, release, err := a.Handler()
+	if err != nil {
+		return
+	}
+	defer release()
+
+	// run server
+	err = http.ListenAndServe(a.serverAddress, h)
+	return
+}
+
+// Handler is a method that sets up the dependencies and the background tasks, and returns the router of the API
+// with a function releasing the files it holds, to call once it no longer serves. The release stops the background
+// tasks and waits for them before it closes the files
+func (a *ServerChi) Handler() (h http.Handler, release func(), err error) {
+	logger := slog.Default()
+	ctx, cancel := context.WithCancel(context.Background())
+	var tasks sync.WaitGroup
+	// background runs a task until the release
+	background := func(task func(ctx context.Context)) {
+		tasks.Add(1)
+		go func() {
+			defer tasks.Done()
+			task(ctx)
+		}()
+	}
+	var releases []func()
+	release = func() {
+		cancel()
+		tasks.Wait()
+		for i := len(releases) - 1; i >= 0; i-- {
+			releases[i]()
+		}
+	}
+	defer func() {
+		if err != nil {
+			release()
+			release = nil
+		}
+	}()
+
 	// dependencies
-	// - loader
-	ld := loader.NewVehicleJSONFile(a.loaderFilePath)
-	db, err := ld.Load()
+	// - loader and repository of each tenant
+	loaderFilePaths := map[string]string{internal.DefaultTenant: a.loaderFilePath}
+	for t, path := range a.tenantLoaderFilePaths {
+		// - the tenant ids name the files of the tenants
+		if t == "" || strings.Trim(t, "abcdefghijklmnopqrstuvwxyz0123456789-_") != "" {
+			return nil, nil, fmt.Errorf("tenant %q: ids are lowercase letters, digits, - and _", t)
+		}
+		loaderFilePaths[t] = path
+	}
+	rps := make(map[string]internal.VehicleRepository, len(loaderFilePaths))
+	rpCatalogs := make(map[string]internal.CatalogRepository, len(loaderFilePaths))
+	for t, path := range loaderFilePaths {
+		ld := loader.NewVehicleJSONFile(path)
+		var db map[int]internal.Vehicle
+		db, err = ld.Load()
+		if err != nil {
+			return nil, nil, fmt.Errorf("tenant %s: %w", t, err)
+		}
+		// - the catalogs of each tenant, seeded with the attributes of its vehicles
+		rpCatalogs[t], err = repository.NewCatalogFile(repository.NewCatalogMap(db), tenantPath(a.catalogFilePath, t))
+		if err != nil {
+			return nil, nil, fmt.Errorf("tenant %s: %w", t, err)
+		}
+		var rp internal.VehicleRepository = repository.NewVehicleMap(db)
+		if a.walFilePath != "" {
+			var wal *repository.VehicleWAL
+			wal, err = repository.NewVehicleWAL(rp.(*repository.VehicleMap), tenantPath(a.walFilePath, t), a.walSync)
+			if err != nil {
+				return nil, nil, fmt.Errorf("tenant %s: %w", t, err)
+			}
+			releases = append(releases, func() { wal.Close() })
+			logger := logger.With("tenant", t)
+			background(func(ctx context.Context) { a.maintainWAL(ctx, wal, logger) })
+			rp = wal
+		}
+		// - the server allocates the ids of the vehicles created
+		var seq *repository.VehicleSequenceFile
+		seq, err = repository.NewVehicleSequenceFile(tenantPath(a.sequenceFilePath, t))
+		if err != nil {
+			return nil, nil, fmt.Errorf("tenant %s: %w", t, err)
+		}
+		rp, err = repository.NewVehicleIDs(rp, seq, a.vehicleIDStrategy, a.clientIDs)
+		if err != nil {
+			return nil, nil, fmt.Errorf("tenant %s: %w", t, err)
+		}
+		rps[t] = rp
+	}
+	rpAudit, err := repository.NewAuditFile(a.auditFilePath)
 	if err != nil {
 		return
 	}
-	// - repository
-	rp := repository.NewVehicleMap(db)
+	rpEvents := make(map[string]internal.VehicleEventRepository, len(rps))
+	for t := range rps {
+		rpEvents[t] = repository.NewVehicleEventRing(a.eventBufferSize, a.eventSubscriberBufferSize)
+	}
+	rpWebhook, err := repository.NewWebhookFile(repository.NewWebhookMap(100), a.webhookFilePath)
+	if err != nil {
+		return
+	}
+	rpDeadLetter, err := repository.NewWebhookDeadLetterFile(a.webhookDeadLetterFilePath)
+	if err != nil {
+		return
+	}
+	rpQuota, err := repository.NewQuotaFile(a.quotaFilePath)
+	if err != nil {
+		return
+	}
+	releases = append(releases, func() { rpQuota.Sync() })
+	background(func(ctx context.Context) { a.syncQuotas(ctx, rpQuota, logger) })
+	var rpIdempotency internal.IdempotencyRepository
+	switch a.idempotencyStore {
+	case IdempotencyStoreMemory:
+		rpIdempotency = repository.NewIdempotencyMap()
+	case IdempotencyStoreFile:
+		rpIdempotency, err = repository.NewIdempotencyFile(a.idempotencyFilePath)
+		if err != nil {
+			return
+		}
+	default:
+		return nil, nil, fmt.Errorf("unknown idempotency store %q", a.idempotencyStore)
+	}
 	// - service
-	sv := service.NewVehicleDefault(rp)
+	svCatalogs := make(map[string]internal.CatalogService, len(rpCatalogs))
+	for t, rp := range rpCatalogs {
+		svCatalogs[t] = service.NewCatalogDefault(rp, a.catalogLenient)
+	}
+	svAudit := service.NewAuditDefault(rpAudit)
+	svQuota := service.NewQuotaDefault(rpQuota, a.dailyQuota)
+	svIdempotency := service.NewIdempotencyDefault(rpIdempotency, a.idempotencyTTL)
+	svEvent := service.NewVehicleEventDefault(rpEvents)
+	// - event bus, the audit log and the change feed record every write before it is answered
+	bus := internal.NewEventBus(8, func(err error) { logger.Error("event bus", "error", err) })
+	releases = append(releases, bus.Close)
+	internal.Subscribe(bus, svAudit.Record)
+	internal.Subscribe(bus, svEvent.Record)
+	svWebhook := service.NewWebhookDefault(rpWebhook, rpDeadLetter, svEvent, service.NewWebhookClient(10*time.Second), a.webhookMaxAttempts, a.webhookBackoff, logger)
+	svs := make(map[string]internal.VehicleService, len(rps))
+	for t, rp := range rps {
+		svs[t] = service.NewVehicleDefault(rp, svCatalogs[t], bus).WithTenant(t)
+	}
+	sv := service.NewVehicleTenants(svs)
 	// - handler
+	hdCatalog := handler.NewCatalogDefault(svCatalogs)
+	hdAudit := handler.NewAuditDefault(svAudit, sv)
+	hdEvent := handler.NewVehicleEventDefault(svEvent)
+	hdSocket := handler.NewVehicleSocket(sv, svEvent, a.webSocketOrigins)
+	hdWebhook := handler.NewWebhookDefault(svWebhook)
 	hd := handler.NewVehicleDefault(sv)
+	hdTenant := handler.NewTenantDefault(sv)
+	hdRateLimit := handler.NewRateLimitDefault(svQuota, a.readRateLimit, a.writeRateLimit, a.batchRateLimit, a.ipRateLimit)
+	hdIdempotency := handler.NewIdempotencyDefault(svIdempotency, logger)
+	hdGraphQL := handler.NewGraphQLDefault(sv, graphql.Limits{MaxDepth: a.graphqlMaxDepth, MaxComplexity: a.graphqlMaxComplexity})
+	// - authentication, every route requires the viewer role to read, operator to PATCH and admin for the rest,
+	// but /graphql which any caller may query and whose mutations check the role of the route they mirror
+	authenticate := handler.Open
+	authorize := func(next http.Handler) http.Handler { return next }
+	if a.authFilePath != "" {
+		var cfg internal.AuthConfig
+		cfg, err = loader.NewAuthJSONFile(a.authFilePath).Load()
+		if err != nil {
+			return
+		}
+		hdAuth := handler.NewAuthDefault(cfg)
+		authenticate = hdAuth.Authenticate
+		authorize = hdAuth.Authorize
+	} else {
+		logger.Warn("no auth file configured, the API is open to anyone")
+	}
+	// - purge of the trash and the history of each tenant
+	for t, sv := range svs {
+		sv, logger := sv, logger.With("tenant", t)
+		background(func(ctx context.Context) { a.purgeTrash(ctx, sv, logger) })
+		background(func(ctx context.Context) { a.purgeHistory(ctx, sv, logger) })
+	}
+	// - expiry of the idempotency keys
+	background(func(ctx context.Context) { a.purgeIdempotencyKeys(ctx, svIdempotency, logger) })
+	// - delivery of the webhooks, queued from the change feed of each tenant
+	for t := range rps {
+		t := t
+		background(func(ctx context.Context) { svWebhook.Dispatch(ctx, t) })
+	}
+	background(svWebhook.Deliver)
+
 	// router
 	rt := chi.NewRouter()
 	// - middlewares
 	rt.Use(middleware.Logger)
 	rt.Use(middleware.Recoverer)
-	// - endpoints
-	rt.Route("/vehicles", func(rt chi.Router) {
-		// - GET /vehicles
-		rt.Get("/", hd.GetAll())
-		// - POST /vehicles
-		rt.Post("/", hd.Create())
-		// - GET /vehicles/color/{color}/year/{year}
-		rt.Get("/color/{color}/year/{year}", hd.GetByColorAndYear())
-		// - GET /vehicles/brand/{brand}/between/{start_year}/{end_year}
-		rt.Get("/brand/{brand}/between/{start_year}/{end_year}", hd.GetByBrandAndYearRange())
-		// - GET /vehicles/average_speed/brand/{brand}
-		rt.Get("/average_speed/brand/{brand}", hd.GetAverageSpeedByBrand())
-		// - POST /vehicles/batch
-		rt.Post("/batch", hd.CreateMultiple())
-		// - PATCH /vehicles/{id}/update_speed
-		rt.Patch("/{id}/update_speed", hd.UpdateSpeed())
-		// - GET /vehicles/fuel_type/{type}
-		rt.Get("/fuel_type/{type}", hd.GetByFuelType())
-		// DELETE /vehicles/{id}
-		rt.Delete("/{id}", hd.Delete())
-		// - GET /vehicles/transmission/{type}
-		rt.Get("/transmission/{type}", hd.GetByTransmission())
-		// - PATCH /vehicles/{id}/update_fuel
-		rt.Patch("/{id}/update_fuel", hd.UpdateFuel())
-		// - GET /vehicles/average_capacity/brand/{brand}
-		rt.Get("/average_capacity/brand/{brand}", hd.GetAverageCapacityByBrand())
-		// - GET /vehicles/dimensions?length={min_length}-{max_length}&width={min_width}-{max_width}
-		rt.Get("/dimensions", hd.GetByDimensions())
-		// - GET /vehicles/weight?min={weight_min}&max={weight_max}
-		rt.Get("/weight", hd.GetByWeight())
+	// - endpoints of the version 1, under /v1 and unprefixed for the clients that predate the versions
+	v1 := func(rt chi.Router) {
+		rt.Route("/vehicles", func(rt chi.Router) {
+			// - the routes that /v2/vehicles replaces are deprecated
+			rt.Group(func(rt chi.Router) {
+				rt.Use(handler.Deprecated("/v2/vehicles", a.v1Sunset))
+				// - GET /vehicles?as_of={RFC 3339 time}
+				rt.Get("/", hd.GetAll())
+				// - POST /vehicles with an optional Idempotency-Key header
+				rt.With(hdIdempotency.Handle).Post("/", hd.Create())
+				// - GET /vehicles/color/{color}/year/{year}
+				rt.Get("/color/{color}/year/{year}", hd.GetByColorAndYear())
+				// - GET /vehicles/brand/{brand}/between/{start_year}/{end_year}
+				rt.Get("/brand/{brand}/between/{start_year}/{end_year}", hd.GetByBrandAndYearRange())
+				// - POST /vehicles/batch with an optional Idempotency-Key header
+				rt.With(hdIdempotency.Handle).Post("/batch", hd.CreateMultiple())
+				// - PATCH /vehicles/{id}/update_speed
+				rt.Patch("/{id}/update_speed", hd.UpdateSpeed())
+				// - GET /vehicles/fuel_type/{type}
+				rt.Get("/fuel_type/{type}", hd.GetByFuelType())
+				// - GET /vehicles/{id}
+				rt.Get("/{id}", hd.GetByID())
+				// - PUT /vehicles/{id}
+				rt.Put("/{id}", hd.Replace())
+				// DELETE /vehicles/{id}?hard={true|false}
+				rt.Delete("/{id}", hd.Delete())
+				// - GET /vehicles/transmission/{type}
+				rt.Get("/transmission/{type}", hd.GetByTransmission())
+				// - PATCH /vehicles/{id}/update_fuel
+				rt.Patch("/{id}/update_fuel", hd.UpdateFuel())
+				// - GET /vehicles/dimensions?length={min_length}-{max_length}&width={min_width}-{max_width}
+				rt.Get("/dimensions", hd.GetByDimensions())
+				// - GET /vehicles/weight?min={weight_min}&max={weight_max}
+				rt.Get("/weight", hd.GetByWeight())
+				// - GET /vehicles/stats?group_by={fields}&metrics={metrics}&as_of={RFC 3339 time}
+				rt.Get("/stats", hd.GetStats())
+			})
+			// - GET /vehicles/average_speed/brand/{brand}
+			rt.Get("/average_speed/brand/{brand}", hd.GetAverageSpeedByBrand())
+			// - GET /vehicles/trash
+			rt.Get("/trash", hd.GetTrash())
+			// - POST /vehicles/{id}/restore
+			rt.Post("/{id}/restore", hd.Restore())
+			// - GET /vehicles/{id}/history
+			rt.Get("/{id}/history", hdAudit.GetByVehicle())
+			// - GET /vehicles/average_capacity/brand/{brand}
+			rt.Get("/average_capacity/brand/{brand}", hd.GetAverageCapacityByBrand())
+			// - GET /vehicles/summary
+			rt.Get("/summary", hd.GetSummary())
+			// - GET /vehicles/search?q={text}&limit={limit}
+			rt.Get("/search", hd.Search())
+			// - GET /vehicles/events?brand={brand}&... as Server-Sent Events
+			rt.Get("/events", hdEvent.Stream())
+			// - GET /vehicles/ws upgraded to the websocket API, whose updates require the operator role
+			rt.Get("/ws", hdSocket.Serve())
+
+		})
+
+		rt.Route("/snapshots", func(rt chi.Router) {
+			// - GET, POST /snapshots
+			rt.Get("/", hd.GetSnapshots())
+			rt.Post("/", hd.CreateSnapshot())
+			// - GET /snapshots/{name}
+			rt.Get("/{name}", hd.GetSnapshot())
+		})
+
+		rt.Route("/webhooks", func(rt chi.Router) {
+			// - GET, POST /webhooks
+			rt.Get("/", hdWebhook.GetAll())
+			rt.Post("/", hdWebhook.Create())
+			// - GET /webhooks/dead-letters
+			rt.Get("/dead-letters", hdWebhook.GetDeadLetters())
+			// - GET, PUT, DELETE /webhooks/{id}
+			rt.Get("/{id}", hdWebhook.GetByID())
+			rt.Put("/{id}", hdWebhook.Update())
+			rt.Delete("/{id}", hdWebhook.Delete())
+			// - GET /webhooks/{id}/deliveries
+			rt.Get("/{id}/deliveries", hdWebhook.GetDeliveries())
+		})
 
+		// - GET /audit?since={RFC 3339 time}&actor={actor}
+		rt.Get("/audit", hdAudit.Get())
+		rt.Route("/catalog", func(rt chi.Router) {
+			// - GET, POST /catalog/brands and DELETE /catalog/brands/{value}
+			rt.Get("/brands", hdCatalog.GetAll(internal.CatalogBrands))
+			rt.Post("/brands", hdCatalog.Create(internal.CatalogBrands))
+			rt.Delete("/brands/{value}", hdCatalog.Delete(internal.CatalogBrands))
+			// - GET, POST /catalog/brands/{brand}/models and DELETE /catalog/brands/{brand}/models/{model}
+			rt.Get("/brands/{brand}/models", hdCatalog.GetModels())
+			rt.Post("/brands/{brand}/models", hdCatalog.CreateModel())
+			rt.Delete("/brands/{brand}/models/{model}", hdCatalog.DeleteModel())
+			// - GET, POST /catalog/colors and DELETE /catalog/colors/{value}
+			rt.Get("/colors", hdCatalog.GetAll(internal.CatalogColors))
+			rt.Post("/colors", hdCatalog.Create(internal.CatalogColors))
+			rt.Delete("/colors/{value}", hdCatalog.Delete(internal.CatalogColors))
+			// - GET, POST /catalog/fuel-types and DELETE /catalog/fuel-types/{value}
+			rt.Get("/fuel-types", hdCatalog.GetAll(internal.CatalogFuelTypes))
+			rt.Post("/fuel-types", hdCatalog.Create(internal.CatalogFuelTypes))
+			rt.Delete("/fuel-types/{value}", hdCatalog.Delete(internal.CatalogFuelTypes))
+			// - GET, POST /catalog/transmissions and DELETE /catalog/transmissions/{value}
+			rt.Get("/transmissions", hdCatalog.GetAll(internal.CatalogTransmissions))
+			rt.Post("/transmissions", hdCatalog.Create(internal.CatalogTransmissions))
+			rt.Delete("/transmissions/{value}", hdCatalog.Delete(internal.CatalogTransmissions))
+		})
+	}
+	// - endpoints of the version 2
+	v2 := func(rt chi.Router) {
+		rt.Route("/vehicles", func(rt chi.Router) {
+			// - GET /v2/vehicles?{filters}&as_of={RFC 3339 time}&limit={limit}&offset={offset}
+			rt.Get("/", hd.ListV2())
+			// - POST /v2/vehicles and /v2/vehicles/batch with an optional Idempotency-Key header
+			rt.With(hdIdempotency.Handle).Post("/", hd.CreateV2())
+			rt.With(hdIdempotency.Handle).Post("/batch", hd.CreateMultipleV2())
+			// - GET /v2/vehicles/stats?{filters}&group_by={fields}&metrics={metrics}&as_of={RFC 3339 time}
+			rt.Get("/stats", hd.GetStatsV2())
+			// - GET, PUT, PATCH, DELETE /v2/vehicles/{id}
+			rt.Get("/{id}", hd.GetByIDV2())
+			rt.Put("/{id}", hd.ReplaceV2())
+			rt.Patch("/{id}", hd.PatchV2())
+			rt.Delete("/{id}", hd.DeleteV2())
+		})
+	}
+	// - GET /graphiql, the IDE of the GraphQL API when enabled, open since the credentials are sent by the queries it makes
+	if a.graphiQL {
+		rt.Get("/graphiql", hdGraphQL.GraphiQL())
+	}
+	// - endpoints of the API, authenticated, the REST ones also authorized by the role of their method
+	rt.Group(func(rt chi.Router) {
+		rt.Use(hdRateLimit.LimitIP)
+		rt.Use(authenticate)
+		rt.Use(hdRateLimit.Limit)
+		rt.Use(hdTenant.Resolve)
+		rt.With(authorize).Route("/v1", v1)
+		rt.With(authorize).Group(v1)
+		rt.With(authorize).Route("/v2", v2)
+		// - GET, POST /graphql
+		rt.Handle("/graphql", hdGraphQL.Serve())
 	})
 
-	// run server
-	err = http.ListenAndServe(a.serverAddress, rt)
+	h = rt
 	return
 }
+
+// tenantPath is a function that returns the path of a file of a tenant, next to the file of the default tenant
+func tenantPath(path string, tenant string) string {
+	if tenant == internal.DefaultTenant {
+		return path
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + tenant + ext
+}
+
+// purgeTrash is a method that periodically deletes for good the vehicles kept in the trash longer than the retention,
+// until ctx is done
+func (a *ServerChi) purgeTrash(ctx context.Context, sv internal.VehicleService, logger *slog.Logger) {
+	sv = sv.WithActor("system")
+	ticker := time.NewTicker(min(a.trashRetention, time.Hour))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if _, err := sv.PurgeTrash(time.Now().Add(-a.trashRetention)); err != nil {
+			logger.Error("purge the trash", "retention", a.trashRetention, "error", err)
+		}
+	}
+}
+
+// purgeHistory is a method that periodically forgets the past of the vehicles older than the retention, until ctx is done
+func (a *ServerChi) purgeHistory(ctx context.Context, sv internal.VehicleService, logger *slog.Logger) {
+	ticker := time.NewTicker(min(a.historyRetention, time.Hour))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := sv.PurgeHistory(time.Now().Add(-a.historyRetention)); err != nil {
+			logger.Error("purge the history", "retention", a.historyRetention, "error", err)
+		}
+	}
+}
+
+// purgeIdempotencyKeys is a method that periodically forgets the responses of the expired idempotency keys, until ctx is done
+func (a *ServerChi) purgeIdempotencyKeys(ctx context.Context, sv *service.IdempotencyDefault, logger *slog.Logger) {
+	ticker := time.NewTicker(min(a.idempotencyTTL, time.Hour))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := sv.PurgeExpired(); err != nil {
+			logger.Error("purge the expired idempotency keys", "ttl", a.idempotencyTTL, "error", err)
+		}
+	}
+}
+
+// syncQuotas is a method that saves the counters of the daily quotas every second, until ctx is done
+func (a *ServerChi) syncQuotas(ctx context.Context, rp *repository.QuotaFile, logger *slog.Logger) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := rp.Sync(); err != nil {
+			logger.Error("save the quotas", "path", a.quotaFilePath, "error", err)
+		}
+	}
+}
+
+// maintainWAL is a method that periodically syncs the write-ahead log, with the interval policy, and compacts it,
+// until ctx is done
+func (a *ServerChi) maintainWAL(ctx context.Context, wal *repository.VehicleWAL, logger *slog.Logger) {
+	sync := time.NewTicker(a.walSyncInterval)
+	defer sync.Stop()
+	compact := time.NewTicker(a.compactInterval)
+	defer compact.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sync.C:
+			if a.walSync != repository.WALSyncInterval {
+				continue
+			}
+			if err := wal.Sync(); err != nil {
+				logger.Error("sync the write-ahead log", "error", err)
+			}
+		case <-compact.C:
+			if err := wal.Compact(); err != nil {
+				logger.Error("compact the write-ahead log", "error", err)
+			}
+		}
+	}
+}
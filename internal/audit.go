@@ -0,0 +1,115 @@
+package internal
+
+import (
+	"time"
+)
+
+// Audit operations
+const (
+	// AuditCreate is the operation of a vehicle created
+	AuditCreate = "create"
+	// AuditUpdate is the operation of some fields of a vehicle updated
+	AuditUpdate = "update"
+	// AuditReplace is the operation of every field of a vehicle replaced
+	AuditReplace = "replace"
+	// AuditDelete is the operation of a vehicle moved to the trash
+	AuditDelete = "delete"
+	// AuditRestore is the operation of a vehicle restored from the trash
+	AuditRestore = "restore"
+	// AuditPurge is the operation of a vehicle deleted for good
+	AuditPurge = "purge"
+)
+
+// FieldChange is a struct that represents the value of a field before and after a write
+type FieldChange struct {
+	// Before is the value before the write, nil for a create
+	Before any `json:"before"`
+	// After is the value after the write, nil for a delete
+	After any `json:"after"`
+}
+
+// AuditEntry is a struct that represents a write made on a vehicle
+type AuditEntry struct {
+	// Tenant is the tenant of the vehicle written, empty for the entries written before tenants and read as the default one
+	Tenant string `json:"tenant,omitempty"`
+	// Timestamp is the moment of the write
+	Timestamp time.Time `json:"timestamp"`
+	// Actor is who made the write
+	Actor string `json:"actor"`
+	// Operation is the kind of write
+	Operation string `json:"operation"`
+	// VehicleID is the id of the vehicle written
+	VehicleID int `json:"vehicle_id"`
+	// Changes is the change of each field that differs, by JSON name
+	Changes map[string]FieldChange `json:"changes"`
+}
+
+// AuditRepository is an interface that represents an append-only repository of audit entries
+type AuditRepository interface {
+	// Append is a method that adds an entry to the log
+	Append(e AuditEntry) (err error)
+	// FindByVehicle is a method that returns the entries of a vehicle of a tenant, oldest first
+	FindByVehicle(tenant string, id int) (entries []AuditEntry, err error)
+	// Find is a method that returns the entries of a tenant since a moment, of an actor if not empty, oldest first
+	Find(tenant string, since time.Time, actor string) (entries []AuditEntry, err error)
+}
+
+// AuditService is an interface that represents a service of audit entries
+type AuditService interface {
+	// FindByVehicle is a method that returns the entries of a vehicle of a tenant, oldest first
+	FindByVehicle(tenant string, id int) (entries []AuditEntry, err error)
+	// Find is a method that returns the entries of a tenant since a moment, of an actor if not empty, oldest first
+	Find(tenant string, since time.Time, actor string) (entries []AuditEntry, err error)
+}
+
+// ErrAuditEntriesNotFound is the error returned when no audit entry matches
+var ErrAuditEntriesNotFound = NewError("audit_entries_not_found")
+
+// EntryTenant is a method that returns the tenant of the entry, the default one when empty
+func (e AuditEntry) EntryTenant() string {
+	if e.Tenant == "" {
+		return DefaultTenant
+	}
+	return e.Tenant
+}
+
+// Fields is a method that returns the attributes of the vehicle by JSON name
+func (v Vehicle) Fields() map[string]any {
+	return map[string]any{
+		"id":           v.Id,
+		"brand":        v.Brand,
+		"model":        v.Model,
+		"registration": v.Registration,
+		"color":        v.Color,
+		"year":         v.FabricationYear,
+		"passengers":   v.Capacity,
+		"max_speed":    v.MaxSpeed,
+		"fuel_type":    v.FuelType,
+		"transmission": v.Transmission,
+		"weight":       v.Weight,
+		"height":       v.Height,
+		"length":       v.Length,
+		"width":        v.Width,
+	}
+}
+
+// Diff is a function that returns the change of each field that differs between two versions of a vehicle,
+// before is nil for a create and after is nil for a delete
+func Diff(before *Vehicle, after *Vehicle) (changes map[string]FieldChange) {
+	changes = make(map[string]FieldChange)
+	var b, a map[string]any
+	if before != nil {
+		b = before.Fields()
+	}
+	if after != nil {
+		a = after.Fields()
+	}
+	for _, fields := range []map[string]any{b, a} {
+		for field := range fields {
+			if b[field] != a[field] {
+				changes[field] = FieldChange{Before: b[field], After: a[field]}
+			}
+		}
+	}
+	return
+}
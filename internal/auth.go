@@ -0,0 +1,78 @@
+package internal
+
+import (
+	"crypto/rsa"
+)
+
+// Role is a string that represents the permissions of a caller of the API
+type Role string
+
+// Roles, each one allows everything the previous one does
+const (
+	// RoleViewer is the role that reads vehicles
+	RoleViewer Role = "viewer"
+	// RoleOperator is the role that also updates the speed and fuel of vehicles
+	RoleOperator Role = "operator"
+	// RoleAdmin is the role that also creates, replaces and deletes vehicles and manages the rest of the API
+	RoleAdmin Role = "admin"
+)
+
+// roleLevels is the level of each role, higher allows more
+var roleLevels = map[Role]int{
+	RoleViewer:   1,
+	RoleOperator: 2,
+	RoleAdmin:    3,
+}
+
+// Valid is a method that reports whether the role is a known one
+func (r Role) Valid() bool {
+	_, ok := roleLevels[r]
+	return ok
+}
+
+// Allows is a method that reports whether the role has the permissions of another one
+func (r Role) Allows(required Role) bool {
+	return roleLevels[r] >= roleLevels[required]
+}
+
+// Principal is a struct that represents an authenticated caller of the API
+type Principal struct {
+	// Name is the name of the API key or the subject of the token
+	Name string
+	// Role is the role of the caller
+	Role Role
+	// Tenant is the tenant the caller is bound to, empty for a caller that picks any tenant
+	Tenant string
+}
+
+// APIKey is a struct that represents an API key, kept as the hex SHA-256 hash of the key
+type APIKey struct {
+	// Name is the name of the key, recorded as the actor of its writes
+	Name string
+	// Hash is the hex SHA-256 hash of the key
+	Hash string
+	// Role is the role of the key
+	Role Role
+	// Tenant is the tenant the key is bound to, empty for a key of every tenant
+	Tenant string
+}
+
+// AuthConfig is a struct that represents the credentials accepted by the API
+type AuthConfig struct {
+	// APIKeys is the list of API keys
+	APIKeys []APIKey
+	// HS256Secret is the secret of the HS256 bearer tokens, nil to reject them
+	HS256Secret []byte
+	// RS256PublicKey is the public key of the RS256 bearer tokens, nil to reject them
+	RS256PublicKey *rsa.PublicKey
+	// Issuer is the iss claim required of the tokens, empty for any
+	Issuer string
+	// Audience is the aud claim required of the tokens, empty for any
+	Audience string
+}
+
+// Var for the errors of the authentication
+var (
+	ErrUnauthenticated = NewError("unauthenticated")
+	ErrForbidden       = NewError("forbidden")
+)
@@ -0,0 +1,60 @@
+package internal
+
+// Catalog names, as used in the routes /catalog/{catalog}
+const (
+	// CatalogBrands is the catalog of vehicle brands
+	CatalogBrands = "brands"
+	// CatalogColors is the catalog of vehicle colors
+	CatalogColors = "colors"
+	// CatalogFuelTypes is the catalog of vehicle fuel types
+	CatalogFuelTypes = "fuel-types"
+	// CatalogTransmissions is the catalog of vehicle transmissions
+	CatalogTransmissions = "transmissions"
+)
+
+// CatalogAttributes is the vehicle attribute each catalog constrains, by catalog name
+var CatalogAttributes = map[string]string{
+	CatalogBrands:        "brand",
+	CatalogColors:        "color",
+	CatalogFuelTypes:     "fuel_type",
+	CatalogTransmissions: "transmission",
+}
+
+// CatalogRepository is an interface that represents a repository of reference catalogs
+type CatalogRepository interface {
+	// FindAll is a method that returns the values of a catalog
+	FindAll(catalog string) (values []string, err error)
+	// Exists is a method that reports whether a value is in a catalog
+	Exists(catalog string, value string) (ok bool, err error)
+	// Create is a method that adds a value to a catalog
+	Create(catalog string, value string) (err error)
+	// Delete is a method that removes a value from a catalog
+	Delete(catalog string, value string) (err error)
+	// FindModels is a method that returns the models of a brand
+	FindModels(brand string) (models []string, err error)
+	// ExistsModel is a method that reports whether a model is in the catalog of a brand
+	ExistsModel(brand string, model string) (ok bool, err error)
+	// CreateModel is a method that adds a model to the catalog of a brand
+	CreateModel(brand string, model string) (err error)
+	// DeleteModel is a method that removes a model from the catalog of a brand
+	DeleteModel(brand string, model string) (err error)
+}
+
+// CatalogService is an interface that represents a service of reference catalogs
+type CatalogService interface {
+	CatalogRepository
+	// Check is a method that validates the categorical attributes of a vehicle against the catalogs,
+	// letting the unknown values through when the service is lenient
+	Check(v Vehicle) (err error)
+	// Register is a method that adds the unknown categorical attributes of a vehicle, once written, to the catalogs
+	// when the service is lenient
+	Register(v Vehicle) (err error)
+}
+
+// Var for the errors of the catalogs
+var (
+	ErrCatalogNotFound           = NewError("catalog_not_found")
+	ErrCatalogValueNotFound      = NewError("catalog_value_not_found")
+	ErrCatalogValueAlreadyExists = NewError("catalog_value_already_exists")
+	ErrVehicleNotInCatalog       = NewError("vehicle_not_in_catalog")
+)
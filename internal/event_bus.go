@@ -0,0 +1,208 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// DomainEvent is an interface that represents an event published on the bus after a successful write
+type DomainEvent interface {
+	// EventVehicleID is a method that returns the id of the vehicle written, the events of a vehicle keep their order
+	EventVehicleID() int
+	// Meta is a method that returns the data common to every event
+	Meta() EventMeta
+}
+
+// EventMeta is a struct that represents the data common to every event
+type EventMeta struct {
+	// Tenant is the tenant of the vehicle written
+	Tenant string
+	// Timestamp is the moment of the write
+	Timestamp time.Time
+	// Actor is who made the write
+	Actor string
+	// Operation is the kind of write, one of the audit operations
+	Operation string
+}
+
+// Meta is a method that returns the data common to every event
+func (m EventMeta) Meta() EventMeta { return m }
+
+// VehicleCreated is a struct that represents a vehicle created
+type VehicleCreated struct {
+	EventMeta
+	// Vehicle is the vehicle created
+	Vehicle Vehicle
+}
+
+// VehicleRestored is a struct that represents a vehicle moved back from the trash
+type VehicleRestored struct {
+	EventMeta
+	// Vehicle is the vehicle restored
+	Vehicle Vehicle
+}
+
+// VehicleUpdated is a struct that represents some or every field of a vehicle updated
+type VehicleUpdated struct {
+	EventMeta
+	// Before is the vehicle before the write
+	Before Vehicle
+	// After is the vehicle after the write
+	After Vehicle
+	// Changes is the change of each field that differs, by JSON name
+	Changes map[string]FieldChange
+}
+
+// VehicleDeleted is a struct that represents a vehicle moved to the trash, or deleted for good
+type VehicleDeleted struct {
+	EventMeta
+	// Vehicle is the vehicle deleted
+	Vehicle Vehicle
+}
+
+// EventVehicleID is a method that returns the id of the vehicle created
+func (e VehicleCreated) EventVehicleID() int { return e.Vehicle.Id }
+
+// EventVehicleID is a method that returns the id of the vehicle restored
+func (e VehicleRestored) EventVehicleID() int { return e.Vehicle.Id }
+
+// EventVehicleID is a method that returns the id of the vehicle updated
+func (e VehicleUpdated) EventVehicleID() int { return e.After.Id }
+
+// EventVehicleID is a method that returns the id of the vehicle deleted
+func (e VehicleDeleted) EventVehicleID() int { return e.Vehicle.Id }
+
+// NewEventBus is a function that returns a new instance of EventBus, whose asynchronous subscribers
+// run on shards workers each, and whose asynchronous errors go to onError, logged when nil
+func NewEventBus(shards int, onError func(err error)) *EventBus {
+	if onError == nil {
+		onError = func(err error) { slog.Error("event bus", "error", err) }
+	}
+	return &EventBus{shards: shards, onError: onError}
+}
+
+// EventBus is a struct that represents a typed, in-process bus of domain events.
+// Synchronous subscribers run in the order they subscribed before Publish returns; asynchronous subscribers
+// run in the background, on one worker per shard of vehicle ids so the events of a vehicle keep their order.
+// A subscriber that fails or panics does not keep the others from running, and an asynchronous one
+// that falls behind misses the events its queue has no room for
+type EventBus struct {
+	// mu is the lock of the subscribers
+	mu sync.RWMutex
+	// subscribers is the list of synchronous subscribers
+	subscribers []func(e DomainEvent) error
+	// background is the list of asynchronous subscribers
+	background []*asyncSubscriber
+	// shards is the number of workers of each asynchronous subscriber
+	shards int
+	// onError is the handler of the errors of the asynchronous subscribers
+	onError func(err error)
+	// wg is the group of the workers of the asynchronous subscribers
+	wg sync.WaitGroup
+}
+
+// asyncSubscriber is a struct that represents a subscriber run in the background
+type asyncSubscriber struct {
+	// queues is the queue of events of each worker
+	queues []chan DomainEvent
+}
+
+// typed is a function that returns a handler of any event calling fn for the events of type T only
+func typed[T DomainEvent](fn func(e T) error) func(e DomainEvent) error {
+	return func(e DomainEvent) error {
+		if t, ok := e.(T); ok {
+			return fn(t)
+		}
+		return nil
+	}
+}
+
+// Subscribe is a function that adds a synchronous subscriber to the events of type T,
+// DomainEvent for every event. Its errors are returned by Publish
+func Subscribe[T DomainEvent](b *EventBus, fn func(e T) error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subscribers = append(b.subscribers, typed(fn))
+}
+
+// SubscribeAsync is a function that adds an asynchronous subscriber to the events of type T,
+// DomainEvent for every event. Its errors go to the error handler of the bus
+func SubscribeAsync[T DomainEvent](b *EventBus, fn func(e T) error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	handle := typed(fn)
+	s := &asyncSubscriber{queues: make([]chan DomainEvent, b.shards)}
+	for i := range s.queues {
+		queue := make(chan DomainEvent, 256)
+		s.queues[i] = queue
+		b.wg.Add(1)
+		go func() {
+			defer b.wg.Done()
+			for e := range queue {
+				if err := call(handle, e); err != nil {
+					b.onError(err)
+				}
+			}
+		}()
+	}
+	b.background = append(b.background, s)
+}
+
+// Publish is a method that delivers an event to the subscribers, queueing it for the asynchronous ones
+// and then waiting for the synchronous ones, which run without the lock of the bus.
+// An asynchronous subscriber whose queue is full misses the event, reported to the error handler
+func (b *EventBus) Publish(e DomainEvent) (err error) {
+	b.mu.RLock()
+	subscribers := b.subscribers
+	shard := e.EventVehicleID() % b.shards
+	if shard < 0 {
+		shard += b.shards
+	}
+	for _, s := range b.background {
+		select {
+		case s.queues[shard] <- e:
+		default:
+			b.onError(fmt.Errorf("event queue full, %T of vehicle %d dropped", e, e.EventVehicleID()))
+		}
+	}
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, handle := range subscribers {
+		if handleErr := call(handle, e); handleErr != nil {
+			errs = append(errs, handleErr)
+		}
+	}
+	err = errors.Join(errs...)
+	return
+}
+
+// Close is a method that waits for the asynchronous subscribers to handle the events queued, nothing is published after
+func (b *EventBus) Close() {
+	b.mu.Lock()
+	for _, s := range b.background {
+		for _, queue := range s.queues {
+			close(queue)
+		}
+	}
+	b.background = nil
+	b.mu.Unlock()
+
+	b.wg.Wait()
+}
+
+// call is a function that calls a subscriber, turning its panic into an error
+func call(handle func(e DomainEvent) error, e DomainEvent) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("event subscriber panicked: %v", r)
+		}
+	}()
+	err = handle(e)
+	return
+}
@@ -0,0 +1,53 @@
+package internal
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEventBus_Publish(t *testing.T) {
+	dropped := make(chan error, 1)
+	b := NewEventBus(1, func(err error) {
+		select {
+		case dropped <- err:
+		default:
+		}
+	})
+	defer b.Close()
+
+	// a slow asynchronous subscriber does not block the writes, it misses the events its queue has no room for
+	release := make(chan struct{})
+	SubscribeAsync(b, func(e VehicleCreated) error {
+		<-release
+		return nil
+	})
+	defer close(release)
+	// a synchronous subscriber runs without the lock of the bus, so it may subscribe in turn
+	Subscribe(b, func(e VehicleCreated) error {
+		Subscribe(b, func(e VehicleDeleted) error { return nil })
+		return errors.New("failed")
+	})
+
+	done := make(chan error)
+	go func() {
+		var err error
+		for i := 0; i < 300; i++ {
+			err = b.Publish(VehicleCreated{Vehicle: Vehicle{Id: 1}})
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil || err.Error() != "failed" {
+			t.Errorf("got %v, want the error of the synchronous subscriber", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("publish blocked")
+	}
+	select {
+	case <-dropped:
+	default:
+		t.Error("got no error, want the events dropped reported")
+	}
+}
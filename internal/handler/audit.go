@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"app/internal"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/bootcamp-go/web/response"
+)
+
+// actor is a function that returns who makes a request: the authenticated caller, or else, when the API is open,
+// the X-Actor header. With authentication the header is ignored, so a caller cannot write on behalf of another
+func actor(r *http.Request) string {
+	if p, ok := principal(r); ok {
+		return p.Name
+	}
+	if a := r.Header.Get("X-Actor"); a != "" && open(r) {
+		return a
+	}
+	return "anonymous"
+}
+
+// NewAuditDefault is a function that returns a new instance of AuditDefault,
+// resolving the string ids of the vehicles with svVehicle
+func NewAuditDefault(sv internal.AuditService, svVehicle internal.VehicleTenantService) *AuditDefault {
+	return &AuditDefault{sv: sv, vehicles: NewVehicleDefault(svVehicle)}
+}
+
+// AuditDefault is a struct with methods that represent handlers for the audit log
+type AuditDefault struct {
+	// sv is the service that will be used by the handler
+	sv internal.AuditService
+	// vehicles is the handler of the vehicles that resolves their ids
+	vehicles *VehicleDefault
+}
+
+// GetByVehicle is a method that returns a handler for the route GET /vehicles/{id}/history
+func (h *AuditDefault) GetByVehicle() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - get id from url, an integer id or the string id of a vehicle in the store or the trash
+		id, err := h.vehicles.vehicleID(r)
+		if err != nil {
+			code := http.StatusBadRequest
+			if errors.Is(err, internal.ErrVehicleNotFound) {
+				code = http.StatusNotFound
+			}
+			writeError(w, r, code, err)
+			return
+		}
+
+		// process
+		// - get entries of the vehicle
+		entries, err := h.sv.FindByVehicle(tenant(r), id)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    entries,
+		})
+	}
+}
+
+// Get is a method that returns a handler for the route GET /audit?since={RFC 3339 time}&actor={actor}
+func (h *AuditDefault) Get() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - get query params
+		var since time.Time
+		if value := r.URL.Query().Get("since"); value != "" {
+			var err error
+			since, err = time.Parse(time.RFC3339, value)
+			if err != nil {
+				writeError(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, internal.NewError("parameter_time", "since")))
+				return
+			}
+		}
+		actor := r.URL.Query().Get("actor")
+
+		// process
+		// - get entries
+		entries, err := h.sv.Find(tenant(r), since, actor)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    entries,
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"app/internal"
+	"app/internal/repository"
+	"app/internal/service"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestActor(t *testing.T) {
+	served := func(mw func(http.Handler) http.Handler, r *http.Request) (got string) {
+		mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { got = actor(r) })).ServeHTTP(httptest.NewRecorder(), r)
+		return
+	}
+	r := httptest.NewRequest(http.MethodPost, "/vehicles", nil)
+	r.Header.Set("X-Actor", "alice")
+
+	// an open API trusts the header
+	if got := served(Open, r); got != "alice" {
+		t.Errorf("got %s, want alice", got)
+	}
+
+	// an authenticated one records the caller and ignores the header, even on a route open to anyone
+	authenticated := r.WithContext(context.WithValue(r.Context(), principalKey{}, internal.Principal{Name: "bob", Role: internal.RoleAdmin}))
+	if got := actor(authenticated); got != "bob" {
+		t.Errorf("got %s, want bob", got)
+	}
+	if got := actor(r); got != "anonymous" {
+		t.Errorf("got %s, want anonymous", got)
+	}
+}
+
+func TestAuditDefault_GetByVehicle(t *testing.T) {
+	db := map[int]internal.Vehicle{
+		1: {Id: 1, UID: "01hv1", VehicleAttributes: internal.VehicleAttributes{Brand: "Ford", MaxSpeed: 180}},
+		2: {Id: 2, UID: "01hv2", VehicleAttributes: internal.VehicleAttributes{Brand: "Fiat", MaxSpeed: 150}},
+	}
+	rpAudit, err := repository.NewAuditFile(filepath.Join(t.TempDir(), "audit.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	svAudit := service.NewAuditDefault(rpAudit)
+	bus := internal.NewEventBus(1, nil)
+	defer bus.Close()
+	internal.Subscribe(bus, svAudit.Record)
+	svVehicle := service.NewVehicleDefault(repository.NewVehicleMap(db), nil, bus).WithTenant(internal.DefaultTenant)
+	sv := service.NewVehicleTenants(map[string]internal.VehicleService{internal.DefaultTenant: svVehicle})
+
+	// vehicle 1 is written twice and vehicle 2 once, then moved to the trash
+	for _, speed := range []float64{190, 200} {
+		if _, err = svVehicle.Update(1, map[string]any{"speed": speed}, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = svVehicle.Update(2, map[string]any{"speed": 160.0}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err = svVehicle.Delete(2, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	rt := chi.NewRouter()
+	rt.Get("/vehicles/{id}/history", NewAuditDefault(svAudit, sv).GetByVehicle())
+	cases := []struct {
+		name    string
+		id      string
+		code    int
+		entries int
+	}{
+		{name: "id", id: "1", code: http.StatusOK, entries: 2},
+		{name: "uid", id: "01hv1", code: http.StatusOK, entries: 2},
+		{name: "uid in the trash", id: "01hv2", code: http.StatusOK, entries: 2},
+		{name: "unknown uid", id: "01hv3", code: http.StatusNotFound},
+		{name: "unknown id", id: "3", code: http.StatusNotFound},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			rt.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/vehicles/"+c.id+"/history", nil))
+			if res.Code != c.code {
+				t.Fatalf("got status %d, want %d: %s", res.Code, c.code, res.Body)
+			}
+			var body struct {
+				Data []internal.AuditEntry `json:"data"`
+			}
+			if err := json.Unmarshal(res.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if len(body.Data) != c.entries {
+				t.Errorf("got %d entries, want %d", len(body.Data), c.entries)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"app/internal"
+	"app/platform/jwt"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ProblemJSON is a struct that represents an error response in the RFC 9457 problem details format
+type ProblemJSON struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail"`
+	Code   string `json:"code"`
+}
+
+// principalKey is the key of the principal in the context of a request
+type principalKey struct{}
+
+// principal is a function that returns the authenticated caller of a request, false when the request is not authenticated
+func principal(r *http.Request) (p internal.Principal, ok bool) {
+	p, ok = r.Context().Value(principalKey{}).(internal.Principal)
+	return
+}
+
+// openKey is the key of the context of a request that marks it as handled by an API without authentication
+type openKey struct{}
+
+// open is a function that reports whether a request is handled by an API without authentication
+func open(r *http.Request) bool {
+	_, ok := r.Context().Value(openKey{}).(bool)
+	return ok
+}
+
+// Open is a middleware that marks the requests of an API without authentication, whose callers may name
+// themselves with the X-Actor header
+func Open(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), openKey{}, true)))
+	})
+}
+
+// problem is a function that writes a problem details response of an error, detailed in the language of the request
+func problem(w http.ResponseWriter, r *http.Request, status int, err error) {
+	lang := language(r)
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("Content-Language", lang)
+	w.Header().Add("Vary", "Accept-Language")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ProblemJSON{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: localize(err, lang),
+		Code:   errorCode(err, status),
+	})
+}
+
+// NewAuthDefault is a function that returns a new instance of AuthDefault
+func NewAuthDefault(cfg internal.AuthConfig) *AuthDefault {
+	return &AuthDefault{
+		keys: cfg.APIKeys,
+		jwt: jwt.Keys{
+			HS256:    cfg.HS256Secret,
+			RS256:    cfg.RS256PublicKey,
+			Issuer:   cfg.Issuer,
+			Audience: cfg.Audience,
+			Leeway:   time.Minute,
+		},
+	}
+}
+
+// AuthDefault is a struct with methods that represent middlewares authenticating and authorizing the callers of the API
+type AuthDefault struct {
+	// keys is the list of API keys accepted
+	keys []internal.APIKey
+	// jwt is the keys the bearer tokens are verified with
+	jwt jwt.Keys
+}
+
+// authenticate is a method that returns the caller of a request, from the X-API-Key header or a bearer token
+func (h *AuthDefault) authenticate(r *http.Request) (p internal.Principal, err error) {
+	// api key
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		sum := sha256.Sum256([]byte(key))
+		hash := hex.EncodeToString(sum[:])
+		for _, k := range h.keys {
+			if subtle.ConstantTimeCompare([]byte(strings.ToLower(k.Hash)), []byte(hash)) == 1 {
+				p = internal.Principal{Name: k.Name, Role: k.Role, Tenant: k.Tenant}
+				return
+			}
+		}
+		err = internal.ErrUnauthenticated
+		return
+	}
+
+	// bearer token, the name is the claim sub, the role the claim role and the tenant the claim tenant
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		err = internal.ErrUnauthenticated
+		return
+	}
+	claims, err := jwt.Verify(strings.TrimSpace(token), h.jwt, time.Now())
+	if err != nil {
+		return
+	}
+	p = internal.Principal{Name: claims.String("sub"), Role: internal.Role(claims.String("role")), Tenant: claims.String("tenant")}
+	if p.Name == "" || !p.Role.Valid() {
+		err = internal.ErrUnauthenticated
+	}
+	return
+}
+
+// Authenticate is a method that returns a middleware rejecting with 401 the requests without valid credentials
+func (h *AuthDefault) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, err := h.authenticate(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="vehicles"`)
+			problem(w, r, http.StatusUnauthorized, internal.ErrUnauthenticated)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, p)))
+	})
+}
+
+// methodRole is a function that returns the role required by the method of a request:
+// viewer to read, operator to update with PATCH, admin to create, replace and delete
+func methodRole(method string) internal.Role {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return internal.RoleViewer
+	case http.MethodPatch:
+		return internal.RoleOperator
+	default:
+		return internal.RoleAdmin
+	}
+}
+
+// Authorize is a method that returns a middleware rejecting with 403 the requests whose caller lacks the role of their method
+func (h *AuthDefault) Authorize(next http.Handler) http.Handler {
+	return h.Require("")(next)
+}
+
+// Require is a method that returns a middleware rejecting with 403 the requests whose caller lacks a role,
+// empty for the role of their method
+func (h *AuthDefault) Require(role internal.Role) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			required := role
+			if required == "" {
+				required = methodRole(r.Method)
+			}
+			p, ok := principal(r)
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="vehicles"`)
+				problem(w, r, http.StatusUnauthorized, internal.ErrUnauthenticated)
+				return
+			}
+			if !p.Role.Allows(required) {
+				problem(w, r, http.StatusForbidden, internal.ErrForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
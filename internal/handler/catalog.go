@@ -0,0 +1,246 @@
+package handler
+
+import (
+	"app/internal"
+	"app/platform/tools"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/bootcamp-go/web/response"
+	"github.com/go-chi/chi/v5"
+)
+
+// NewCatalogDefault is a function that returns a new instance of CatalogDefault
+func NewCatalogDefault(svs map[string]internal.CatalogService) *CatalogDefault {
+	return &CatalogDefault{svs: svs}
+}
+
+// CatalogDefault is a struct with methods that represent handlers for catalogs
+type CatalogDefault struct {
+	// svs is the catalog service of each tenant, by tenant id
+	svs map[string]internal.CatalogService
+}
+
+// service is a method that returns the catalog service of the tenant of a request,
+// failing with ErrTenantNotFound for a tenant the tenant middleware did not check
+func (h *CatalogDefault) service(r *http.Request) (sv internal.CatalogService, err error) {
+	sv, ok := h.svs[tenant(r)]
+	if !ok {
+		err = internal.ErrTenantNotFound
+	}
+	return
+}
+
+// catalogStatus is a function that returns the status code for an error of the catalogs
+func catalogStatus(err error) int {
+	switch {
+	case errors.Is(err, internal.ErrCatalogValueAlreadyExists):
+		return http.StatusConflict
+	case errors.Is(err, internal.ErrFieldsMissing):
+		return http.StatusBadRequest
+	}
+	return http.StatusNotFound
+}
+
+// readName is a function that reads the name of a catalog value from the body {"name": "..."}
+func readName(r *http.Request) (name string, err error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return
+	}
+	bodyMap := map[string]any{}
+	if err = json.Unmarshal(body, &bodyMap); err != nil {
+		return
+	}
+	if err = tools.ValidateField(bodyMap, "name"); err != nil {
+		err = errors.Join(internal.ErrFieldsMissing, err)
+		return
+	}
+	name, ok := bodyMap["name"].(string)
+	if !ok || name == "" {
+		err = errors.Join(internal.ErrFieldsMissing, &tools.FieldError{Field: "name", Msg: "must be a non empty string"})
+	}
+	return
+}
+
+// GetAll is a method that returns a handler for the route GET /catalog/{catalog}
+func (h *CatalogDefault) GetAll(catalog string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		// process
+		// - get values of the catalog
+		values, err := sv.FindAll(catalog)
+		if err != nil {
+			writeError(w, r, catalogStatus(err), err)
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    values,
+		})
+	}
+}
+
+// Create is a method that returns a handler for the route POST /catalog/{catalog}
+func (h *CatalogDefault) Create(catalog string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
+		// - read name from body
+		name, err := readName(r)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		// process
+		// - add value to the catalog
+		err = sv.Create(catalog, name)
+		if err != nil {
+			writeError(w, r, catalogStatus(err), err)
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusCreated, map[string]any{
+			"message": "success",
+			"data":    internal.Canonical(internal.CatalogAttributes[catalog], name),
+		})
+	}
+}
+
+// Delete is a method that returns a handler for the route DELETE /catalog/{catalog}/{value}
+func (h *CatalogDefault) Delete(catalog string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
+		// - get value from url
+		value := chi.URLParam(r, "value")
+
+		// process
+		// - remove value from the catalog
+		err = sv.Delete(catalog, value)
+		if err != nil {
+			writeError(w, r, catalogStatus(err), err)
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusNoContent, nil)
+	}
+}
+
+// GetModels is a method that returns a handler for the route GET /catalog/brands/{brand}/models
+func (h *CatalogDefault) GetModels() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
+		// - get brand from url
+		brand := chi.URLParam(r, "brand")
+
+		// process
+		// - get models of the brand
+		models, err := sv.FindModels(brand)
+		if err != nil {
+			writeError(w, r, catalogStatus(err), err)
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    models,
+		})
+	}
+}
+
+// CreateModel is a method that returns a handler for the route POST /catalog/brands/{brand}/models
+func (h *CatalogDefault) CreateModel() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
+		// - get brand from url and model from body
+		brand := chi.URLParam(r, "brand")
+		name, err := readName(r)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		// process
+		// - add model to the brand, which has to exist
+		if ok, _ := sv.Exists(internal.CatalogBrands, brand); !ok {
+			writeError(w, r, http.StatusNotFound, internal.ErrCatalogValueNotFound)
+			return
+		}
+		err = sv.CreateModel(brand, name)
+		if err != nil {
+			writeError(w, r, catalogStatus(err), err)
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusCreated, map[string]any{
+			"message": "success",
+			"data":    internal.Canonical("model", name),
+		})
+	}
+}
+
+// DeleteModel is a method that returns a handler for the route DELETE /catalog/brands/{brand}/models/{model}
+func (h *CatalogDefault) DeleteModel() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
+		// - get brand and model from url
+		brand := chi.URLParam(r, "brand")
+		model := chi.URLParam(r, "model")
+
+		// process
+		// - remove model from the brand
+		err = sv.DeleteModel(brand, model)
+		if err != nil {
+			writeError(w, r, catalogStatus(err), err)
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusNoContent, nil)
+	}
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// etag is a function that returns the entity tag of a vehicle version
+func etag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// ifMatch is a function that returns the version required by the If-Match header of a request:
+// 0 when there is no header or it is *, -1 when it does not name a version
+func ifMatch(r *http.Request) (version int) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		unquoted, err := strconv.Unquote(tag)
+		if err != nil {
+			continue
+		}
+		if version, err = strconv.Atoi(unquoted); err == nil && version > 0 {
+			return
+		}
+	}
+	return -1
+}
+
+// noneMatch is a function that reports whether the If-None-Match header of a request names the version
+func noneMatch(r *http.Request, version int) bool {
+	header := strings.TrimSpace(r.Header.Get("If-None-Match"))
+	if header == "" {
+		return false
+	}
+	if header == "*" {
+		return true
+	}
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == etag(version) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,751 @@
+package handler
+
+import (
+	"app/internal"
+	"app/platform/graphql"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bootcamp-go/web/response"
+)
+
+// maxGraphQLBodySize is the largest body of a GraphQL request, in bytes
+const maxGraphQLBodySize = 1 << 20
+
+// NewGraphQLDefault is a function that returns a new instance of GraphQLDefault
+func NewGraphQLDefault(sv internal.VehicleTenantService, limits graphql.Limits) *GraphQLDefault {
+	h := &GraphQLDefault{sv: sv, limits: limits}
+	schema, err := graphql.NewSchema(h.query(), h.mutation())
+	if err != nil {
+		panic(err)
+	}
+	h.schema = schema
+	return h
+}
+
+// GraphQLDefault is a struct with methods that represent handlers for the GraphQL API of the vehicles
+type GraphQLDefault struct {
+	// sv is the service of the tenants the vehicles are read and written with
+	sv internal.VehicleTenantService
+	// schema is the schema of the GraphQL API
+	schema *graphql.Schema
+	// vehicle is the Vehicle type of the schema, shared by the queries and the mutations
+	vehicle *graphql.Object
+	// limits is the bounds of the depth and complexity of the operations
+	limits graphql.Limits
+}
+
+// graphqlRequestKey is the key of the context of a GraphQL operation holding the HTTP request it came in
+type graphqlRequestKey struct{}
+
+// graphqlRequest is a function that returns the HTTP request of the context of a GraphQL operation
+func graphqlRequest(ctx context.Context) *http.Request {
+	return ctx.Value(graphqlRequestKey{}).(*http.Request)
+}
+
+// service is a method that returns the vehicle service of the tenant of a request,
+// failing with ErrTenantNotFound for a tenant the tenant middleware did not check
+func (h *GraphQLDefault) service(r *http.Request) (sv internal.VehicleService, err error) {
+	sv, err = h.sv.Tenant(tenant(r))
+	return
+}
+
+// allow is a function that returns ErrForbidden when the caller of a request lacks a role, nil when the API is open
+func allow(r *http.Request, role internal.Role) error {
+	if p, ok := principal(r); ok && !p.Role.Allows(role) {
+		return internal.ErrForbidden
+	}
+	return nil
+}
+
+// Serve is a method that returns a handler for the routes GET /graphql?query={query}&operationName={name}&variables={json},
+// queries only, and POST /graphql with a JSON body {"query", "operationName", "variables"}
+func (h *GraphQLDefault) Serve() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - get operation from query or body
+		var req graphql.Request
+		switch r.Method {
+		case http.MethodGet:
+			query := r.URL.Query()
+			req.Query = query.Get("query")
+			req.OperationName = query.Get("operationName")
+			if variables := query.Get("variables"); variables != "" {
+				if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+					writeGraphQLError(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, err))
+					return
+				}
+			}
+			// - mutations are not sent with GET, which is safe
+			if doc, err := graphql.Parse(req.Query); err == nil {
+				if op, err := doc.Operation(req.OperationName); err == nil && op.Type != "query" {
+					w.Header().Set("Allow", http.MethodPost)
+					writeGraphQLError(w, r, http.StatusMethodNotAllowed, internal.ErrForbidden)
+					return
+				}
+			}
+		case http.MethodPost:
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxGraphQLBodySize)).Decode(&req); err != nil {
+				writeGraphQLError(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, err))
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if req.Query == "" {
+			writeGraphQLError(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, internal.NewError("parameter_required", "query")))
+			return
+		}
+
+		// process
+		// - execute operation, the resolvers reach the request through the context
+		ctx := context.WithValue(r.Context(), graphqlRequestKey{}, r)
+		res := h.schema.Execute(ctx, req, h.limits)
+
+		// response
+		// - the errors of the API are translated, with their code
+		lang := language(r)
+		w.Header().Set("Content-Language", lang)
+		w.Header().Add("Vary", "Accept-Language")
+		for _, e := range res.Errors {
+			switch {
+			case errors.Is(e, graphql.ErrTooDeep):
+				e.Message = internal.Message(lang, "graphql_too_deep", h.limits.MaxDepth)
+				e.Extensions = map[string]any{"code": "graphql_too_deep"}
+			case errors.Is(e, graphql.ErrTooComplex):
+				e.Message = internal.Message(lang, "graphql_too_complex", h.limits.MaxComplexity)
+				e.Extensions = map[string]any{"code": "graphql_too_complex"}
+			case e.Err != nil:
+				e.Message = localize(e.Err, lang)
+				e.Extensions = map[string]any{"code": errorCode(e.Err, vehicleStatus(e.Err))}
+			}
+		}
+		// - a request that could not be executed is answered with 400
+		code := http.StatusOK
+		if res.Data == nil {
+			code = http.StatusBadRequest
+		}
+		response.JSON(w, code, res)
+	}
+}
+
+// writeGraphQLError is a function that writes an error of a request that is not a GraphQL operation, in the GraphQL format
+func writeGraphQLError(w http.ResponseWriter, r *http.Request, status int, err error) {
+	lang := language(r)
+	w.Header().Set("Content-Language", lang)
+	w.Header().Add("Vary", "Accept-Language")
+	response.JSON(w, status, graphql.Response{Errors: []*graphql.Error{{
+		Message:    localize(err, lang),
+		Extensions: map[string]any{"code": errorCode(err, status)},
+	}}})
+}
+
+// graphiQL is the page of GraphiQL, the IDE of GraphQL in the browser, for the endpoint /graphql
+const graphiQL = `<!doctype html>
+<html lang="en">
+<head>
+	<meta charset="utf-8">
+	<title>Vehicles GraphiQL</title>
+	<link rel="stylesheet" href="https://unpkg.com/graphiql@3/graphiql.min.css">
+</head>
+<body style="margin: 0">
+	<div id="graphiql" style="height: 100vh"></div>
+	<script crossorigin src="https://unpkg.com/react@18/umd/react.production.min.js"></script>
+	<script crossorigin src="https://unpkg.com/react-dom@18/umd/react-dom.production.min.js"></script>
+	<script crossorigin src="https://unpkg.com/graphiql@3/graphiql.min.js"></script>
+	<script>
+		const fetcher = GraphiQL.createFetcher({ url: "/graphql" });
+		ReactDOM.createRoot(document.getElementById("graphiql")).render(
+			React.createElement(GraphiQL, { fetcher: fetcher, defaultEditorToolsVisibility: true })
+		);
+	</script>
+</body>
+</html>
+`
+
+// GraphiQL is a method that returns a handler for the route GET /graphiql, the page of the IDE of the GraphQL API.
+// The credentials are set in the headers of its editor
+func (h *GraphQLDefault) GraphiQL() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(graphiQL))
+	}
+}
+
+// vehicleConnection is a struct that represents a page of vehicles of the GraphQL API
+type vehicleConnection struct {
+	// vehicles is the vehicles of the page
+	vehicles []internal.Vehicle
+	// total is the number of vehicles matching the filter
+	total int
+	// hasNext is whether there are vehicles after the page
+	hasNext bool
+	// hasPrevious is whether there are vehicles before the page
+	hasPrevious bool
+}
+
+// statsGroup is a struct that represents the result of an aggregation for a group in the GraphQL API
+type statsGroup struct {
+	// query is the aggregation, whose group by fields and metrics sort the result
+	query internal.AggregateQuery
+	// group is the result
+	group internal.AggregateGroup
+}
+
+// keyValue is a struct that represents a named value of a group of the GraphQL API
+type keyValue struct {
+	key   string
+	value any
+}
+
+// cursor is a function that returns the opaque cursor of a vehicle in a connection
+func cursor(v internal.Vehicle) string {
+	return base64.StdEncoding.EncodeToString([]byte("vehicle:" + strconv.Itoa(v.Id)))
+}
+
+// cursorID is a function that returns the id of the vehicle of a cursor
+func cursorID(c string) (id int, err error) {
+	data, err := base64.StdEncoding.DecodeString(c)
+	if err == nil {
+		value, ok := strings.CutPrefix(string(data), "vehicle:")
+		if id, err = strconv.Atoi(value); ok && err == nil {
+			return
+		}
+	}
+	err = errors.Join(internal.ErrFieldsMissing, internal.NewError("parameter_cursor", "after"))
+	return
+}
+
+// graphqlFilter is a function that returns the filter of the argument filter of a field
+func graphqlFilter(args map[string]any) (f internal.VehicleFilter) {
+	m, _ := args["filter"].(map[string]any)
+	f.Brand, _ = m["brand"].(string)
+	f.Model, _ = m["model"].(string)
+	f.Color, _ = m["color"].(string)
+	f.FuelType, _ = m["fuelType"].(string)
+	f.Transmission, _ = m["transmission"].(string)
+	f.MinYear, _ = m["minYear"].(int)
+	f.MaxYear, _ = m["maxYear"].(int)
+	f.MinWeight, _ = m["minWeight"].(float64)
+	f.MaxWeight, _ = m["maxWeight"].(float64)
+	f.MinLength, _ = m["minLength"].(float64)
+	f.MaxLength, _ = m["maxLength"].(float64)
+	f.MinWidth, _ = m["minWidth"].(float64)
+	f.MaxWidth, _ = m["maxWidth"].(float64)
+	return
+}
+
+// graphqlAsOf is a function that returns the moment of the argument asOf of a field, zero when it is not given
+func graphqlAsOf(args map[string]any) (at time.Time, err error) {
+	value, _ := args["asOf"].(string)
+	if value == "" {
+		return
+	}
+	at, err = time.Parse(time.RFC3339, value)
+	if err != nil {
+		err = errors.Join(internal.ErrFieldsMissing, internal.NewError("parameter_time", "asOf"))
+	}
+	return
+}
+
+// findVehicle is a function that returns a vehicle by its id or its string id
+func findVehicle(sv internal.VehicleService, id string) (v internal.Vehicle, err error) {
+	if n, e := strconv.Atoi(id); e == nil {
+		return sv.FindByID(n)
+	}
+	return sv.FindByUID(id)
+}
+
+// vehicleInputFields is the field of the version 2 of the API of each field of the vehicle inputs of the GraphQL API
+var vehicleInputFields = map[string]string{
+	"brand":        "brand",
+	"model":        "model",
+	"registration": "registration",
+	"color":        "color",
+	"year":         "year",
+	"passengers":   "passengers",
+	"maxSpeed":     "max_speed",
+	"fuelType":     "fuel_type",
+	"transmission": "transmission",
+	"weight":       "weight",
+	"dimensions":   "dimensions",
+}
+
+// applyVehicleInput is a function that sets the fields given in a vehicle input of the GraphQL API, null ones left as they are
+func applyVehicleInput(v *VehicleV2JSON, input map[string]any) {
+	for name, value := range input {
+		if value == nil {
+			continue
+		}
+		switch name {
+		case "id":
+			v.ID = value.(int)
+		case "brand":
+			v.Brand = value.(string)
+		case "model":
+			v.Model = value.(string)
+		case "registration":
+			v.Registration = value.(string)
+		case "color":
+			v.Color = value.(string)
+		case "year":
+			v.FabricationYear = value.(int)
+		case "passengers":
+			v.Capacity = value.(int)
+		case "maxSpeed":
+			v.MaxSpeed = value.(float64)
+		case "fuelType":
+			v.FuelType = value.(string)
+		case "transmission":
+			v.Transmission = value.(string)
+		case "weight":
+			v.Weight = value.(float64)
+		case "dimensions":
+			for dimension, size := range value.(map[string]any) {
+				if size == nil {
+					continue
+				}
+				switch dimension {
+				case "height":
+					v.Dimensions.Height = size.(float64)
+				case "length":
+					v.Dimensions.Length = size.(float64)
+				case "width":
+					v.Dimensions.Width = size.(float64)
+				}
+			}
+		}
+	}
+}
+
+// query is a method that returns the root type of the queries of the GraphQL API
+func (h *GraphQLDefault) query() *graphql.Object {
+	dimensions := &graphql.Object{Name: "Dimensions", Description: "The dimensions of a vehicle.", Fields: []*graphql.FieldDefinition{
+		{Name: "height", Type: graphql.NewNonNull(graphql.Float), Resolve: func(p graphql.ResolveParams) (any, error) {
+			return p.Source.(internal.Dimensions).Height, nil
+		}},
+		{Name: "length", Type: graphql.NewNonNull(graphql.Float), Resolve: func(p graphql.ResolveParams) (any, error) {
+			return p.Source.(internal.Dimensions).Length, nil
+		}},
+		{Name: "width", Type: graphql.NewNonNull(graphql.Float), Resolve: func(p graphql.ResolveParams) (any, error) {
+			return p.Source.(internal.Dimensions).Width, nil
+		}},
+	}}
+
+	// - the fields of a vehicle, read from the vehicle of their source
+	field := func(name string, t graphql.Type, description string, value func(v internal.Vehicle) any) *graphql.FieldDefinition {
+		return &graphql.FieldDefinition{Name: name, Type: t, Description: description, Resolve: func(p graphql.ResolveParams) (any, error) {
+			return value(p.Source.(internal.Vehicle)), nil
+		}}
+	}
+	h.vehicle = &graphql.Object{Name: "Vehicle", Description: "A vehicle of the fleet.", Fields: []*graphql.FieldDefinition{
+		field("id", graphql.NewNonNull(graphql.Int), "The id of the vehicle.", func(v internal.Vehicle) any { return v.Id }),
+		field("uid", graphql.String, "The string id of the vehicle, with the uuidv7 and ulid strategies.", func(v internal.Vehicle) any {
+			if v.UID == "" {
+				return nil
+			}
+			return v.UID
+		}),
+		field("version", graphql.NewNonNull(graphql.Int), "The number of writes of the vehicle.", func(v internal.Vehicle) any { return v.Version }),
+		field("brand", graphql.NewNonNull(graphql.String), "", func(v internal.Vehicle) any { return v.Brand }),
+		field("model", graphql.NewNonNull(graphql.String), "", func(v internal.Vehicle) any { return v.Model }),
+		field("registration", graphql.NewNonNull(graphql.String), "", func(v internal.Vehicle) any { return v.Registration }),
+		field("color", graphql.NewNonNull(graphql.String), "", func(v internal.Vehicle) any { return v.Color }),
+		field("year", graphql.NewNonNull(graphql.Int), "The fabrication year of the vehicle.", func(v internal.Vehicle) any { return v.FabricationYear }),
+		field("passengers", graphql.NewNonNull(graphql.Int), "The capacity of people of the vehicle.", func(v internal.Vehicle) any { return v.Capacity }),
+		field("maxSpeed", graphql.NewNonNull(graphql.Float), "", func(v internal.Vehicle) any { return v.MaxSpeed }),
+		field("fuelType", graphql.NewNonNull(graphql.String), "", func(v internal.Vehicle) any { return v.FuelType }),
+		field("transmission", graphql.NewNonNull(graphql.String), "", func(v internal.Vehicle) any { return v.Transmission }),
+		field("weight", graphql.NewNonNull(graphql.Float), "", func(v internal.Vehicle) any { return v.Weight }),
+		field("dimensions", graphql.NewNonNull(dimensions), "", func(v internal.Vehicle) any { return v.Dimensions }),
+	}}
+
+	// - pages of vehicles, as Relay connections
+	pageInfo := &graphql.Object{Name: "PageInfo", Description: "The position of a page in a list.", Fields: []*graphql.FieldDefinition{
+		{Name: "hasNextPage", Type: graphql.NewNonNull(graphql.Boolean), Resolve: func(p graphql.ResolveParams) (any, error) {
+			return p.Source.(vehicleConnection).hasNext, nil
+		}},
+		{Name: "hasPreviousPage", Type: graphql.NewNonNull(graphql.Boolean), Resolve: func(p graphql.ResolveParams) (any, error) {
+			return p.Source.(vehicleConnection).hasPrevious, nil
+		}},
+		{Name: "startCursor", Type: graphql.String, Resolve: func(p graphql.ResolveParams) (any, error) {
+			if c := p.Source.(vehicleConnection); len(c.vehicles) > 0 {
+				return cursor(c.vehicles[0]), nil
+			}
+			return nil, nil
+		}},
+		{Name: "endCursor", Type: graphql.String, Resolve: func(p graphql.ResolveParams) (any, error) {
+			if c := p.Source.(vehicleConnection); len(c.vehicles) > 0 {
+				return cursor(c.vehicles[len(c.vehicles)-1]), nil
+			}
+			return nil, nil
+		}},
+	}}
+	edge := &graphql.Object{Name: "VehicleEdge", Description: "A vehicle of a page, with its cursor.", Fields: []*graphql.FieldDefinition{
+		{Name: "cursor", Type: graphql.NewNonNull(graphql.String), Resolve: func(p graphql.ResolveParams) (any, error) {
+			return cursor(p.Source.(internal.Vehicle)), nil
+		}},
+		{Name: "node", Type: graphql.NewNonNull(h.vehicle), Resolve: func(p graphql.ResolveParams) (any, error) {
+			return p.Source, nil
+		}},
+	}}
+	connection := &graphql.Object{Name: "VehicleConnection", Description: "A page of vehicles, sorted by id.", Fields: []*graphql.FieldDefinition{
+		{Name: "edges", Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(edge))), Resolve: func(p graphql.ResolveParams) (any, error) {
+			return p.Source.(vehicleConnection).vehicles, nil
+		}},
+		{Name: "nodes", Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(h.vehicle))), Resolve: func(p graphql.ResolveParams) (any, error) {
+			return p.Source.(vehicleConnection).vehicles, nil
+		}},
+		{Name: "pageInfo", Type: graphql.NewNonNull(pageInfo), Resolve: func(p graphql.ResolveParams) (any, error) {
+			return p.Source, nil
+		}},
+		{Name: "totalCount", Type: graphql.NewNonNull(graphql.Int), Resolve: func(p graphql.ResolveParams) (any, error) {
+			return p.Source.(vehicleConnection).total, nil
+		}},
+	}}
+
+	// - aggregations
+	keyValueField := func(name string, t graphql.Type) *graphql.FieldDefinition {
+		return &graphql.FieldDefinition{Name: name, Type: graphql.NewNonNull(t), Resolve: func(p graphql.ResolveParams) (any, error) {
+			if name == "value" {
+				return p.Source.(keyValue).value, nil
+			}
+			return p.Source.(keyValue).key, nil
+		}}
+	}
+	groupKey := &graphql.Object{Name: "GroupKey", Description: "The value of a group by field for a group.", Fields: []*graphql.FieldDefinition{
+		keyValueField("field", graphql.String),
+		keyValueField("value", graphql.String),
+	}}
+	metricValue := &graphql.Object{Name: "MetricValue", Description: "The value of a metric for a group.", Fields: []*graphql.FieldDefinition{
+		keyValueField("metric", graphql.String),
+		keyValueField("value", graphql.Float),
+	}}
+	group := &graphql.Object{Name: "StatsGroup", Description: "The metrics of a group of vehicles.", Fields: []*graphql.FieldDefinition{
+		{Name: "key", Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(groupKey))), Resolve: func(p graphql.ResolveParams) (any, error) {
+			g := p.Source.(statsGroup)
+			key := make([]keyValue, 0, len(g.query.GroupBy))
+			for _, field := range g.query.GroupBy {
+				key = append(key, keyValue{key: field, value: g.group.Key[field]})
+			}
+			return key, nil
+		}},
+		{Name: "metrics", Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(metricValue))), Resolve: func(p graphql.ResolveParams) (any, error) {
+			g := p.Source.(statsGroup)
+			metrics := make([]keyValue, 0, len(g.query.Metrics))
+			for _, m := range g.query.Metrics {
+				metrics = append(metrics, keyValue{key: m.String(), value: g.group.Values[m.String()]})
+			}
+			return metrics, nil
+		}},
+	}}
+
+	filter := &graphql.InputObject{Name: "VehicleFilter", Description: "Optional criteria a vehicle has to match.", Fields: []*graphql.ArgumentDefinition{
+		{Name: "brand", Type: graphql.String},
+		{Name: "model", Type: graphql.String},
+		{Name: "color", Type: graphql.String},
+		{Name: "fuelType", Type: graphql.String},
+		{Name: "transmission", Type: graphql.String},
+		{Name: "minYear", Type: graphql.Int},
+		{Name: "maxYear", Type: graphql.Int},
+		{Name: "minWeight", Type: graphql.Float},
+		{Name: "maxWeight", Type: graphql.Float},
+		{Name: "minLength", Type: graphql.Float},
+		{Name: "maxLength", Type: graphql.Float},
+		{Name: "minWidth", Type: graphql.Float},
+		{Name: "maxWidth", Type: graphql.Float},
+	}}
+	asOf := &graphql.ArgumentDefinition{Name: "asOf", Type: graphql.String, Description: "The RFC 3339 moment of the fleet, now when not given."}
+
+	return &graphql.Object{Name: "Query", Fields: []*graphql.FieldDefinition{
+		{
+			Name:        "vehicle",
+			Description: "A vehicle by its id or string id, null when there is none.",
+			Type:        h.vehicle,
+			Args:        []*graphql.ArgumentDefinition{{Name: "id", Type: graphql.NewNonNull(graphql.ID)}},
+			Resolve:     h.resolveVehicle,
+		},
+		{
+			Name:        "vehicles",
+			Description: "A page of the vehicles matching a filter, after a cursor.",
+			Type:        graphql.NewNonNull(connection),
+			Args: []*graphql.ArgumentDefinition{
+				{Name: "filter", Type: filter},
+				{Name: "first", Type: graphql.Int, Default: defaultPageSize, Description: "The size of the page, up to 500."},
+				{Name: "after", Type: graphql.String, Description: "The cursor the page starts after."},
+				asOf,
+			},
+			Resolve: h.resolveVehicles,
+			// - every vehicle of the page costs its selection set
+			Complexity: func(args map[string]any, children int) int {
+				first, _ := args["first"].(int)
+				return 1 + min(max(first, 1), maxPageSize)*children
+			},
+		},
+		{
+			Name:        "stats",
+			Description: "The metrics of the vehicles matching a filter, by group.",
+			Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(group))),
+			Args: []*graphql.ArgumentDefinition{
+				{Name: "filter", Type: filter},
+				{Name: "groupBy", Type: graphql.NewList(graphql.NewNonNull(graphql.String)), Description: "The fields the vehicles are grouped by: brand, model, color, fuel_type, transmission or year."},
+				{Name: "metrics", Type: graphql.NewList(graphql.NewNonNull(graphql.String)), Default: []any{"count"}, Description: "The metrics of each group: count, or sum, avg, min, max or pNN of a numeric field, e.g. avg(max_speed)."},
+				asOf,
+			},
+			Resolve: h.resolveStats,
+		},
+	}}
+}
+
+// mutation is a method that returns the root type of the mutations of the GraphQL API
+func (h *GraphQLDefault) mutation() *graphql.Object {
+	required := graphql.NewNonNull
+	input := &graphql.InputObject{Name: "VehicleInput", Description: "The attributes of a vehicle to create.", Fields: []*graphql.ArgumentDefinition{
+		{Name: "id", Type: graphql.Int, Description: "The id of the vehicle, allocated by the server when not given."},
+		{Name: "brand", Type: required(graphql.String)},
+		{Name: "model", Type: required(graphql.String)},
+		{Name: "registration", Type: required(graphql.String)},
+		{Name: "color", Type: required(graphql.String)},
+		{Name: "year", Type: required(graphql.Int)},
+		{Name: "passengers", Type: required(graphql.Int)},
+		{Name: "maxSpeed", Type: required(graphql.Float)},
+		{Name: "fuelType", Type: required(graphql.String)},
+		{Name: "transmission", Type: required(graphql.String)},
+		{Name: "weight", Type: required(graphql.Float)},
+		{Name: "dimensions", Type: required(&graphql.InputObject{Name: "DimensionsInput", Fields: []*graphql.ArgumentDefinition{
+			{Name: "height", Type: required(graphql.Float)},
+			{Name: "length", Type: required(graphql.Float)},
+			{Name: "width", Type: required(graphql.Float)},
+		}})},
+	}}
+	patch := &graphql.InputObject{Name: "VehiclePatch", Description: "The attributes of a vehicle to update, the others left as they are. Operators may update maxSpeed and fuelType only.", Fields: []*graphql.ArgumentDefinition{
+		{Name: "brand", Type: graphql.String},
+		{Name: "model", Type: graphql.String},
+		{Name: "registration", Type: graphql.String},
+		{Name: "color", Type: graphql.String},
+		{Name: "year", Type: graphql.Int},
+		{Name: "passengers", Type: graphql.Int},
+		{Name: "maxSpeed", Type: graphql.Float},
+		{Name: "fuelType", Type: graphql.String},
+		{Name: "transmission", Type: graphql.String},
+		{Name: "weight", Type: graphql.Float},
+		{Name: "dimensions", Type: &graphql.InputObject{Name: "DimensionsPatch", Fields: []*graphql.ArgumentDefinition{
+			{Name: "height", Type: graphql.Float},
+			{Name: "length", Type: graphql.Float},
+			{Name: "width", Type: graphql.Float},
+		}}},
+	}}
+	id := &graphql.ArgumentDefinition{Name: "id", Type: required(graphql.ID), Description: "The id or string id of the vehicle."}
+	version := &graphql.ArgumentDefinition{Name: "version", Type: graphql.Int, Description: "The version the vehicle has to be at, any when not given."}
+
+	return &graphql.Object{Name: "Mutation", Fields: []*graphql.FieldDefinition{
+		{
+			Name:        "createVehicle",
+			Description: "Creates a vehicle. Requires the admin role.",
+			Type:        required(h.vehicle),
+			Args:        []*graphql.ArgumentDefinition{{Name: "input", Type: required(input)}},
+			Resolve:     h.resolveCreateVehicle,
+		},
+		{
+			Name:        "updateVehicle",
+			Description: "Updates the attributes of a vehicle given in the patch. Requires the operator role.",
+			Type:        required(h.vehicle),
+			Args:        []*graphql.ArgumentDefinition{id, {Name: "input", Type: required(patch)}, version},
+			Resolve:     h.resolveUpdateVehicle,
+		},
+		{
+			Name:        "deleteVehicle",
+			Description: "Moves a vehicle to the trash, or deletes it for good when hard, and returns its id. Requires the admin role.",
+			Type:        required(graphql.Int),
+			Args:        []*graphql.ArgumentDefinition{id, {Name: "hard", Type: graphql.Boolean, Default: false}, version},
+			Resolve:     h.resolveDeleteVehicle,
+		},
+	}}
+}
+
+// resolveVehicle is a method that resolves the query vehicle(id)
+func (h *GraphQLDefault) resolveVehicle(p graphql.ResolveParams) (any, error) {
+	sv, err := h.service(graphqlRequest(p.Context))
+	if err != nil {
+		return nil, err
+	}
+	v, err := findVehicle(sv, p.Args["id"].(string))
+	if errors.Is(err, internal.ErrVehicleNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// resolveVehicles is a method that resolves the query vehicles(filter, first, after, asOf)
+func (h *GraphQLDefault) resolveVehicles(p graphql.ResolveParams) (any, error) {
+	// arguments
+	sv, err := h.service(graphqlRequest(p.Context))
+	if err != nil {
+		return nil, err
+	}
+	filter := graphqlFilter(p.Args)
+	first, _ := p.Args["first"].(int)
+	if first <= 0 {
+		return nil, errors.Join(internal.ErrFieldsMissing, internal.NewError("parameter_positive", "first"))
+	}
+	first = min(first, maxPageSize)
+	at, err := graphqlAsOf(p.Args)
+	if err != nil {
+		return nil, err
+	}
+	after := 0
+	if c, ok := p.Args["after"].(string); ok {
+		if after, err = cursorID(c); err != nil {
+			return nil, err
+		}
+	}
+
+	// vehicles matching the filter, sorted by id
+	var v map[int]internal.Vehicle
+	if at.IsZero() {
+		v, err = sv.FindAll()
+	} else {
+		v, err = sv.FindAsOf(at)
+	}
+	if err != nil && !errors.Is(err, internal.ErrVehicleNotFound) {
+		return nil, err
+	}
+	ids := make([]int, 0, len(v))
+	for id, value := range v {
+		if filter.Match(value) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+
+	// page after the cursor
+	start := sort.SearchInts(ids, after+1)
+	end := min(start+first, len(ids))
+	c := vehicleConnection{total: len(ids), hasNext: end < len(ids), hasPrevious: start > 0}
+	for _, id := range ids[start:end] {
+		c.vehicles = append(c.vehicles, v[id])
+	}
+	return c, nil
+}
+
+// resolveStats is a method that resolves the query stats(filter, groupBy, metrics, asOf)
+func (h *GraphQLDefault) resolveStats(p graphql.ResolveParams) (any, error) {
+	sv, err := h.service(graphqlRequest(p.Context))
+	if err != nil {
+		return nil, err
+	}
+	q := internal.AggregateQuery{Filter: graphqlFilter(p.Args)}
+	groupBy, _ := p.Args["groupBy"].([]any)
+	for _, field := range groupBy {
+		q.GroupBy = append(q.GroupBy, field.(string))
+	}
+	metrics, _ := p.Args["metrics"].([]any)
+	for _, m := range metrics {
+		q.Metrics = append(q.Metrics, metric(m.(string)))
+	}
+	if q.AsOf, err = graphqlAsOf(p.Args); err != nil {
+		return nil, err
+	}
+
+	groups, err := sv.Aggregate(q)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]statsGroup, 0, len(groups))
+	for _, g := range groups {
+		data = append(data, statsGroup{query: q, group: g})
+	}
+	return data, nil
+}
+
+// resolveCreateVehicle is a method that resolves the mutation createVehicle(input)
+func (h *GraphQLDefault) resolveCreateVehicle(p graphql.ResolveParams) (any, error) {
+	r := graphqlRequest(p.Context)
+	if err := allow(r, internal.RoleAdmin); err != nil {
+		return nil, err
+	}
+	sv, err := h.service(r)
+	if err != nil {
+		return nil, err
+	}
+	var vehicle VehicleV2JSON
+	applyVehicleInput(&vehicle, p.Args["input"].(map[string]any))
+	return sv.WithActor(actor(r)).Create(vehicleFromV2JSON(vehicle))
+}
+
+// resolveUpdateVehicle is a method that resolves the mutation updateVehicle(id, input, version), a merge of the input
+// on the vehicle as it is. Operators may update the maxSpeed and fuelType only
+func (h *GraphQLDefault) resolveUpdateVehicle(p graphql.ResolveParams) (any, error) {
+	r := graphqlRequest(p.Context)
+	if err := allow(r, internal.RoleOperator); err != nil {
+		return nil, err
+	}
+	input := p.Args["input"].(map[string]any)
+	if allow(r, internal.RoleAdmin) != nil {
+		for name, value := range input {
+			if value != nil && !slices.Contains(vehicleV2OperatorFields, vehicleInputFields[name]) {
+				return nil, internal.ErrForbidden
+			}
+		}
+	}
+
+	// apply input to the vehicle as it is now
+	sv, err := h.service(r)
+	if err != nil {
+		return nil, err
+	}
+	sv = sv.WithActor(actor(r))
+	current, err := findVehicle(sv, p.Args["id"].(string))
+	if err != nil {
+		return nil, err
+	}
+	if version, _ := p.Args["version"].(int); version != 0 && version != current.Version {
+		return nil, internal.ErrVehicleVersionMismatch
+	}
+	patched := vehicleV2JSON(current)
+	applyVehicleInput(&patched, input)
+
+	// replace vehicle, if nothing was written since it was read
+	return sv.Replace(vehicleFromV2JSON(patched), current.Version)
+}
+
+// resolveDeleteVehicle is a method that resolves the mutation deleteVehicle(id, hard, version)
+func (h *GraphQLDefault) resolveDeleteVehicle(p graphql.ResolveParams) (any, error) {
+	r := graphqlRequest(p.Context)
+	if err := allow(r, internal.RoleAdmin); err != nil {
+		return nil, err
+	}
+	sv, err := h.service(r)
+	if err != nil {
+		return nil, err
+	}
+	sv = sv.WithActor(actor(r))
+	v, err := findVehicle(sv, p.Args["id"].(string))
+	if err != nil {
+		return nil, err
+	}
+	version, _ := p.Args["version"].(int)
+	if hard, _ := p.Args["hard"].(bool); hard {
+		err = sv.Purge(v.Id, version)
+	} else {
+		err = sv.Delete(v.Id, version)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return v.Id, nil
+}
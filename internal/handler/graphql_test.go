@@ -0,0 +1,110 @@
+package handler_test
+
+import (
+	"app/internal"
+	"app/internal/handler"
+	"app/internal/repository"
+	"app/internal/service"
+	"app/platform/graphql"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// newGraphQLServer is a function that returns a server of the GraphQL API of a fleet of one vehicle,
+// authenticated with the keys "viewer", "operator" and "admin" of those roles
+func newGraphQLServer(t *testing.T) (*httptest.Server, internal.VehicleRepository) {
+	t.Helper()
+	db := map[int]internal.Vehicle{1: {Id: 1, VehicleAttributes: internal.VehicleAttributes{Brand: "Ford", Model: "Focus", MaxSpeed: 180}}}
+	rp := repository.NewVehicleMap(db)
+	svCatalog := service.NewCatalogDefault(repository.NewCatalogMap(db), true)
+	sv := service.NewVehicleTenants(map[string]internal.VehicleService{
+		internal.DefaultTenant: service.NewVehicleDefault(rp, svCatalog, nil).WithTenant(internal.DefaultTenant),
+	})
+
+	hash := func(key string) string {
+		sum := sha256.Sum256([]byte(key))
+		return hex.EncodeToString(sum[:])
+	}
+	hdAuth := handler.NewAuthDefault(internal.AuthConfig{APIKeys: []internal.APIKey{
+		{Name: "viewer", Hash: hash("viewer"), Role: internal.RoleViewer},
+		{Name: "operator", Hash: hash("operator"), Role: internal.RoleOperator},
+		{Name: "admin", Hash: hash("admin"), Role: internal.RoleAdmin},
+	}})
+	hd := handler.NewGraphQLDefault(sv, graphql.Limits{})
+	rt := chi.NewRouter()
+	rt.With(hdAuth.Authenticate).Handle("/graphql", hd.Serve())
+	srv := httptest.NewServer(rt)
+	t.Cleanup(srv.Close)
+	return srv, rp
+}
+
+func TestGraphQLDefault_MutationAuthorization(t *testing.T) {
+	cases := []struct {
+		name  string
+		key   string
+		query string
+		code  string
+	}{
+		{name: "viewer updates", key: "viewer", query: `mutation { updateVehicle(id: "1", input: {maxSpeed: 200}) { id } }`, code: "forbidden"},
+		{name: "operator updates the speed", key: "operator", query: `mutation { updateVehicle(id: "1", input: {maxSpeed: 200}) { id } }`},
+		{name: "operator updates the brand", key: "operator", query: `mutation { updateVehicle(id: "1", input: {brand: "Kia"}) { id } }`, code: "forbidden"},
+		{name: "operator deletes", key: "operator", query: `mutation { deleteVehicle(id: "1") }`, code: "forbidden"},
+		{name: "admin deletes", key: "admin", query: `mutation { deleteVehicle(id: "1") }`},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			srv, rp := newGraphQLServer(t)
+			body, _ := json.Marshal(graphql.Request{Query: c.query})
+			req, _ := http.NewRequest(http.MethodPost, srv.URL+"/graphql", bytes.NewReader(body))
+			req.Header.Set("X-API-Key", c.key)
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+
+			var got struct {
+				Errors []struct {
+					Extensions map[string]any `json:"extensions"`
+				} `json:"errors"`
+			}
+			if err = json.NewDecoder(res.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			if c.code == "" {
+				if len(got.Errors) != 0 {
+					t.Fatalf("got errors %+v, want none", got.Errors)
+				}
+				return
+			}
+			if len(got.Errors) != 1 || got.Errors[0].Extensions["code"] != c.code {
+				t.Fatalf("got errors %+v, want the code %s", got.Errors, c.code)
+			}
+			// the vehicle is left as it was
+			if v, err := rp.FindByID(1); err != nil || v.Brand != "Ford" || v.MaxSpeed != 180 {
+				t.Errorf("got %+v and %v, want the vehicle unchanged", v, err)
+			}
+		})
+	}
+}
+
+func TestGraphQLDefault_MutationWithGet(t *testing.T) {
+	srv, _ := newGraphQLServer(t)
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+`/graphql?query=mutation{deleteVehicle(id:"1")}`, nil)
+	req.Header.Set("X-API-Key", "admin")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusMethodNotAllowed || res.Header.Get("Allow") != http.MethodPost {
+		t.Errorf("got %d allowing %q, want %d allowing POST", res.StatusCode, res.Header.Get("Allow"), http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"app/internal"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// NewIdempotencyDefault is a function that returns a new instance of IdempotencyDefault
+func NewIdempotencyDefault(sv internal.IdempotencyService, logger *slog.Logger) *IdempotencyDefault {
+	return &IdempotencyDefault{sv: sv, logger: logger}
+}
+
+// IdempotencyDefault is a struct with methods that represent the middleware of the idempotency keys
+type IdempotencyDefault struct {
+	// sv is the service that will be used by the handler
+	sv internal.IdempotencyService
+	// logger is the logger of the failures to keep a response, which is already sent by then
+	logger *slog.Logger
+}
+
+// idempotencyRecorder is a struct that represents a response writer that keeps a copy of the response
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	// status is the status code written, 0 until then
+	status int
+	// header is the header of the handler when the status code was written
+	header http.Header
+	// body is the body written
+	body bytes.Buffer
+}
+
+// WriteHeader is a method that writes the status code, keeping a copy of it and of the header set by the handler
+func (rc *idempotencyRecorder) WriteHeader(status int) {
+	if rc.status != 0 {
+		return
+	}
+	rc.status = status
+	rc.header = make(http.Header)
+	for key, values := range rc.ResponseWriter.Header() {
+		// - the headers of the middlewares describe the request that made the response, not its retries
+		if strings.HasPrefix(key, "Ratelimit-") || strings.HasPrefix(key, "X-Quota-") {
+			continue
+		}
+		rc.header[key] = values
+	}
+	rc.ResponseWriter.WriteHeader(status)
+}
+
+// Write is a method that writes the body, keeping a copy of it
+func (rc *idempotencyRecorder) Write(b []byte) (int, error) {
+	if rc.status == 0 {
+		rc.WriteHeader(http.StatusOK)
+	}
+	rc.body.Write(b)
+	return rc.ResponseWriter.Write(b)
+}
+
+// Handle is a method that returns a middleware replaying the response of the first request made with an Idempotency-Key
+// header to its retries, for the ttl of the service. A key reused with another request is rejected with 422,
+// and a retry made while the first request is in progress with 409. Server errors are not kept, so they can be retried
+func (h *IdempotencyDefault) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - get key from header, the requests without one are not idempotent
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > 255 {
+			problem(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, internal.NewError("parameter_too_long", "Idempotency-Key", 255)))
+			return
+		}
+		// - fingerprint the request, the keys are scoped to the tenant and the client
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			problem(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, err))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(append([]byte(r.Method+" "+r.URL.Path+"\n"), body...))
+		fingerprint := hex.EncodeToString(sum[:])
+		key = tenant(r) + "|" + client(r) + "|" + key
+
+		// process
+		// - replay the response of the key, if any
+		record, replay, err := h.sv.Begin(key, fingerprint)
+		switch {
+		case errors.Is(err, internal.ErrIdempotencyKeyReused):
+			problem(w, r, http.StatusUnprocessableEntity, err)
+			return
+		case errors.Is(err, internal.ErrIdempotencyInProgress):
+			problem(w, r, http.StatusConflict, err)
+			return
+		case err != nil:
+			problem(w, r, http.StatusInternalServerError, err)
+			return
+		case replay:
+			for k, values := range record.Header {
+				w.Header()[k] = values
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(record.StatusCode)
+			w.Write(record.Body)
+			return
+		}
+		// - handle the request, keeping its response; the key is released when it panics or fails on the server
+		rc := &idempotencyRecorder{ResponseWriter: w}
+		defer func() {
+			if rc.status != 0 && rc.status < http.StatusInternalServerError {
+				record.Done = true
+				record.StatusCode = rc.status
+				record.Header = rc.header
+				record.Body = rc.body.Bytes()
+			}
+			if err := h.sv.End(record); err != nil {
+				h.logger.Error("keep an idempotent response", "key", record.Key, "status", record.StatusCode, "error", err)
+			}
+		}()
+		next.ServeHTTP(rc, r)
+	})
+}
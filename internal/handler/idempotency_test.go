@@ -0,0 +1,99 @@
+package handler_test
+
+import (
+	"app/internal/handler"
+	"app/internal/repository"
+	"app/internal/service"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestIdempotency is a function that returns the idempotency middleware around next, keeping the responses in memory
+func newTestIdempotency(next http.Handler) http.Handler {
+	sv := service.NewIdempotencyDefault(repository.NewIdempotencyMap(), time.Hour)
+	return handler.NewIdempotencyDefault(sv, slog.New(slog.NewTextHandler(io.Discard, nil))).Handle(next)
+}
+
+// idempotent is a function that sends a POST with an Idempotency-Key to h
+func idempotent(h http.Handler, key string, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/vehicles", strings.NewReader(body))
+	r.Header.Set("Idempotency-Key", key)
+	res := httptest.NewRecorder()
+	h.ServeHTTP(res, r)
+	return res
+}
+
+func TestIdempotency_Replay(t *testing.T) {
+	var calls atomic.Int32
+	h := newTestIdempotency(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Location", "/vehicles/7")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":7}`))
+	}))
+
+	first := idempotent(h, "k1", `{"brand":"Ford"}`)
+	retry := idempotent(h, "k1", `{"brand":"Ford"}`)
+	if calls.Load() != 1 {
+		t.Errorf("got %d calls, want 1", calls.Load())
+	}
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() || retry.Header().Get("Location") != "/vehicles/7" {
+		t.Errorf("got %d %s %v, want the first response", retry.Code, retry.Body, retry.Header())
+	}
+	if retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("got no Idempotent-Replayed header")
+	}
+
+	// the same key with another body is another request
+	if res := idempotent(h, "k1", `{"brand":"Kia"}`); res.Code != http.StatusUnprocessableEntity {
+		t.Errorf("got %d, want %d", res.Code, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestIdempotency_InProgress(t *testing.T) {
+	started, finish := make(chan struct{}), make(chan struct{})
+	h := newTestIdempotency(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-finish
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- idempotent(h, "k1", "{}") }()
+	<-started
+	if res := idempotent(h, "k1", "{}"); res.Code != http.StatusConflict {
+		t.Errorf("got %d, want %d", res.Code, http.StatusConflict)
+	}
+	close(finish)
+	if res := <-done; res.Code != http.StatusCreated {
+		t.Errorf("got %d, want %d", res.Code, http.StatusCreated)
+	}
+}
+
+func TestIdempotency_ServerErrorReleasesKey(t *testing.T) {
+	var calls atomic.Int32
+	h := newTestIdempotency(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	if res := idempotent(h, "k1", "{}"); res.Code != http.StatusServiceUnavailable {
+		t.Fatalf("got %d, want %d", res.Code, http.StatusServiceUnavailable)
+	}
+	// the retry is handled again rather than replayed or rejected as in progress
+	if res := idempotent(h, "k1", "{}"); res.Code != http.StatusCreated || res.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("got %d replayed %q, want %d not replayed", res.Code, res.Header().Get("Idempotent-Replayed"), http.StatusCreated)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("got %d calls, want 2", calls.Load())
+	}
+}
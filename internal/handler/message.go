@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"app/internal"
+	"app/platform/tools"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/bootcamp-go/web/response"
+)
+
+// language is a function that returns the language of the messages of a request, negotiated with its Accept-Language header
+func language(r *http.Request) string {
+	return internal.Messages.Negotiate(r.Header.Get("Accept-Language"), internal.DefaultLanguage)
+}
+
+// message is a function that returns a message of the API in the language of a request
+func message(w http.ResponseWriter, r *http.Request, code string) string {
+	lang := language(r)
+	w.Header().Set("Content-Language", lang)
+	w.Header().Add("Vary", "Accept-Language")
+	return internal.Message(lang, code)
+}
+
+// walkError is a function that calls fn with an error and every error it wraps
+func walkError(err error, fn func(error)) {
+	if err == nil {
+		return
+	}
+	fn(err)
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		walkError(e.Unwrap(), fn)
+	case interface{ Unwrap() []error }:
+		for _, inner := range e.Unwrap() {
+			walkError(inner, fn)
+		}
+	}
+}
+
+// localize is a function that returns the message of an error in a language, the errors of the API it wraps translated
+func localize(err error, lang string) string {
+	text := err.Error()
+	walkError(err, func(e error) {
+		var translated string
+		switch e := e.(type) {
+		case *internal.Error:
+			translated = e.Message(lang)
+		case *tools.FieldError:
+			translated = internal.Message(lang, "field_required", e.Field)
+		default:
+			return
+		}
+		text = strings.Replace(text, e.Error(), translated, 1)
+	})
+	return text
+}
+
+// errorCode is a function that returns the machine-readable code of an error, the one of the first error of the API
+// it wraps, or else the one of its status code
+func errorCode(err error, status int) string {
+	var e *internal.Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	var fe *tools.FieldError
+	if errors.As(err, &fe) {
+		return "field_required"
+	}
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+}
+
+// writeError is a function that writes an error response with its code and its message in the language of the request
+func writeError(w http.ResponseWriter, r *http.Request, status int, err error) {
+	lang := language(r)
+	w.Header().Set("Content-Language", lang)
+	w.Header().Add("Vary", "Accept-Language")
+	response.JSON(w, status, map[string]any{
+		"message": localize(err, lang),
+		"code":    errorCode(err, status),
+	})
+}
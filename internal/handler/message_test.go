@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"app/internal"
+	"app/platform/tools"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+// messageVerbs is the expression of the verbs of a message, e.g. %s or %[1]s
+var messageVerbs = regexp.MustCompile(`%(\[\d+\])?[a-z]`)
+
+// writtenError is a function that returns the status, the body and the Content-Language of an error written
+// for a request accepting a language
+func writtenError(t *testing.T, accept string, status int, err error) (code int, body map[string]string, lang string) {
+	t.Helper()
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Language", accept)
+	writeError(res, req, status, err)
+	if e := json.Unmarshal(res.Body.Bytes(), &body); e != nil {
+		t.Fatal(e)
+	}
+	return res.Code, body, res.Header().Get("Content-Language")
+}
+
+func TestWriteError_EveryCode(t *testing.T) {
+	for lang, messages := range internal.Messages {
+		for code, text := range messages {
+			// an arg for each verb of the message
+			var args []any
+			for _, verb := range messageVerbs.FindAllString(text, -1) {
+				if verb == "%d" {
+					args = append(args, 7)
+					continue
+				}
+				args = append(args, "x")
+			}
+
+			status, body, contentLanguage := writtenError(t, lang, http.StatusBadRequest, internal.NewError(code, args...))
+			if status != http.StatusBadRequest || contentLanguage != lang {
+				t.Errorf("%s in %s: got %d in %s, want %d in %s", code, lang, status, contentLanguage, http.StatusBadRequest, lang)
+			}
+			if want := internal.Message(lang, code, args...); body["message"] != want || body["code"] != code {
+				t.Errorf("%s in %s: got %q with code %s, want %q", code, lang, body["message"], body["code"], want)
+			}
+		}
+	}
+}
+
+func TestWriteError_Wrapped(t *testing.T) {
+	cases := []struct {
+		name    string
+		accept  string
+		status  int
+		err     error
+		code    string
+		message string
+	}{
+		{
+			name: "joined errors of the API", accept: "en", status: http.StatusBadRequest,
+			err:     errors.Join(internal.ErrFieldsMissing, internal.NewError("parameter_required", "q")),
+			code:    "fields_missing",
+			message: internal.Message("en", "fields_missing") + "\n" + internal.Message("en", "parameter_required", "q"),
+		},
+		{
+			name: "field error", accept: "en-US", status: http.StatusBadRequest,
+			err:     &tools.FieldError{Field: "brand", Msg: "field brand is required"},
+			code:    "field_required",
+			message: internal.Message("en", "field_required", "brand"),
+		},
+		{
+			name: "error of the API wrapping another", accept: "es", status: http.StatusNotFound,
+			err:     errors.Join(internal.ErrVehicleNotFound, errors.New("id 7")),
+			code:    "vehicle_not_found",
+			message: internal.Message("es", "vehicle_not_found") + "\nid 7",
+		},
+		{
+			name: "other error", accept: "en", status: http.StatusInternalServerError,
+			err:     errors.New("disk full"),
+			code:    "internal_server_error",
+			message: "disk full",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, body, _ := writtenError(t, c.accept, c.status, c.err)
+			if body["code"] != c.code || body["message"] != c.message {
+				t.Errorf("got %q with code %s, want %q with code %s", body["message"], body["code"], c.message, c.code)
+			}
+		})
+	}
+}
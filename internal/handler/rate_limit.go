@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"app/internal"
+	"app/platform/graphql"
+	"app/platform/ratelimit"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Route groups, each one with its own rate limit
+const (
+	// rateGroupReads is the group of the GET routes
+	rateGroupReads = "reads"
+	// rateGroupWrites is the group of the routes that write, but batches
+	rateGroupWrites = "writes"
+	// rateGroupBatch is the group of the batch routes
+	rateGroupBatch = "batch"
+	// rateGroupIP is the group of every request of an IP, limited before the caller is authenticated
+	rateGroupIP = "ip"
+)
+
+// NewRateLimitDefault is a function that returns a new instance of RateLimitDefault, a limit of 0 requests leaves its group unlimited
+func NewRateLimitDefault(sv internal.QuotaService, reads, writes, batch, ip internal.RateLimit) *RateLimitDefault {
+	h := &RateLimitDefault{sv: sv, limiters: make(map[string]*ratelimit.Limiter), policies: make(map[string]string)}
+	for group, limit := range map[string]internal.RateLimit{rateGroupReads: reads, rateGroupWrites: writes, rateGroupBatch: batch, rateGroupIP: ip} {
+		if limit.Requests <= 0 || limit.Window <= 0 {
+			continue
+		}
+		h.limiters[group] = ratelimit.NewLimiter(limit.Requests, limit.Window)
+		h.policies[group] = fmt.Sprintf("%d;w=%d", limit.Requests, int(math.Ceil(limit.Window.Seconds())))
+	}
+	return h
+}
+
+// RateLimitDefault is a struct with methods that represent the middleware limiting the requests of each client
+type RateLimitDefault struct {
+	// sv is the service of the daily quotas
+	sv internal.QuotaService
+	// limiters is the token bucket limiter of each route group
+	limiters map[string]*ratelimit.Limiter
+	// policies is the RateLimit-Policy header of each route group
+	policies map[string]string
+}
+
+// client is a function that returns the key a request is limited by: the authenticated caller, or else its IP
+func client(r *http.Request) string {
+	if p, ok := principal(r); ok {
+		return "key:" + p.Name
+	}
+	return "ip:" + remoteIP(r)
+}
+
+// remoteIP is a function that returns the IP a request comes from
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return host
+}
+
+// rateGroup is a function that returns the route group of a request
+func rateGroup(r *http.Request) string {
+	switch {
+	case r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions:
+		return rateGroupReads
+	case strings.HasSuffix(strings.TrimSuffix(r.URL.Path, "/"), "/batch"):
+		return rateGroupBatch
+	case strings.HasSuffix(strings.TrimSuffix(r.URL.Path, "/"), "/graphql") && graphqlQuery(r):
+		return rateGroupReads
+	default:
+		return rateGroupWrites
+	}
+}
+
+// graphqlQuery is a function that returns whether the body of a POST /graphql request is a query, which only reads,
+// leaving the body in place for the handler; a body that cannot be parsed counts as a mutation
+func graphqlQuery(r *http.Request) bool {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxGraphQLBodySize+1))
+	// - the handler reads the body again, the rest of it too so that it rejects it when too large
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+	if err != nil || len(body) > maxGraphQLBodySize {
+		return false
+	}
+	var req graphql.Request
+	if err := json.Unmarshal(body, &req); err != nil {
+		return false
+	}
+	doc, err := graphql.Parse(req.Query)
+	if err != nil {
+		return false
+	}
+	op, err := doc.Operation(req.OperationName)
+	return err == nil && op.Type == "query"
+}
+
+// seconds is a function that returns a duration in whole seconds, rounded up
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// LimitIP is a method that returns a middleware rejecting with 429 the requests of an IP beyond its rate limit,
+// put before the authentication so that the attempts with wrong credentials are limited too
+func (h *RateLimitDefault) LimitIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if limiter, ok := h.limiters[rateGroupIP]; ok {
+			res := limiter.Allow(remoteIP(r), time.Now())
+			if !res.Allowed {
+				w.Header().Set("RateLimit-Policy", h.policies[rateGroupIP])
+				w.Header().Set("Retry-After", seconds(res.RetryAfter))
+				problem(w, r, http.StatusTooManyRequests, internal.ErrRateLimited)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Limit is a method that returns a middleware rejecting with 429 the requests of a client beyond the rate limit
+// of their route group or its daily quota, with the RateLimit headers and Retry-After
+func (h *RateLimitDefault) Limit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := client(r)
+		now := time.Now()
+
+		// rate limit of the route group
+		group := rateGroup(r)
+		if limiter, ok := h.limiters[group]; ok {
+			res := limiter.Allow(group+"|"+key, now)
+			w.Header().Set("RateLimit-Policy", h.policies[group])
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			w.Header().Set("RateLimit-Reset", seconds(res.Reset))
+			if !res.Allowed {
+				w.Header().Set("Retry-After", seconds(res.RetryAfter))
+				problem(w, r, http.StatusTooManyRequests, internal.ErrRateLimited)
+				return
+			}
+		}
+
+		// daily quota
+		remaining, reset, err := h.sv.Take(key, now)
+		if err != nil {
+			if errors.Is(err, internal.ErrQuotaExceeded) {
+				w.Header().Set("Retry-After", seconds(reset.Sub(now)))
+				problem(w, r, http.StatusTooManyRequests, err)
+				return
+			}
+			problem(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		if remaining >= 0 {
+			w.Header().Set("X-Quota-Remaining", strconv.Itoa(remaining))
+			w.Header().Set("X-Quota-Reset", reset.Format(http.TimeFormat))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
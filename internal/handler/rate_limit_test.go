@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"app/internal"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateGroup(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   string
+	}{
+		{"get", http.MethodGet, "/v2/vehicles", "", rateGroupReads},
+		{"post", http.MethodPost, "/v2/vehicles", "{}", rateGroupWrites},
+		{"batch", http.MethodPost, "/v2/vehicles/batch", "[]", rateGroupBatch},
+		{"graphql query", http.MethodPost, "/graphql", `{"query": "{ vehicles { totalCount } }"}`, rateGroupReads},
+		{"graphql named query", http.MethodPost, "/graphql", `{"query": "mutation M { deleteVehicle(id: 1) } query Q { vehicle(id: 1) { id } }", "operationName": "Q"}`, rateGroupReads},
+		{"graphql mutation", http.MethodPost, "/graphql", `{"query": "mutation { deleteVehicle(id: 1) }"}`, rateGroupWrites},
+		{"graphql invalid", http.MethodPost, "/graphql", `{"query": "{"}`, rateGroupWrites},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if got := rateGroup(r); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+			// the handler still reads the whole body
+			body, err := io.ReadAll(r.Body)
+			if err != nil || string(body) != tt.body {
+				t.Errorf("got body %q (%v), want %q", body, err, tt.body)
+			}
+		})
+	}
+}
+
+func TestLimitIP(t *testing.T) {
+	h := NewRateLimitDefault(nil, internal.RateLimit{}, internal.RateLimit{}, internal.RateLimit{}, internal.RateLimit{Requests: 1, Window: time.Minute})
+	served := h.LimitIP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusUnauthorized) }))
+	request := func(addr string) int {
+		r := httptest.NewRequest(http.MethodGet, "/v2/vehicles", nil)
+		r.RemoteAddr = addr
+		w := httptest.NewRecorder()
+		served.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	// the attempts of an IP are limited before its credentials are checked, whatever the port
+	if code := request("192.0.2.1:1000"); code != http.StatusUnauthorized {
+		t.Errorf("got %d, want %d", code, http.StatusUnauthorized)
+	}
+	if code := request("192.0.2.1:2000"); code != http.StatusTooManyRequests {
+		t.Errorf("got %d, want %d", code, http.StatusTooManyRequests)
+	}
+	// other IPs are not
+	if code := request("192.0.2.2:1000"); code != http.StatusUnauthorized {
+		t.Errorf("got %d, want %d", code, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"app/internal"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/bootcamp-go/web/response"
+	"github.com/go-chi/chi/v5"
+)
+
+// asOf is a function that returns the moment of the as_of query param of a request, zero when there is none
+func asOf(r *http.Request) (at time.Time, err error) {
+	value := r.URL.Query().Get("as_of")
+	if value == "" {
+		return
+	}
+	at, err = time.Parse(time.RFC3339, value)
+	if err != nil {
+		err = internal.NewError("parameter_time", "as_of")
+	}
+	return
+}
+
+// SnapshotJSON is a struct that represents a snapshot, without its vehicles, in JSON format
+type SnapshotJSON struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	AsOf      time.Time `json:"as_of"`
+	Vehicles  int       `json:"vehicles"`
+}
+
+// CreateSnapshot is a method that returns a handler for the route POST /snapshots
+func (h *VehicleDefault) CreateSnapshot() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
+		// - get name and moment from body, the fleet as it is now by default
+		var body struct {
+			Name string     `json:"name"`
+			AsOf *time.Time `json:"as_of"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Name == "" {
+			writeError(w, r, http.StatusBadRequest, internal.ErrFieldsMissing)
+			return
+		}
+		at := time.Now()
+		if body.AsOf != nil {
+			at = *body.AsOf
+		}
+
+		// process
+		// - create snapshot
+		snapshot, err := sv.CreateSnapshot(body.Name, at)
+		if err != nil {
+			code := http.StatusConflict
+			if errors.Is(err, internal.ErrHistoryNotKept) {
+				code = http.StatusBadRequest
+			}
+			writeError(w, r, code, err)
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusCreated, map[string]any{
+			"message": "success",
+			"data": SnapshotJSON{
+				Name:      snapshot.Name,
+				CreatedAt: snapshot.CreatedAt,
+				AsOf:      snapshot.AsOf,
+				Vehicles:  len(snapshot.Vehicles),
+			},
+		})
+	}
+}
+
+// GetSnapshots is a method that returns a handler for the route GET /snapshots
+func (h *VehicleDefault) GetSnapshots() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
+		// process
+		// - get snapshots
+		snapshots, err := sv.FindSnapshots()
+		if err != nil {
+			response.JSON(w, http.StatusInternalServerError, nil)
+			return
+		}
+
+		// response
+		data := make([]SnapshotJSON, 0, len(snapshots))
+		for _, snapshot := range snapshots {
+			data = append(data, SnapshotJSON{
+				Name:      snapshot.Name,
+				CreatedAt: snapshot.CreatedAt,
+				AsOf:      snapshot.AsOf,
+				Vehicles:  len(snapshot.Vehicles),
+			})
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    data,
+		})
+	}
+}
+
+// GetSnapshot is a method that returns a handler for the route GET /snapshots/{name},
+// the vehicles are downloaded as an array in the format of the data file
+func (h *VehicleDefault) GetSnapshot() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
+		// - get name from url
+		name := chi.URLParam(r, "name")
+
+		// process
+		// - get snapshot
+		snapshot, err := sv.FindSnapshot(name)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		// response
+		data := make([]VehicleJSON, 0, len(snapshot.Vehicles))
+		for _, value := range snapshot.Vehicles {
+			data = append(data, *vehicleJSON(&value))
+		}
+		sort.Slice(data, func(i, j int) bool { return data[i].ID < data[j].ID })
+		w.Header().Set("Content-Disposition", "attachment; filename="+strconv.Quote(snapshot.Name+".json"))
+		response.JSON(w, http.StatusOK, data)
+	}
+}
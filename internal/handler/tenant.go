@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"app/internal"
+	"context"
+	"net/http"
+)
+
+// tenantKey is the key of the tenant in the context of a request
+type tenantKey struct{}
+
+// tenant is a function that returns the tenant of a request, the default one when it was not resolved
+func tenant(r *http.Request) string {
+	if t, ok := r.Context().Value(tenantKey{}).(string); ok {
+		return t
+	}
+	return internal.DefaultTenant
+}
+
+// NewTenantDefault is a function that returns a new instance of TenantDefault
+func NewTenantDefault(sv internal.VehicleTenantService) *TenantDefault {
+	return &TenantDefault{sv: sv}
+}
+
+// TenantDefault is a struct with methods that represent the middleware resolving the tenant of the requests
+type TenantDefault struct {
+	// sv is the service the tenants are checked against
+	sv internal.VehicleTenantService
+}
+
+// Resolve is a method that returns a middleware resolving the tenant of a request: the tenant the caller is bound to,
+// or else the X-Tenant-ID header, or else the default one. It rejects with 403 a header naming another tenant than
+// the caller's, and with 404 an unknown tenant
+func (h *TenantDefault) Resolve(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t := r.Header.Get("X-Tenant-ID")
+		if p, ok := principal(r); ok && p.Tenant != "" {
+			if t != "" && t != p.Tenant {
+				problem(w, r, http.StatusForbidden, internal.ErrForbidden)
+				return
+			}
+			t = p.Tenant
+		}
+		if t == "" {
+			t = internal.DefaultTenant
+		}
+		if _, err := h.sv.Tenant(t); err != nil {
+			problem(w, r, http.StatusNotFound, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), tenantKey{}, t)))
+	})
+}
@@ -0,0 +1,32 @@
+package handler_test
+
+import (
+	"app/internal"
+	"app/internal/handler"
+	"app/internal/repository"
+	"app/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTenant_NotFound(t *testing.T) {
+	// the default tenant is unknown and the handlers are reached without the tenant middleware
+	db := map[int]internal.Vehicle{1: {Id: 1, VehicleAttributes: internal.VehicleAttributes{Brand: "Ford"}}}
+	svCatalog := service.NewCatalogDefault(repository.NewCatalogMap(db), false)
+	sv := service.NewVehicleTenants(map[string]internal.VehicleService{"acme": service.NewVehicleDefault(repository.NewVehicleMap(db), svCatalog, nil)})
+	hd := handler.NewVehicleDefault(sv)
+	hdCatalog := handler.NewCatalogDefault(map[string]internal.CatalogService{"acme": svCatalog})
+
+	for name, h := range map[string]http.HandlerFunc{
+		"vehicles":    hd.GetAll(),
+		"vehicles v2": hd.ListV2(),
+		"catalog":     hdCatalog.GetAll(internal.CatalogBrands),
+	} {
+		res := httptest.NewRecorder()
+		h(res, httptest.NewRequest(http.MethodGet, "/", nil))
+		if res.Code != http.StatusNotFound {
+			t.Errorf("%s: got %d, want 404", name, res.Code)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/bootcamp-go/web/response"
 	"github.com/go-chi/chi/v5"
@@ -17,6 +18,7 @@ import (
 // VehicleJSON is a struct that represents a vehicle in JSON format
 type VehicleJSON struct {
 	ID              int     `json:"id"`
+	UID             string  `json:"uid,omitempty"`
 	Brand           string  `json:"brand"`
 	Model           string  `json:"model"`
 	Registration    string  `json:"registration"`
@@ -37,25 +39,52 @@ type MultipleVehicleJSON struct {
 }
 
 // NewVehicleDefault is a function that returns a new instance of VehicleDefault
-func NewVehicleDefault(sv internal.VehicleService) *VehicleDefault {
+func NewVehicleDefault(sv internal.VehicleTenantService) *VehicleDefault {
 	return &VehicleDefault{sv: sv}
 }
 
 // VehicleDefault is a struct with methods that represent handlers for vehicles
 type VehicleDefault struct {
-	// sv is the service that will be used by the handler
-	sv internal.VehicleService
+	// sv is the service of the tenants that will be used by the handler
+	sv internal.VehicleTenantService
 }
 
-// GetAll is a method that returns a handler for the route GET /vehicles
+// service is a method that returns the vehicle service of the tenant of a request,
+// failing with ErrTenantNotFound for a tenant the tenant middleware did not check
+func (h *VehicleDefault) service(r *http.Request) (sv internal.VehicleService, err error) {
+	sv, err = h.sv.Tenant(tenant(r))
+	return
+}
+
+// GetAll is a method that returns a handler for the route GET /vehicles?as_of={time}
 func (h *VehicleDefault) GetAll() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// request
-		// ...
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
+		// - get as_of from query, the fleet as it is now by default
+		at, err := asOf(r)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, err))
+			return
+		}
 
 		// process
 		// - get all vehicles
-		v, err := h.sv.FindAll()
+		var v map[int]internal.Vehicle
+		if at.IsZero() {
+			v, err = sv.FindAll()
+		} else {
+			v, err = sv.FindAsOf(at)
+		}
+		if errors.Is(err, internal.ErrHistoryNotKept) {
+			writeError(w, r, http.StatusBadRequest, err)
+			return
+		}
 		if err != nil {
 			response.JSON(w, http.StatusInternalServerError, nil)
 			return
@@ -64,22 +93,7 @@ func (h *VehicleDefault) GetAll() http.HandlerFunc {
 		// response
 		data := make(map[int]VehicleJSON)
 		for key, value := range v {
-			data[key] = VehicleJSON{
-				ID:              value.Id,
-				Brand:           value.Brand,
-				Model:           value.Model,
-				Registration:    value.Registration,
-				Color:           value.Color,
-				FabricationYear: value.FabricationYear,
-				Capacity:        value.Capacity,
-				MaxSpeed:        value.MaxSpeed,
-				FuelType:        value.FuelType,
-				Transmission:    value.Transmission,
-				Weight:          value.Weight,
-				Height:          value.Height,
-				Length:          value.Length,
-				Width:           value.Width,
-			}
+			data[key] = *vehicleJSON(&value)
 		}
 		response.JSON(w, http.StatusOK, map[string]any{
 			"message": "success",
@@ -92,6 +106,12 @@ func (h *VehicleDefault) GetAll() http.HandlerFunc {
 func (h *VehicleDefault) Create() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
 		// - read body to bytes
 		body, err := io.ReadAll(r.Body)
 		if err != nil {
@@ -111,27 +131,21 @@ func (h *VehicleDefault) Create() http.HandlerFunc {
 		if err = tools.ValidateField(bodyMap, "brand", "model", "registration", "color", "year", "passengers", "max_speed", "fuel_type", "transmission", "weight", "height", "length", "width"); err != nil {
 			var fieldError *tools.FieldError
 			if errors.As(err, &fieldError) {
-				response.JSON(w, http.StatusBadRequest, map[string]any{
-					"message": errors.Join(internal.ErrFieldsMissing, errors.New(fieldError.Error())).Error(),
-				})
+				writeError(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, fieldError))
 				return
 			}
-			response.JSON(w, http.StatusBadRequest, map[string]any{
-				"message": "internal error",
-			})
+			writeError(w, r, http.StatusBadRequest, internal.ErrInternal)
 			return
 		}
 		// - unmarshal body to vehicle
 		var vehicle VehicleJSON
 		err = json.Unmarshal(body, &vehicle)
 		if err != nil {
-			response.JSON(w, http.StatusBadRequest, map[string]any{
-				"message": internal.ErrFieldsMissing.Error(),
-			})
+			writeError(w, r, http.StatusBadRequest, internal.ErrFieldsMissing)
 			return
 		}
 		// - create vehicle
-		err = h.sv.Create(internal.Vehicle{
+		created, err := sv.WithActor(actor(r)).Create(internal.Vehicle{
 			Id: vehicle.ID,
 			VehicleAttributes: internal.VehicleAttributes{
 				Brand:           vehicle.Brand,
@@ -152,16 +166,17 @@ func (h *VehicleDefault) Create() http.HandlerFunc {
 			},
 		})
 		if err != nil {
-			response.JSON(w, http.StatusConflict, map[string]any{
-				"message": err.Error(),
-			})
+			code := http.StatusConflict
+			if errors.Is(err, internal.ErrVehicleNotInCatalog) || errors.Is(err, internal.ErrVehicleIDAssigned) {
+				code = http.StatusBadRequest
+			}
+			writeError(w, r, code, err)
 			return
 		}
 
 		// response
-		response.JSON(w, http.StatusCreated, map[string]any{
-			"message": internal.MesgVehicleCreated,
-		})
+		w.Header().Set("Location", vehicleLocation(r, created))
+		writeVehicle(w, r, http.StatusCreated, internal.MesgVehicleCreated, created)
 	}
 }
 
@@ -169,45 +184,32 @@ func (h *VehicleDefault) Create() http.HandlerFunc {
 func (h *VehicleDefault) GetByColorAndYear() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
 		// - get color and year from url
 		color := chi.URLParam(r, "color")
 		year, err := strconv.Atoi(chi.URLParam(r, "year"))
 		if err != nil {
-			response.JSON(w, http.StatusBadRequest, map[string]any{
-				"message": err.Error(),
-			})
+			writeError(w, r, http.StatusBadRequest, err)
 			return
 		}
 
 		// process
 		// - get vehicles by color and year
-		v, err := h.sv.GetByColorAndYear(color, year)
+		v, err := sv.GetByColorAndYear(color, year)
 		if err != nil {
-			response.JSON(w, http.StatusNotFound, map[string]any{
-				"message": err.Error(),
-			})
+			writeError(w, r, http.StatusNotFound, err)
 			return
 		}
 
 		// response
 		data := make(map[int]VehicleJSON)
 		for key, value := range v {
-			data[key] = VehicleJSON{
-				ID:              value.Id,
-				Brand:           value.Brand,
-				Model:           value.Model,
-				Registration:    value.Registration,
-				Color:           value.Color,
-				FabricationYear: value.FabricationYear,
-				Capacity:        value.Capacity,
-				MaxSpeed:        value.MaxSpeed,
-				FuelType:        value.FuelType,
-				Transmission:    value.Transmission,
-				Weight:          value.Weight,
-				Height:          value.Height,
-				Length:          value.Length,
-				Width:           value.Width,
-			}
+			data[key] = *vehicleJSON(&value)
 		}
 		response.JSON(w, http.StatusOK, map[string]any{
 			"message": "success",
@@ -221,59 +223,42 @@ func (h *VehicleDefault) GetByColorAndYear() http.HandlerFunc {
 func (h *VehicleDefault) GetByBrandAndYearRange() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
 		// - get brand and year range from url
 		brand := chi.URLParam(r, "brand")
 		yearStart, err := strconv.Atoi(chi.URLParam(r, "start_year"))
 		if err != nil {
-			response.JSON(w, http.StatusBadRequest, map[string]any{
-				"message": err.Error(),
-			})
+			writeError(w, r, http.StatusBadRequest, err)
 			return
 		}
 		yearEnd, err := strconv.Atoi(chi.URLParam(r, "end_year"))
 		if err != nil {
-			response.JSON(w, http.StatusBadRequest, map[string]any{
-				"message": err.Error(),
-			})
+			writeError(w, r, http.StatusBadRequest, err)
 			return
 		}
 		// validate year range
 		if yearStart > yearEnd {
-			response.JSON(w, http.StatusBadRequest, map[string]any{
-				"message": "400 Bad Request: year range is invalid",
-			})
+			writeError(w, r, http.StatusBadRequest, internal.ErrInvalidYearRange)
 			return
 		}
 
 		// process
 		// - get vehicles by brand and year range
-		v, err := h.sv.GetByBrandAndYearRange(brand, yearStart, yearEnd)
+		v, err := sv.GetByBrandAndYearRange(brand, yearStart, yearEnd)
 		if err != nil {
-			response.JSON(w, http.StatusNotFound, map[string]any{
-				"message": err.Error(),
-			})
+			writeError(w, r, http.StatusNotFound, err)
 			return
 		}
 
 		// response
 		data := make(map[int]VehicleJSON)
 		for key, value := range v {
-			data[key] = VehicleJSON{
-				ID:              value.Id,
-				Brand:           value.Brand,
-				Model:           value.Model,
-				Registration:    value.Registration,
-				Color:           value.Color,
-				FabricationYear: value.FabricationYear,
-				Capacity:        value.Capacity,
-				MaxSpeed:        value.MaxSpeed,
-				FuelType:        value.FuelType,
-				Transmission:    value.Transmission,
-				Weight:          value.Weight,
-				Height:          value.Height,
-				Length:          value.Length,
-				Width:           value.Width,
-			}
+			data[key] = *vehicleJSON(&value)
 		}
 		response.JSON(w, http.StatusOK, map[string]any{
 			"message": "success",
@@ -286,16 +271,20 @@ func (h *VehicleDefault) GetByBrandAndYearRange() http.HandlerFunc {
 func (h *VehicleDefault) GetAverageSpeedByBrand() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
 		// - get brand from url
 		brand := chi.URLParam(r, "brand")
 
 		// process
 		// - get average speed by brand
-		v, err := h.sv.GetAverageSpeedByBrand(brand)
+		v, err := sv.GetAverageSpeedByBrand(brand)
 		if err != nil {
-			response.JSON(w, http.StatusNotFound, map[string]any{
-				"message": err.Error(),
-			})
+			writeError(w, r, http.StatusNotFound, err)
 			return
 		}
 
@@ -311,12 +300,16 @@ func (h *VehicleDefault) GetAverageSpeedByBrand() http.HandlerFunc {
 func (h *VehicleDefault) CreateMultiple() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
 		// - get body
 		body, err := io.ReadAll(r.Body)
 		if err != nil {
-			response.JSON(w, http.StatusBadRequest, map[string]any{
-				"message": err.Error(),
-			})
+			writeError(w, r, http.StatusBadRequest, err)
 			return
 		}
 
@@ -324,9 +317,7 @@ func (h *VehicleDefault) CreateMultiple() http.HandlerFunc {
 		var vehicles map[string](map[string]any)
 		err = json.Unmarshal(body, &vehicles)
 		if err != nil {
-			response.JSON(w, http.StatusBadRequest, map[string]any{
-				"message": err.Error(),
-			})
+			writeError(w, r, http.StatusBadRequest, err)
 			return
 		}
 
@@ -337,38 +328,31 @@ func (h *VehicleDefault) CreateMultiple() http.HandlerFunc {
 			if err = tools.ValidateField(value, "brand", "model", "registration", "color", "year", "passengers", "max_speed", "fuel_type", "transmission", "weight", "height", "length", "width"); err != nil {
 				var fieldError *tools.FieldError
 				if errors.As(err, &fieldError) {
-					response.JSON(w, http.StatusBadRequest, map[string]any{
-						"message": errors.Join(internal.ErrFieldsMissing, errors.New(fieldError.Error())).Error(),
-					})
+					writeError(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, fieldError))
 					return
 				}
-				response.JSON(w, http.StatusBadRequest, map[string]any{
-					"message": "internal error",
-				})
+				writeError(w, r, http.StatusBadRequest, internal.ErrInternal)
 				return
 			}
 		}
 
 		// - create vehicles slice
 		var vehiclesSend []internal.Vehicle
-		var vehicle internal.Vehicle
 		for _, value := range vehicles {
+			// - each vehicle is decoded on its own, an entry without id is left to the server
+			var vehicle VehicleJSON
 			jsonData, err := json.Marshal(value)
 			if err != nil {
-				response.JSON(w, http.StatusBadRequest, map[string]any{
-					"message": err.Error(),
-				})
+				writeError(w, r, http.StatusBadRequest, err)
 				return
 			}
 			err = json.Unmarshal(jsonData, &vehicle)
 			if err != nil {
-				response.JSON(w, http.StatusBadRequest, map[string]any{
-					"message": err.Error(),
-				})
+				writeError(w, r, http.StatusBadRequest, err)
 				return
 			}
 			vehiclesSend = append(vehiclesSend, internal.Vehicle{
-				Id: vehicle.Id,
+				Id: vehicle.ID,
 				VehicleAttributes: internal.VehicleAttributes{
 					Brand:           vehicle.Brand,
 					Model:           vehicle.Model,
@@ -389,17 +373,31 @@ func (h *VehicleDefault) CreateMultiple() http.HandlerFunc {
 			})
 		}
 
-		err = h.sv.CreateMultiple(vehiclesSend)
+		created, err := sv.WithActor(actor(r)).CreateMultiple(vehiclesSend)
 		if err != nil {
-			response.JSON(w, http.StatusBadRequest, map[string]any{
-				"message": err.Error(),
-			})
+			code := http.StatusBadRequest
+			if errors.Is(err, internal.ErrVehicleAlreadyExists) {
+				code = http.StatusConflict
+			}
+			writeError(w, r, code, err)
 			return
 		}
 
 		// response
+		if returnMinimal(r) {
+			w.Header().Set("Preference-Applied", "return=minimal")
+			response.JSON(w, http.StatusCreated, map[string]any{
+				"message": message(w, r, internal.MesgVehicleCreated),
+			})
+			return
+		}
+		data := make([]VehicleJSON, 0, len(created))
+		for _, v := range created {
+			data = append(data, *vehicleJSON(&v))
+		}
 		response.JSON(w, http.StatusCreated, map[string]any{
-			"message": internal.MesgVehicleCreated,
+			"message": message(w, r, internal.MesgVehicleCreated),
+			"data":    data,
 		})
 	}
 }
@@ -408,21 +406,27 @@ func (h *VehicleDefault) CreateMultiple() http.HandlerFunc {
 func (h *VehicleDefault) UpdateSpeed() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
 		// - get id from url
-		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		id, err := h.vehicleID(r)
 		if err != nil {
-			response.JSON(w, http.StatusBadRequest, map[string]any{
-				"message": err.Error(),
-			})
+			code := http.StatusBadRequest
+			if errors.Is(err, internal.ErrVehicleNotFound) {
+				code = http.StatusNotFound
+			}
+			writeError(w, r, code, err)
 			return
 		}
 
 		// - get body
 		body, err := io.ReadAll(r.Body)
 		if err != nil {
-			response.JSON(w, http.StatusBadRequest, map[string]any{
-				"message": err.Error(),
-			})
+			writeError(w, r, http.StatusBadRequest, err)
 			return
 		}
 
@@ -430,9 +434,7 @@ func (h *VehicleDefault) UpdateSpeed() http.HandlerFunc {
 		var speed map[string]any
 		err = json.Unmarshal(body, &speed)
 		if err != nil {
-			response.JSON(w, http.StatusBadRequest, map[string]any{
-				"message": err.Error(),
-			})
+			writeError(w, r, http.StatusBadRequest, err)
 			return
 		}
 
@@ -441,40 +443,34 @@ func (h *VehicleDefault) UpdateSpeed() http.HandlerFunc {
 		if err = tools.ValidateField(speed, "speed"); err != nil {
 			var fieldError *tools.FieldError
 			if errors.As(err, &fieldError) {
-				response.JSON(w, http.StatusBadRequest, map[string]any{
-					"message": errors.Join(internal.ErrFieldsMissing, errors.New(fieldError.Error())).Error(),
-				})
+				writeError(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, fieldError))
 				return
 			}
-			response.JSON(w, http.StatusBadRequest, map[string]any{
-				"message": "internal error",
-			})
+			writeError(w, r, http.StatusBadRequest, internal.ErrInternal)
 			return
 		}
 		// - update speed
 		speedValue := speed["speed"].(float64)
 		if speedValue < 0 {
-			response.JSON(w, http.StatusBadRequest, map[string]any{
-				"message": "400 Bad Request: Velocidad mal formada o fuera de rango.",
-			})
+			writeError(w, r, http.StatusBadRequest, internal.ErrInvalidSpeed)
 			return
 		}
 
 		speed = map[string]any{
 			"speed": speedValue,
 		}
-		err = h.sv.Update(id, speed)
+		v, err := sv.WithActor(actor(r)).Update(id, speed, ifMatch(r))
 		if err != nil {
-			response.JSON(w, http.StatusNotFound, map[string]any{
-				"message": err.Error(),
-			})
+			code := http.StatusNotFound
+			if errors.Is(err, internal.ErrVehicleVersionMismatch) {
+				code = http.StatusPreconditionFailed
+			}
+			writeError(w, r, code, err)
 			return
 		}
 
 		// response
-		response.JSON(w, http.StatusOK, map[string]any{
-			"message": internal.MesgVehicleUpdatedSpeed,
-		})
+		writeVehicle(w, r, http.StatusOK, internal.MesgVehicleUpdatedSpeed, v)
 	}
 }
 
@@ -482,54 +478,84 @@ func (h *VehicleDefault) UpdateSpeed() http.HandlerFunc {
 func (h *VehicleDefault) GetByFuelType() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
 		// - get fuel type from url
 		fuelType := chi.URLParam(r, "type")
 
 		// process
 		// - get vehicles by fuel type
-		vehicles, err := h.sv.GetByFuelType(fuelType)
+		vehicles, err := sv.GetByFuelType(fuelType)
 		if err != nil {
-			response.JSON(w, http.StatusNotFound, map[string]any{
-				"message": err.Error(),
-			})
+			writeError(w, r, http.StatusNotFound, err)
 			return
 		}
 
 		// response
+		data := make(map[int]VehicleJSON)
+		for key, value := range vehicles {
+			data[key] = *vehicleJSON(&value)
+		}
 		response.JSON(w, http.StatusOK, map[string]any{
 			"message": "success",
-			"data":    vehicles,
+			"data":    data,
 		})
 	}
 }
 
-// Delete is a method that returns a handler for the route DELETE /vehicles/{id}
+// Delete is a method that returns a handler for the route DELETE /vehicles/{id}?hard={true|false}
 func (h *VehicleDefault) Delete() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
 		// - get id from url
-		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		id, err := h.vehicleID(r)
 		if err != nil {
-			response.JSON(w, http.StatusBadRequest, map[string]any{
-				"message": err.Error(),
-			})
+			code := http.StatusBadRequest
+			if errors.Is(err, internal.ErrVehicleNotFound) {
+				code = http.StatusNotFound
+			}
+			writeError(w, r, code, err)
 			return
 		}
+		// - get hard from query, vehicles are moved to the trash by default
+		hard := false
+		if value := r.URL.Query().Get("hard"); value != "" {
+			hard, err = strconv.ParseBool(value)
+			if err != nil {
+				writeError(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, err))
+				return
+			}
+		}
 
 		// process
 		// - delete vehicle
-		err = h.sv.Delete(id)
+		sv = sv.WithActor(actor(r))
+		if hard {
+			err = sv.Purge(id, ifMatch(r))
+		} else {
+			err = sv.Delete(id, ifMatch(r))
+		}
 		if err != nil {
-			response.JSON(w, http.StatusNotFound, map[string]any{
-				"message": err.Error(),
-			})
+			code := http.StatusNotFound
+			if errors.Is(err, internal.ErrVehicleVersionMismatch) {
+				code = http.StatusPreconditionFailed
+			}
+			writeError(w, r, code, err)
 			return
 		}
 
 		// response
-		response.JSON(w, http.StatusOK, map[string]any{
-			"message": internal.MesgVehicleDeleted,
-		})
+		w.WriteHeader(http.StatusNoContent)
 	}
 }
 
@@ -537,23 +563,31 @@ func (h *VehicleDefault) Delete() http.HandlerFunc {
 func (h *VehicleDefault) GetByTransmission() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
 		// - get transmission type from url
 		transmission := chi.URLParam(r, "type")
 
 		// process
 		// - get vehicles by transmission
-		vehicles, err := h.sv.GetByTransmission(transmission)
+		vehicles, err := sv.GetByTransmission(transmission)
 		if err != nil {
-			response.JSON(w, http.StatusNotFound, map[string]any{
-				"message": err.Error(),
-			})
+			writeError(w, r, http.StatusNotFound, err)
 			return
 		}
 
 		// response
+		data := make(map[int]VehicleJSON)
+		for key, value := range vehicles {
+			data[key] = *vehicleJSON(&value)
+		}
 		response.JSON(w, http.StatusOK, map[string]any{
 			"message": "success",
-			"data":    vehicles,
+			"data":    data,
 		})
 	}
 }
@@ -562,21 +596,27 @@ func (h *VehicleDefault) GetByTransmission() http.HandlerFunc {
 func (h *VehicleDefault) UpdateFuel() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
 		// - get id from url
-		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		id, err := h.vehicleID(r)
 		if err != nil {
-			response.JSON(w, http.StatusBadRequest, map[string]any{
-				"message": err.Error(),
-			})
+			code := http.StatusBadRequest
+			if errors.Is(err, internal.ErrVehicleNotFound) {
+				code = http.StatusNotFound
+			}
+			writeError(w, r, code, err)
 			return
 		}
 
 		// - get body
 		body, err := io.ReadAll(r.Body)
 		if err != nil {
-			response.JSON(w, http.StatusBadRequest, map[string]any{
-				"message": err.Error(),
-			})
+			writeError(w, r, http.StatusBadRequest, err)
 			return
 		}
 
@@ -584,9 +624,7 @@ func (h *VehicleDefault) UpdateFuel() http.HandlerFunc {
 		var fuel map[string]any
 		err = json.Unmarshal(body, &fuel)
 		if err != nil {
-			response.JSON(w, http.StatusBadRequest, map[string]any{
-				"message": err.Error(),
-			})
+			writeError(w, r, http.StatusBadRequest, err)
 			return
 		}
 
@@ -595,32 +633,31 @@ func (h *VehicleDefault) UpdateFuel() http.HandlerFunc {
 		if err = tools.ValidateField(fuel, "fuel_type"); err != nil {
 			var fieldError *tools.FieldError
 			if errors.As(err, &fieldError) {
-				response.JSON(w, http.StatusBadRequest, map[string]any{
-					"message": errors.Join(internal.ErrFieldsMissing, errors.New(fieldError.Error())).Error(),
-				})
+				writeError(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, fieldError))
 				return
 			}
-			response.JSON(w, http.StatusBadRequest, map[string]any{
-				"message": "internal error",
-			})
+			writeError(w, r, http.StatusBadRequest, internal.ErrInternal)
 			return
 		}
 		// - update fuel
 		fuel = map[string]any{
 			"fuel_type": fuel["fuel_type"],
 		}
-		err = h.sv.Update(id, fuel)
+		v, err := sv.WithActor(actor(r)).Update(id, fuel, ifMatch(r))
 		if err != nil {
-			response.JSON(w, http.StatusNotFound, map[string]any{
-				"message": err.Error(),
-			})
+			code := http.StatusNotFound
+			switch {
+			case errors.Is(err, internal.ErrVehicleNotInCatalog):
+				code = http.StatusBadRequest
+			case errors.Is(err, internal.ErrVehicleVersionMismatch):
+				code = http.StatusPreconditionFailed
+			}
+			writeError(w, r, code, err)
 			return
 		}
 
 		// response
-		response.JSON(w, http.StatusOK, map[string]any{
-			"message": internal.MesgVehicleUpdateFuel,
-		})
+		writeVehicle(w, r, http.StatusOK, internal.MesgVehicleUpdateFuel, v)
 	}
 }
 
@@ -628,16 +665,20 @@ func (h *VehicleDefault) UpdateFuel() http.HandlerFunc {
 func (h *VehicleDefault) GetAverageCapacityByBrand() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
 		// - get brand from url
 		brand := chi.URLParam(r, "brand")
 
 		// process
 		// - get average capacity by brand
-		average, err := h.sv.GetAverageCapacityByBrand(brand)
+		average, err := sv.GetAverageCapacityByBrand(brand)
 		if err != nil {
-			response.JSON(w, http.StatusNotFound, map[string]any{
-				"message": err.Error(),
-			})
+			writeError(w, r, http.StatusNotFound, err)
 			return
 		}
 
@@ -653,6 +694,12 @@ func (h *VehicleDefault) GetAverageCapacityByBrand() http.HandlerFunc {
 func (h *VehicleDefault) GetByDimensions() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
 		// - get query params
 		length := r.URL.Query().Get("length")
 		width := r.URL.Query().Get("width")
@@ -662,29 +709,21 @@ func (h *VehicleDefault) GetByDimensions() http.HandlerFunc {
 			// - split length and width
 			lengths := strings.Split(length, "-")
 			if len(lengths) != 2 {
-				response.JSON(w, http.StatusBadRequest, map[string]any{
-					"message": errors.Join(internal.ErrFieldsMissing, errors.New("length must have the format length={min_length}-{max_length}")).Error(),
-				})
+				writeError(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, internal.NewError("range_format", "length")))
 				return
 			}
 			minLength, err := strconv.ParseFloat(lengths[0], 64)
 			if err != nil {
-				response.JSON(w, http.StatusBadRequest, map[string]any{
-					"message": errors.Join(internal.ErrFieldsMissing, err).Error(),
-				})
+				writeError(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, err))
 				return
 			}
 			maxLength, err := strconv.ParseFloat(lengths[1], 64)
 			if err != nil {
-				response.JSON(w, http.StatusBadRequest, map[string]any{
-					"message": errors.Join(internal.ErrFieldsMissing, err).Error(),
-				})
+				writeError(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, err))
 				return
 			}
 			if minLength > maxLength {
-				response.JSON(w, http.StatusBadRequest, map[string]any{
-					"message": errors.Join(internal.ErrFieldsMissing, errors.New("invalid length, max length have to be greater than min length")).Error(),
-				})
+				writeError(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, internal.NewError("range_order", "length")))
 				return
 			}
 			// - add to dimensions map
@@ -696,29 +735,21 @@ func (h *VehicleDefault) GetByDimensions() http.HandlerFunc {
 			// - split length and width
 			widths := strings.Split(width, "-")
 			if len(widths) != 2 {
-				response.JSON(w, http.StatusBadRequest, map[string]any{
-					"message": errors.Join(internal.ErrFieldsMissing, errors.New("width must have the format width={min_width}-{max_width}")).Error(),
-				})
+				writeError(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, internal.NewError("range_format", "width")))
 				return
 			}
 			minWidth, err := strconv.ParseFloat(widths[0], 64)
 			if err != nil {
-				response.JSON(w, http.StatusBadRequest, map[string]any{
-					"message": errors.Join(internal.ErrFieldsMissing, err).Error(),
-				})
+				writeError(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, err))
 				return
 			}
 			maxWidth, err := strconv.ParseFloat(widths[1], 64)
 			if err != nil {
-				response.JSON(w, http.StatusBadRequest, map[string]any{
-					"message": errors.Join(internal.ErrFieldsMissing, err).Error(),
-				})
+				writeError(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, err))
 				return
 			}
 			if minWidth > maxWidth {
-				response.JSON(w, http.StatusBadRequest, map[string]any{
-					"message": errors.Join(internal.ErrFieldsMissing, errors.New("invalid width, max width have to be greater than min width")).Error(),
-				})
+				writeError(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, internal.NewError("range_order", "width")))
 				return
 			}
 			// - add to dimensions map
@@ -728,18 +759,20 @@ func (h *VehicleDefault) GetByDimensions() http.HandlerFunc {
 
 		// process
 		// - get vehicles by dimension
-		vehicles, err := h.sv.GetByDimensions(dimensions)
+		vehicles, err := sv.GetByDimensions(dimensions)
 		if err != nil {
-			response.JSON(w, http.StatusNotFound, map[string]any{
-				"message": err.Error(),
-			})
+			writeError(w, r, http.StatusNotFound, err)
 			return
 		}
 
 		// response
+		data := make(map[int]VehicleJSON)
+		for key, value := range vehicles {
+			data[key] = *vehicleJSON(&value)
+		}
 		response.JSON(w, http.StatusOK, map[string]any{
 			"message": "success",
-			"data":    vehicles,
+			"data":    data,
 		})
 	}
 }
@@ -748,6 +781,12 @@ func (h *VehicleDefault) GetByDimensions() http.HandlerFunc {
 func (h *VehicleDefault) GetByWeight() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
 		// - get query params
 		minWeight := r.URL.Query().Get("min")
 		maxWeight := r.URL.Query().Get("max")
@@ -757,9 +796,7 @@ func (h *VehicleDefault) GetByWeight() http.HandlerFunc {
 			// - parse min weight
 			min, err := strconv.ParseFloat(minWeight, 64)
 			if err != nil {
-				response.JSON(w, http.StatusBadRequest, map[string]any{
-					"message": errors.Join(internal.ErrFieldsMissing, err).Error(),
-				})
+				writeError(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, err))
 				return
 			}
 			// - add to weight map
@@ -770,9 +807,7 @@ func (h *VehicleDefault) GetByWeight() http.HandlerFunc {
 			// - parse max weight
 			max, err := strconv.ParseFloat(maxWeight, 64)
 			if err != nil {
-				response.JSON(w, http.StatusBadRequest, map[string]any{
-					"message": errors.Join(internal.ErrFieldsMissing, err).Error(),
-				})
+				writeError(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, err))
 				return
 			}
 			// - add to weight map
@@ -780,26 +815,504 @@ func (h *VehicleDefault) GetByWeight() http.HandlerFunc {
 		}
 
 		if minWeight != "" && maxWeight != "" && weight["min"] > weight["max"] {
-			response.JSON(w, http.StatusBadRequest, map[string]any{
-				"message": errors.Join(internal.ErrFieldsMissing, errors.New("invalid weight, max weight have to be greater than min weight")).Error(),
-			})
+			writeError(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, internal.NewError("range_order", "weight")))
 			return
 		}
 
 		// process
 		// - get vehicles by weight
-		vehicles, err := h.sv.GetByWeight(weight)
+		vehicles, err := sv.GetByWeight(weight)
 		if err != nil {
-			response.JSON(w, http.StatusNotFound, map[string]any{
-				"message": err.Error(),
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		// response
+		data := make(map[int]VehicleJSON)
+		for key, value := range vehicles {
+			data[key] = *vehicleJSON(&value)
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    data,
+		})
+	}
+}
+
+// vehicleFilter is a function that returns the filter of the query params of a request:
+// brand, model, color, fuel_type, transmission, min_year, max_year and the min_ and max_ of weight, length and width
+func vehicleFilter(r *http.Request) (f internal.VehicleFilter, err error) {
+	query := r.URL.Query()
+	f = internal.VehicleFilter{
+		Brand:        query.Get("brand"),
+		Model:        query.Get("model"),
+		Color:        query.Get("color"),
+		FuelType:     query.Get("fuel_type"),
+		Transmission: query.Get("transmission"),
+	}
+	for param, year := range map[string]*int{"min_year": &f.MinYear, "max_year": &f.MaxYear} {
+		if value := query.Get(param); value != "" {
+			*year, err = strconv.Atoi(value)
+			if err != nil {
+				return
+			}
+		}
+	}
+	ranges := map[string]*float64{
+		"min_weight": &f.MinWeight, "max_weight": &f.MaxWeight,
+		"min_length": &f.MinLength, "max_length": &f.MaxLength,
+		"min_width": &f.MinWidth, "max_width": &f.MaxWidth,
+	}
+	for param, bound := range ranges {
+		if value := query.Get(param); value != "" {
+			*bound, err = strconv.ParseFloat(value, 64)
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// metric is a function that returns a metric as written in a query, split in function and field, e.g. avg(max_speed)
+func metric(s string) internal.Metric {
+	if open := strings.Index(s, "("); open > 0 && strings.HasSuffix(s, ")") {
+		return internal.Metric{Func: s[:open], Field: s[open+1 : len(s)-1]}
+	}
+	return internal.Metric{Func: s}
+}
+
+// aggregateQuery is a function that returns the aggregation of the query params of a request:
+// the filter, group_by, metrics (count by default) and as_of
+func aggregateQuery(r *http.Request) (q internal.AggregateQuery, err error) {
+	query := r.URL.Query()
+	q.Filter, err = vehicleFilter(r)
+	if err != nil {
+		return
+	}
+	if groupBy := query.Get("group_by"); groupBy != "" {
+		q.GroupBy = strings.Split(groupBy, ",")
+	}
+	metrics := query.Get("metrics")
+	if metrics == "" {
+		metrics = "count"
+	}
+	for _, m := range strings.Split(metrics, ",") {
+		q.Metrics = append(q.Metrics, metric(m))
+	}
+	q.AsOf, err = asOf(r)
+	return
+}
+
+// GetStats is a method that returns a handler for the route GET /vehicles/stats?group_by={fields}&metrics={metrics}&as_of={time}
+func (h *VehicleDefault) GetStats() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
+		// - get query params
+		q, err := aggregateQuery(r)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, err))
+			return
+		}
+
+		// process
+		// - aggregate vehicles
+		groups, err := sv.Aggregate(q)
+		if err != nil {
+			code := http.StatusNotFound
+			if errors.Is(err, internal.ErrInvalidAggregate) || errors.Is(err, internal.ErrHistoryNotKept) {
+				code = http.StatusBadRequest
+			}
+			writeError(w, r, code, err)
+			return
+		}
+
+		// response
+		data := make([]map[string]any, 0, len(groups))
+		for _, group := range groups {
+			data = append(data, map[string]any{
+				"group":   group.Key,
+				"metrics": group.Values,
 			})
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    data,
+		})
+	}
+}
+
+// GetSummary is a method that returns a handler for the route GET /vehicles/summary
+func (h *VehicleDefault) GetSummary() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
+		// ...
+
+		// process
+		// - get summary
+		s, err := sv.Summary()
+		if err != nil {
+			response.JSON(w, http.StatusInternalServerError, nil)
+			return
+		}
+
+		// response
+		data := map[string]any{
+			"total":            s.Total,
+			"by_brand":         s.ByBrand,
+			"by_fuel_type":     s.ByFuelType,
+			"by_transmission":  s.ByTransmission,
+			"by_color":         s.ByColor,
+			"by_decade":        s.ByDecade,
+			"fastest":          nil,
+			"heaviest":         nil,
+			"average_capacity": s.AverageCapacity,
+			"total_passengers": s.TotalCapacity,
+		}
+		for key, value := range map[string]*internal.Vehicle{"fastest": s.Fastest, "heaviest": s.Heaviest} {
+			if value == nil {
+				continue
+			}
+			data[key] = *vehicleJSON(value)
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    data,
+		})
+	}
+}
+
+// Search is a method that returns a handler for the route GET /vehicles/search?q={text}&limit={limit}
+func (h *VehicleDefault) Search() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
+		// - get query params
+		q := strings.TrimSpace(r.URL.Query().Get("q"))
+		if q == "" {
+			writeError(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, internal.NewError("parameter_required", "q")))
+			return
+		}
+		limit := 0
+		if value := r.URL.Query().Get("limit"); value != "" {
+			var err error
+			limit, err = strconv.Atoi(value)
+			if err != nil || limit < 0 {
+				writeError(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, internal.NewError("parameter_positive", "limit")))
+				return
+			}
+		}
+
+		// process
+		// - search vehicles
+		results, err := sv.Search(q)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
+		if limit > 0 && len(results) > limit {
+			results = results[:limit]
+		}
+
+		// response
+		data := make([]map[string]any, 0, len(results))
+		for _, value := range results {
+			data = append(data, map[string]any{
+				"score":   value.Score,
+				"vehicle": vehicleJSON(&value.Vehicle),
+			})
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    data,
+		})
+	}
+}
+
+// vehicleID is a method that returns the id of the vehicle in the url, given either by its id or by its uid,
+// also looked up in the trash for the vehicles to restore or purge
+func (h *VehicleDefault) vehicleID(r *http.Request) (id int, err error) {
+	param := chi.URLParam(r, "id")
+	id, err = strconv.Atoi(param)
+	if err == nil {
+		return
+	}
+	sv, err := h.service(r)
+	if err != nil {
+		return
+	}
+	v, err := sv.FindByUID(param)
+	if errors.Is(err, internal.ErrVehicleNotFound) {
+		trash, _ := sv.FindTrash()
+		for _, value := range trash {
+			if value.UID == param {
+				v, err = value.Vehicle, nil
+				break
+			}
+		}
+	}
+	if err != nil {
+		return
+	}
+	id = v.Id
+	return
+}
+
+// returnMinimal is a function that returns whether a request prefers a write to be answered without the resource
+func returnMinimal(r *http.Request) bool {
+	for _, value := range r.Header.Values("Prefer") {
+		for _, preference := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(preference), "return=minimal") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writeVehicle is a function that answers a write with the vehicle written and its ETag,
+// or with the message only when the request prefers it minimal
+func writeVehicle(w http.ResponseWriter, r *http.Request, code int, mesg string, v internal.Vehicle) {
+	w.Header().Set("ETag", etag(v.Version))
+	if returnMinimal(r) {
+		w.Header().Set("Preference-Applied", "return=minimal")
+		response.JSON(w, code, map[string]any{
+			"message": message(w, r, mesg),
+		})
+		return
+	}
+	response.JSON(w, code, map[string]any{
+		"message": message(w, r, mesg),
+		"data":    vehicleJSON(&v),
+	})
+}
+
+// vehicleLocation is a function that returns the url of a vehicle, by its uid when it has one,
+// under the version of the API of the request
+func vehicleLocation(r *http.Request, v internal.Vehicle) string {
+	prefix := ""
+	for _, version := range []string{"/v1", "/v2"} {
+		if strings.HasPrefix(r.URL.Path, version+"/") {
+			prefix = version
+		}
+	}
+	if v.UID != "" {
+		return prefix + "/vehicles/" + v.UID
+	}
+	return prefix + "/vehicles/" + strconv.Itoa(v.Id)
+}
+
+// GetByID is a method that returns a handler for the route GET /vehicles/{id}
+func (h *VehicleDefault) GetByID() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
+		// - get id from url
+		id, err := h.vehicleID(r)
+		if err != nil {
+			code := http.StatusBadRequest
+			if errors.Is(err, internal.ErrVehicleNotFound) {
+				code = http.StatusNotFound
+			}
+			writeError(w, r, code, err)
+			return
+		}
+
+		// process
+		// - get vehicle
+		value, err := sv.FindByID(id)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		// response
+		w.Header().Set("ETag", etag(value.Version))
+		if noneMatch(r, value.Version) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    vehicleJSON(&value),
+		})
+	}
+}
+
+// Replace is a method that returns a handler for the route PUT /vehicles/{id}
+func (h *VehicleDefault) Replace() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
+		// - get id from url
+		id, err := h.vehicleID(r)
+		if err != nil {
+			code := http.StatusBadRequest
+			if errors.Is(err, internal.ErrVehicleNotFound) {
+				code = http.StatusNotFound
+			}
+			writeError(w, r, code, err)
+			return
+		}
+		// - read body to bytes
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			response.JSON(w, http.StatusBadRequest, nil)
+			return
+		}
+		// - unmarshal body to array string any for validations
+		bodyMap := map[string]any{}
+		err = json.Unmarshal(body, &bodyMap)
+		if err != nil {
+			response.JSON(w, http.StatusBadRequest, nil)
+			return
+		}
+
+		// process
+		// - validate body
+		if err = tools.ValidateField(bodyMap, "brand", "model", "registration", "color", "year", "passengers", "max_speed", "fuel_type", "transmission", "weight", "height", "length", "width"); err != nil {
+			writeError(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, err))
+			return
+		}
+		// - unmarshal body to vehicle
+		var vehicle VehicleJSON
+		err = json.Unmarshal(body, &vehicle)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, internal.ErrFieldsMissing)
+			return
+		}
+		// - replace vehicle, the id of the url wins over the one of the body
+		v, err := sv.WithActor(actor(r)).Replace(internal.Vehicle{
+			Id: id,
+			VehicleAttributes: internal.VehicleAttributes{
+				Brand:           vehicle.Brand,
+				Model:           vehicle.Model,
+				Registration:    vehicle.Registration,
+				Color:           vehicle.Color,
+				FabricationYear: vehicle.FabricationYear,
+				Capacity:        vehicle.Capacity,
+				MaxSpeed:        vehicle.MaxSpeed,
+				FuelType:        vehicle.FuelType,
+				Transmission:    vehicle.Transmission,
+				Weight:          vehicle.Weight,
+				Dimensions: internal.Dimensions{
+					Height: vehicle.Height,
+					Length: vehicle.Length,
+					Width:  vehicle.Width,
+				},
+			},
+		}, ifMatch(r))
+		if err != nil {
+			code := http.StatusNotFound
+			switch {
+			case errors.Is(err, internal.ErrVehicleNotInCatalog):
+				code = http.StatusBadRequest
+			case errors.Is(err, internal.ErrVehicleVersionMismatch):
+				code = http.StatusPreconditionFailed
+			}
+			writeError(w, r, code, err)
 			return
 		}
 
 		// response
+		writeVehicle(w, r, http.StatusOK, "success", v)
+	}
+}
+
+// GetTrash is a method that returns a handler for the route GET /vehicles/trash
+func (h *VehicleDefault) GetTrash() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
+		// process
+		// - get vehicles in the trash
+		v, err := sv.FindTrash()
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		// response
+		type TrashedVehicleJSON struct {
+			VehicleJSON
+			DeletedAt time.Time `json:"deleted_at"`
+		}
+		data := make(map[int]TrashedVehicleJSON)
+		for key, value := range v {
+			data[key] = TrashedVehicleJSON{
+				VehicleJSON: *vehicleJSON(&value.Vehicle),
+				DeletedAt:   value.DeletedAt,
+			}
+		}
 		response.JSON(w, http.StatusOK, map[string]any{
 			"message": "success",
-			"data":    vehicles,
+			"data":    data,
 		})
 	}
 }
+
+// Restore is a method that returns a handler for the route POST /vehicles/{id}/restore
+func (h *VehicleDefault) Restore() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
+		// - get id from url
+		id, err := h.vehicleID(r)
+		if err != nil {
+			code := http.StatusBadRequest
+			if errors.Is(err, internal.ErrVehicleNotFound) {
+				code = http.StatusNotFound
+			}
+			writeError(w, r, code, err)
+			return
+		}
+
+		// process
+		// - restore vehicle
+		v, err := sv.WithActor(actor(r)).Restore(id)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		// response
+		writeVehicle(w, r, http.StatusOK, internal.MesgVehicleRestored, v)
+	}
+}
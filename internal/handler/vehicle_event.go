@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"app/internal"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bootcamp-go/web/response"
+)
+
+// VehicleEventJSON is a struct that represents a vehicle event in JSON format
+type VehicleEventJSON struct {
+	ID        int64        `json:"id"`
+	Tenant    string       `json:"tenant"`
+	Type      string       `json:"type"`
+	Timestamp time.Time    `json:"timestamp"`
+	VehicleID int          `json:"vehicle_id"`
+	Before    *VehicleJSON `json:"before"`
+	After     *VehicleJSON `json:"after"`
+}
+
+// NewVehicleEventDefault is a function that returns a new instance of VehicleEventDefault
+func NewVehicleEventDefault(sv internal.VehicleEventService) *VehicleEventDefault {
+	return &VehicleEventDefault{sv: sv, heartbeat: 15 * time.Second}
+}
+
+// VehicleEventDefault is a struct with methods that represent handlers for vehicle events
+type VehicleEventDefault struct {
+	// sv is the service that will be used by the handler
+	sv internal.VehicleEventService
+	// heartbeat is how often a comment is sent to keep idle streams open
+	heartbeat time.Duration
+}
+
+// Stream is a method that returns a handler for the route GET /vehicles/events, a Server-Sent Events stream
+// of the writes on the vehicles of the tenant matching the filter params of the list endpoints, resumed after Last-Event-ID.
+// Each tenant has a feed of its own, whose ids a stream skips when the writes do not match its filter
+func (h *VehicleEventDefault) Stream() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - get filter from query
+		filter, err := vehicleFilter(r)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, err))
+			return
+		}
+		// - get last event id from header, or query for clients that can not set headers
+		var after int64
+		lastEventID := r.Header.Get("Last-Event-ID")
+		if lastEventID == "" {
+			lastEventID = r.URL.Query().Get("last_event_id")
+		}
+		if lastEventID != "" {
+			after, err = strconv.ParseInt(lastEventID, 10, 64)
+			if err != nil {
+				writeError(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, err))
+				return
+			}
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			response.JSON(w, http.StatusInternalServerError, nil)
+			return
+		}
+
+		// process
+		// - subscribe to the feed of the tenant, for the writes matching the filter only
+		backlog, events, cancel, err := h.sv.Subscribe(tenant(r), after, func(e internal.VehicleEvent) bool { return e.Match(filter) })
+		expired := errors.Is(err, internal.ErrVehicleEventsExpired)
+		if errors.Is(err, internal.ErrTenantNotFound) {
+			writeError(w, r, http.StatusNotFound, err)
+			return
+		}
+		if err != nil && !expired {
+			response.JSON(w, http.StatusInternalServerError, nil)
+			return
+		}
+		defer cancel()
+
+		// response
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+		if expired {
+			// - the client missed events, it has to reload the vehicles
+			fmt.Fprintf(w, "event: reset\ndata: %q\n\n", localize(internal.ErrVehicleEventsExpired, language(r)))
+		}
+		for _, e := range backlog {
+			if err = writeEvent(w, e); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+
+		heartbeat := time.NewTicker(h.heartbeat)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-heartbeat.C:
+				if _, err = fmt.Fprint(w, ": ping\n\n"); err != nil {
+					return
+				}
+			case e, ok := <-events:
+				if !ok {
+					// - the client fell behind, it resumes from the last event id when it reconnects
+					return
+				}
+				if err = writeEvent(w, e); err != nil {
+					return
+				}
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// writeEvent is a function that writes an event in the Server-Sent Events format
+func writeEvent(w http.ResponseWriter, e internal.VehicleEvent) (err error) {
+	data, err := json.Marshal(VehicleEventJSON{
+		ID:        e.ID,
+		Tenant:    e.Tenant,
+		Type:      e.Type,
+		Timestamp: e.Timestamp,
+		VehicleID: e.VehicleID,
+		Before:    vehicleJSON(e.Before),
+		After:     vehicleJSON(e.After),
+	})
+	if err != nil {
+		return
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	return
+}
+
+// vehicleJSON is a function that returns a vehicle in JSON format, nil for no vehicle
+func vehicleJSON(v *internal.Vehicle) *VehicleJSON {
+	if v == nil {
+		return nil
+	}
+	return &VehicleJSON{
+		ID:              v.Id,
+		UID:             v.UID,
+		Brand:           v.Brand,
+		Model:           v.Model,
+		Registration:    v.Registration,
+		Color:           v.Color,
+		FabricationYear: v.FabricationYear,
+		Capacity:        v.Capacity,
+		MaxSpeed:        v.MaxSpeed,
+		FuelType:        v.FuelType,
+		Transmission:    v.Transmission,
+		Weight:          v.Weight,
+		Height:          v.Height,
+		Length:          v.Length,
+		Width:           v.Width,
+	}
+}
@@ -0,0 +1,337 @@
+package handler
+
+import (
+	"app/internal"
+	"app/platform/websocket"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SocketFilterJSON is a struct that represents the filter of a subscription in JSON format
+type SocketFilterJSON struct {
+	Brand        string `json:"brand"`
+	Model        string `json:"model"`
+	Color        string `json:"color"`
+	FuelType     string `json:"fuel_type"`
+	Transmission string `json:"transmission"`
+	MinYear      int    `json:"min_year"`
+	MaxYear      int    `json:"max_year"`
+}
+
+// SocketMessageJSON is a struct that represents a message of the websocket API in JSON format, in both directions.
+// The client sends subscribe, unsubscribe and update messages, each with an id of its own choice that the server
+// echoes in the ack or error message answering it. The server sends a snapshot of the vehicles of a subscription
+// once subscribed and then a change message for every write on them, whose vehicle is nil when it left the set
+type SocketMessageJSON struct {
+	Type         string                          `json:"type"`
+	ID           string                          `json:"id,omitempty"`
+	Subscription string                          `json:"subscription,omitempty"`
+	Filter       *SocketFilterJSON               `json:"filter,omitempty"`
+	VehicleID    int                             `json:"vehicle_id,omitempty"`
+	Fields       map[string]any                  `json:"fields,omitempty"`
+	Version      int                             `json:"version,omitempty"`
+	Event        string                          `json:"event,omitempty"`
+	Changes      map[string]internal.FieldChange `json:"changes,omitempty"`
+	Vehicle      *VehicleJSON                    `json:"vehicle,omitempty"`
+	Vehicles     []VehicleJSON                   `json:"vehicles,omitempty"`
+	Message      string                          `json:"message,omitempty"`
+	Code         string                          `json:"code,omitempty"`
+}
+
+// NewVehicleSocket is a function that returns a new instance of VehicleSocket, accepting the browsers of the host
+// of the API and of origins
+func NewVehicleSocket(sv internal.VehicleTenantService, ev internal.VehicleEventService, origins []string) *VehicleSocket {
+	return &VehicleSocket{sv: sv, ev: ev, origins: origins, queueSize: 256, writeTimeout: 10 * time.Second, pingInterval: 30 * time.Second}
+}
+
+// VehicleSocket is a struct with methods that represent the handler of the websocket API for vehicles
+type VehicleSocket struct {
+	// sv is the service of the tenants the vehicles are read and updated with
+	sv internal.VehicleTenantService
+	// ev is the service the writes on the vehicles are received from
+	ev internal.VehicleEventService
+	// origins is the list of the origins of the browsers allowed besides the host of the API, "*" for any
+	origins []string
+	// queueSize is the number of messages waiting to be written to a client before it is dropped as too slow
+	queueSize int
+	// writeTimeout is how long a message may take to be written to a client
+	writeTimeout time.Duration
+	// pingInterval is how often a client is pinged, it is dropped after two intervals without any frame
+	pingInterval time.Duration
+}
+
+// socketSession is a struct that represents the state of a websocket client
+type socketSession struct {
+	// conn is the connection of the client
+	conn *websocket.Conn
+	// tenant is the tenant of the client, whose writes only are sent
+	tenant string
+	// lang is the language of the messages of the client
+	lang string
+	// sv is the service of the tenant acting on behalf of the client
+	sv internal.VehicleService
+	// forbidden is ErrForbidden when the client lacks the operator role to update vehicles, nil otherwise
+	forbidden error
+	// out is the queue of the messages to write to the client
+	out chan SocketMessageJSON
+	// done is closed when the session ends
+	done chan struct{}
+	// once guards the end of the session
+	once sync.Once
+	// mu is the lock of the subscriptions
+	mu sync.Mutex
+	// subscriptions is the filter of each subscription, by id
+	subscriptions map[string]internal.VehicleFilter
+	// next is the id of the next subscription
+	next int
+}
+
+// Serve is a method that returns a handler for the route GET /vehicles/ws, upgraded to the websocket API
+func (h *VehicleSocket) Serve() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - get service of the tenant
+		sv, err := h.sv.Tenant(tenant(r))
+		if err != nil {
+			problem(w, r, http.StatusNotFound, err)
+			return
+		}
+		// - upgrade connection, the handshake errors are already answered
+		conn, err := websocket.Upgrade(w, r, h.origins)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetIdleTimeout(2 * h.pingInterval)
+
+		// process
+		s := &socketSession{
+			conn:          conn,
+			tenant:        tenant(r),
+			lang:          language(r),
+			sv:            sv.WithActor(actor(r)),
+			forbidden:     allow(r, internal.RoleOperator),
+			out:           make(chan SocketMessageJSON, h.queueSize),
+			done:          make(chan struct{}),
+			subscriptions: make(map[string]internal.VehicleFilter),
+		}
+		// - receive the writes on the vehicles of the tenant matching any subscription
+		_, events, cancel, err := h.ev.Subscribe(s.tenant, 0, s.wants)
+		if err != nil {
+			conn.WriteClose(websocket.CloseTryAgainLater, err.Error())
+			return
+		}
+		defer cancel()
+
+		go h.write(s)
+		go h.forward(s, events)
+		h.read(s)
+	}
+}
+
+// read is a method that answers the messages of a client until the session ends
+func (h *VehicleSocket) read(s *socketSession) {
+	defer s.end(websocket.CloseNormal, "")
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var m SocketMessageJSON
+		if err = json.Unmarshal(data, &m); err != nil {
+			s.fail(SocketMessageJSON{}, errors.Join(internal.ErrFieldsMissing, err))
+			continue
+		}
+
+		switch m.Type {
+		case "subscribe":
+			h.subscribe(s, m)
+		case "unsubscribe":
+			s.mu.Lock()
+			_, ok := s.subscriptions[m.Subscription]
+			delete(s.subscriptions, m.Subscription)
+			s.mu.Unlock()
+			if !ok {
+				s.fail(SocketMessageJSON{ID: m.ID, Subscription: m.Subscription}, internal.NewError("unknown_subscription"))
+				continue
+			}
+			s.send(SocketMessageJSON{Type: "ack", ID: m.ID, Subscription: m.Subscription})
+		case "update":
+			h.update(s, m)
+		default:
+			s.fail(SocketMessageJSON{ID: m.ID}, internal.NewError("unknown_message_type", m.Type))
+		}
+	}
+}
+
+// subscribe is a method that adds a subscription of a client and sends the vehicles it holds now
+func (h *VehicleSocket) subscribe(s *socketSession, m SocketMessageJSON) {
+	var filter internal.VehicleFilter
+	if m.Filter != nil {
+		filter = internal.VehicleFilter{
+			Brand:        m.Filter.Brand,
+			Model:        m.Filter.Model,
+			Color:        m.Filter.Color,
+			FuelType:     m.Filter.FuelType,
+			Transmission: m.Filter.Transmission,
+			MinYear:      m.Filter.MinYear,
+			MaxYear:      m.Filter.MaxYear,
+		}
+	}
+
+	// the subscription is added before reading the vehicles, so no write is missed in between
+	s.mu.Lock()
+	s.next++
+	id := strconv.Itoa(s.next)
+	s.subscriptions[id] = filter
+	s.mu.Unlock()
+	s.send(SocketMessageJSON{Type: "ack", ID: m.ID, Subscription: id})
+
+	v, err := s.sv.FindAll()
+	if err != nil {
+		s.fail(SocketMessageJSON{ID: m.ID, Subscription: id}, err)
+		return
+	}
+	vehicles := make([]VehicleJSON, 0)
+	for _, value := range v {
+		if filter.Match(value) {
+			vehicles = append(vehicles, *vehicleJSON(&value))
+		}
+	}
+	s.send(SocketMessageJSON{Type: "snapshot", ID: m.ID, Subscription: id, Vehicles: vehicles})
+}
+
+// update is a method that updates the speed or fuel type of a vehicle on behalf of a client with the operator role
+func (h *VehicleSocket) update(s *socketSession, m SocketMessageJSON) {
+	if s.forbidden != nil {
+		s.fail(SocketMessageJSON{ID: m.ID, VehicleID: m.VehicleID}, s.forbidden)
+		return
+	}
+	fields := make(map[string]any, len(m.Fields))
+	for key, value := range m.Fields {
+		switch key {
+		case "speed":
+			if speed, ok := value.(float64); !ok || speed < 0 {
+				s.fail(SocketMessageJSON{ID: m.ID, VehicleID: m.VehicleID}, internal.ErrInvalidSpeed)
+				return
+			}
+		case "fuel_type":
+		default:
+			s.fail(SocketMessageJSON{ID: m.ID, VehicleID: m.VehicleID}, internal.ErrFieldsMissing)
+			return
+		}
+		fields[key] = value
+	}
+	if len(fields) == 0 {
+		s.fail(SocketMessageJSON{ID: m.ID, VehicleID: m.VehicleID}, internal.ErrFieldsMissing)
+		return
+	}
+
+	v, err := s.sv.Update(m.VehicleID, fields, m.Version)
+	if err != nil {
+		s.fail(SocketMessageJSON{ID: m.ID, VehicleID: m.VehicleID}, err)
+		return
+	}
+	s.send(SocketMessageJSON{Type: "ack", ID: m.ID, VehicleID: m.VehicleID, Version: v.Version})
+}
+
+// forward is a method that sends to a client the writes on the vehicles of its subscriptions until the session ends
+func (h *VehicleSocket) forward(s *socketSession, events <-chan internal.VehicleEvent) {
+	for {
+		select {
+		case <-s.done:
+			return
+		case e, ok := <-events:
+			if !ok {
+				// the client fell behind the feed, it subscribes again when it reconnects
+				s.end(websocket.CloseTryAgainLater, "too slow")
+				return
+			}
+			s.mu.Lock()
+			for id, filter := range s.subscriptions {
+				if !e.Match(filter) {
+					continue
+				}
+				m := SocketMessageJSON{Type: "change", Subscription: id, Event: e.Type, VehicleID: e.VehicleID, Changes: internal.Diff(e.Before, e.After)}
+				if e.After != nil && filter.Match(*e.After) {
+					m.Vehicle = vehicleJSON(e.After)
+				}
+				s.send(m)
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// write is a method that writes the queued messages and the pings to a client until the session ends
+func (h *VehicleSocket) write(s *socketSession) {
+	ping := time.NewTicker(h.pingInterval)
+	defer ping.Stop()
+	for {
+		var op int
+		var data []byte
+		select {
+		case <-s.done:
+			return
+		case <-ping.C:
+			op = websocket.OpPing
+		case m := <-s.out:
+			var err error
+			data, err = json.Marshal(m)
+			if err != nil {
+				continue
+			}
+			op = websocket.OpText
+		}
+		s.conn.SetWriteDeadline(time.Now().Add(h.writeTimeout))
+		if err := s.conn.WriteMessage(op, data); err != nil {
+			s.end(websocket.CloseGoingAway, "")
+			return
+		}
+	}
+}
+
+// wants is a method that reports whether an event matches any subscription of a client
+func (s *socketSession) wants(e internal.VehicleEvent) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, filter := range s.subscriptions {
+		if e.Match(filter) {
+			return true
+		}
+	}
+	return false
+}
+
+// send is a method that queues a message for a client, ending the session when the client is too slow to keep up
+func (s *socketSession) send(m SocketMessageJSON) {
+	select {
+	case s.out <- m:
+	case <-s.done:
+	default:
+		s.end(websocket.CloseTryAgainLater, "too slow")
+	}
+}
+
+// fail is a method that sends an error to a client in its language, answering one of its messages
+func (s *socketSession) fail(m SocketMessageJSON, err error) {
+	m.Type = "error"
+	m.Message = localize(err, s.lang)
+	m.Code = errorCode(err, http.StatusBadRequest)
+	s.send(m)
+}
+
+// end is a method that ends the session once, closing the connection
+func (s *socketSession) end(code int, reason string) {
+	s.once.Do(func() {
+		close(s.done)
+		s.conn.SetWriteDeadline(time.Now().Add(time.Second))
+		s.conn.WriteClose(code, reason)
+		s.conn.Close()
+	})
+}
@@ -0,0 +1,144 @@
+package handler_test
+
+import (
+	"app/internal"
+	"app/internal/handler"
+	"app/internal/repository"
+	"app/internal/service"
+	"app/platform/websocket"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// newSocketServer is a function that returns a server of the websocket API of a fleet of one vehicle,
+// authenticated with the keys "viewer" and "operator" of those roles
+func newSocketServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	db := map[int]internal.Vehicle{1: {Id: 1, VehicleAttributes: internal.VehicleAttributes{Brand: "Ford", Model: "Focus", MaxSpeed: 180}}}
+	rp := repository.NewVehicleMap(db)
+	svEvent := service.NewVehicleEventDefault(map[string]internal.VehicleEventRepository{internal.DefaultTenant: repository.NewVehicleEventRing(16, 16)})
+	bus := internal.NewEventBus(1, nil)
+	t.Cleanup(bus.Close)
+	internal.Subscribe(bus, svEvent.Record)
+	svCatalog := service.NewCatalogDefault(repository.NewCatalogMap(db), true)
+	sv := service.NewVehicleTenants(map[string]internal.VehicleService{
+		internal.DefaultTenant: service.NewVehicleDefault(rp, svCatalog, bus).WithTenant(internal.DefaultTenant),
+	})
+
+	hash := func(key string) string {
+		sum := sha256.Sum256([]byte(key))
+		return hex.EncodeToString(sum[:])
+	}
+	hdAuth := handler.NewAuthDefault(internal.AuthConfig{APIKeys: []internal.APIKey{
+		{Name: "viewer", Hash: hash("viewer"), Role: internal.RoleViewer},
+		{Name: "operator", Hash: hash("operator"), Role: internal.RoleOperator},
+	}})
+	hd := handler.NewVehicleSocket(sv, svEvent, []string{"https://fleet.example.com"})
+	rt := chi.NewRouter()
+	rt.With(hdAuth.Authenticate, hdAuth.Authorize).Get("/vehicles/ws", hd.Serve())
+	srv := httptest.NewServer(rt)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// dial is a function that opens the websocket API of a server with an API key and an origin, none when empty
+func dial(t *testing.T, srv *httptest.Server, key string, origin string) (*websocket.Conn, error) {
+	t.Helper()
+	header := http.Header{"X-API-Key": {key}}
+	if origin != "" {
+		header.Set("Origin", origin)
+	}
+	return websocket.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/vehicles/ws", header)
+}
+
+// exchange is a function that sends a message and returns the next message received of one of types
+func exchange(t *testing.T, conn *websocket.Conn, m handler.SocketMessageJSON, types ...string) (res handler.SocketMessageJSON) {
+	t.Helper()
+	data, _ := json.Marshal(m)
+	if err := conn.WriteMessage(websocket.OpText, data); err != nil {
+		t.Fatal(err)
+	}
+	return next(t, conn, types...)
+}
+
+// next is a function that returns the next message received of one of types, skipping the rest
+func next(t *testing.T, conn *websocket.Conn, types ...string) (res handler.SocketMessageJSON) {
+	t.Helper()
+	conn.SetIdleTimeout(5 * time.Second)
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		res = handler.SocketMessageJSON{}
+		if err = json.Unmarshal(data, &res); err != nil {
+			t.Fatal(err)
+		}
+		for _, typ := range types {
+			if res.Type == typ {
+				return
+			}
+		}
+	}
+}
+
+func TestVehicleSocket_Origin(t *testing.T) {
+	srv := newSocketServer(t)
+
+	// clients without an origin, browsers of the host of the API and of the origins allowed are accepted
+	for _, origin := range []string{"", srv.URL, "https://fleet.example.com"} {
+		conn, err := dial(t, srv, "viewer", origin)
+		if err != nil {
+			t.Fatalf("origin %q: %v", origin, err)
+		}
+		conn.Close()
+	}
+
+	// the rest are rejected
+	if _, err := dial(t, srv, "viewer", "https://evil.example.com"); !errors.Is(err, websocket.ErrBadHandshake) {
+		t.Fatalf("got %v, want ErrBadHandshake", err)
+	}
+}
+
+func TestVehicleSocket_Update(t *testing.T) {
+	srv := newSocketServer(t)
+
+	// a viewer subscribes and receives the vehicles, but may not update them
+	viewer, err := dial(t, srv, "viewer", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer viewer.Close()
+	res := exchange(t, viewer, handler.SocketMessageJSON{Type: "subscribe", ID: "s"}, "snapshot")
+	if len(res.Vehicles) != 1 {
+		t.Fatalf("got %d vehicles, want 1", len(res.Vehicles))
+	}
+	res = exchange(t, viewer, handler.SocketMessageJSON{Type: "update", ID: "u", VehicleID: 1, Fields: map[string]any{"speed": 200}}, "ack", "error")
+	if res.Type != "error" || res.Code != "forbidden" {
+		t.Fatalf("got %+v, want a forbidden error", res)
+	}
+
+	// an operator updates them, and the viewer receives the change
+	operator, err := dial(t, srv, "operator", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer operator.Close()
+	res = exchange(t, operator, handler.SocketMessageJSON{Type: "update", ID: "u", VehicleID: 1, Fields: map[string]any{"speed": 200}}, "ack", "error")
+	if res.Type != "ack" {
+		t.Fatalf("got %+v, want an ack", res)
+	}
+	res = next(t, viewer, "change")
+	if res.VehicleID != 1 || res.Vehicle == nil || res.Vehicle.MaxSpeed != 200 {
+		t.Fatalf("got %+v, want the change of the speed of vehicle 1", res)
+	}
+}
@@ -0,0 +1,258 @@
+package handler_test
+
+import (
+	"app/internal"
+	"app/internal/handler"
+	"app/internal/repository"
+	"app/internal/service"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// newTestVehicleHandler is a function that returns the vehicle handlers of the default tenant on top of vehicle 1,
+// whose ids are allocated by the server unless the clients choose them
+func newTestVehicleHandler(t *testing.T) *handler.VehicleDefault {
+	t.Helper()
+	db := map[int]internal.Vehicle{1: {Id: 1, VehicleAttributes: internal.VehicleAttributes{Brand: "Ford"}}}
+	seq, err := repository.NewVehicleSequenceFile(filepath.Join(t.TempDir(), "vehicles.seq"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rp, err := repository.NewVehicleIDs(repository.NewVehicleMap(db), seq, repository.VehicleIDSequence, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svCatalog := service.NewCatalogDefault(repository.NewCatalogMap(db), true)
+	sv := service.NewVehicleTenants(map[string]internal.VehicleService{internal.DefaultTenant: service.NewVehicleDefault(rp, svCatalog, nil)})
+	return handler.NewVehicleDefault(sv)
+}
+
+// newFleetHandler is a function that returns the vehicle handlers of the default tenant on top of a fleet
+func newFleetHandler(db map[int]internal.Vehicle) *handler.VehicleDefault {
+	svCatalog := service.NewCatalogDefault(repository.NewCatalogMap(db), true)
+	sv := service.NewVehicleTenants(map[string]internal.VehicleService{internal.DefaultTenant: service.NewVehicleDefault(repository.NewVehicleMap(db), svCatalog, nil)})
+	return handler.NewVehicleDefault(sv)
+}
+
+// batchEntry is a function that returns a vehicle of a batch, with its id when not 0
+func batchEntry(id int, registration string) map[string]any {
+	entry := map[string]any{
+		"brand": "Ford", "model": "Fiesta", "registration": registration, "color": "Red", "year": 2010, "passengers": 5,
+		"max_speed": 180.0, "fuel_type": "gasoline", "transmission": "manual", "weight": 1000.0, "height": 1.5, "length": 4.0, "width": 1.7,
+	}
+	if id != 0 {
+		entry["id"] = id
+	}
+	return entry
+}
+
+func TestVehicleDefault_CreateMultipleMixedIDs(t *testing.T) {
+	post := func(hd *handler.VehicleDefault, batch map[string]any) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(batch)
+		res := httptest.NewRecorder()
+		hd.CreateMultiple()(res, httptest.NewRequest(http.MethodPost, "/vehicles/batch", strings.NewReader(string(body))))
+		return res
+	}
+
+	// the entries without id get one of their own, not the id of another entry
+	hd := newTestVehicleHandler(t)
+	res := post(hd, map[string]any{"a": batchEntry(10, "A"), "b": batchEntry(0, "B"), "c": batchEntry(0, "C")})
+	if res.Code != http.StatusCreated {
+		t.Fatalf("got %d, want %d: %s", res.Code, http.StatusCreated, res.Body)
+	}
+	var body struct {
+		Data []handler.VehicleJSON `json:"data"`
+	}
+	if err := json.Unmarshal(res.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	registrations := make(map[int]string)
+	for _, v := range body.Data {
+		registrations[v.ID] = v.Registration
+	}
+	if len(registrations) != 3 || registrations[10] != "A" {
+		t.Errorf("got %v, want 3 vehicles with A as 10", registrations)
+	}
+
+	// a batch repeating an id is rejected as a whole
+	hd = newTestVehicleHandler(t)
+	if res = post(hd, map[string]any{"a": batchEntry(10, "A"), "b": batchEntry(10, "B"), "c": batchEntry(0, "C")}); res.Code != http.StatusConflict {
+		t.Errorf("got %d, want %d", res.Code, http.StatusConflict)
+	}
+	if res = post(hd, map[string]any{"a": batchEntry(1, "A")}); res.Code != http.StatusConflict {
+		t.Errorf("got %d, want %d", res.Code, http.StatusConflict)
+	}
+}
+
+func TestVehicleDefault_GetByIDNotModified(t *testing.T) {
+	rt := chi.NewRouter()
+	rt.Get("/vehicles/{id}", newTestVehicleHandler(t).GetByID())
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/vehicles/1", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		res := httptest.NewRecorder()
+		rt.ServeHTTP(res, req)
+		return res
+	}
+
+	res := get("")
+	tag := res.Header().Get("ETag")
+	if res.Code != http.StatusOK || tag == "" {
+		t.Fatalf("got %d with etag %q", res.Code, tag)
+	}
+
+	// the version held by the client is the current one
+	res = get(tag)
+	if res.Code != http.StatusNotModified {
+		t.Fatalf("got %d, want %d", res.Code, http.StatusNotModified)
+	}
+	if got := res.Header().Get("ETag"); got != tag {
+		t.Errorf("got etag %q, want %q", got, tag)
+	}
+	if res.Body.Len() != 0 || res.Header().Get("Content-Type") != "" {
+		t.Errorf("got body %q of type %q, want none", res.Body, res.Header().Get("Content-Type"))
+	}
+
+	// it is not
+	if res = get(`"0"`); res.Code != http.StatusOK {
+		t.Errorf("got %d, want %d", res.Code, http.StatusOK)
+	}
+}
+
+func TestVehicleDefault_Search(t *testing.T) {
+	db := map[int]internal.Vehicle{
+		1: {Id: 1, VehicleAttributes: internal.VehicleAttributes{Brand: "Ford", Model: "Focus"}},
+		2: {Id: 2, VehicleAttributes: internal.VehicleAttributes{Brand: "Ford", Model: "Fiesta"}},
+		3: {Id: 3, VehicleAttributes: internal.VehicleAttributes{Brand: "Shelby", Model: "Ford GT"}},
+	}
+	hd := newFleetHandler(db)
+
+	cases := []struct {
+		name   string
+		query  string
+		status int
+		ids    []int
+	}{
+		{name: "most relevant first", query: "q=ford", status: http.StatusOK, ids: []int{3, 1, 2}},
+		{name: "limit", query: "q=ford&limit=2", status: http.StatusOK, ids: []int{3, 1}},
+		{name: "limit past the results", query: "q=ford&limit=10", status: http.StatusOK, ids: []int{3, 1, 2}},
+		{name: "no limit", query: "q=ford&limit=0", status: http.StatusOK, ids: []int{3, 1, 2}},
+		{name: "negative limit", query: "q=ford&limit=-1", status: http.StatusBadRequest},
+		{name: "missing query", query: "q=%20", status: http.StatusBadRequest},
+		{name: "no match", query: "q=tesla", status: http.StatusNotFound},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			hd.Search()(res, httptest.NewRequest(http.MethodGet, "/vehicles/search?"+c.query, nil))
+			if res.Code != c.status {
+				t.Fatalf("got %d, want %d: %s", res.Code, c.status, res.Body)
+			}
+			if c.status != http.StatusOK {
+				return
+			}
+			var body struct {
+				Data []struct {
+					Score   float64             `json:"score"`
+					Vehicle handler.VehicleJSON `json:"vehicle"`
+				} `json:"data"`
+			}
+			if err := json.Unmarshal(res.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			ids := make([]int, 0, len(body.Data))
+			for _, value := range body.Data {
+				ids = append(ids, value.Vehicle.ID)
+			}
+			if !slices.Equal(ids, c.ids) {
+				t.Errorf("got %v, want %v", ids, c.ids)
+			}
+		})
+	}
+}
+
+func TestVehicleDefault_GetStats(t *testing.T) {
+	vehicle := func(id int, fuelType string, speed float64, weight float64) internal.Vehicle {
+		return internal.Vehicle{Id: id, VehicleAttributes: internal.VehicleAttributes{Brand: "Ford", FuelType: fuelType, MaxSpeed: speed, Weight: weight}}
+	}
+	mixed := map[int]internal.Vehicle{
+		1: vehicle(1, "gasoline", 180, 1000),
+		2: vehicle(2, "gasoline", 200, 1400),
+		3: vehicle(3, "gasoline", 160, 1200),
+		4: vehicle(4, "diesel", 150, 2000),
+		5: vehicle(5, "electric", 220, 1800),
+	}
+
+	type group struct {
+		Group   map[string]string  `json:"group"`
+		Metrics map[string]float64 `json:"metrics"`
+	}
+	cases := []struct {
+		name   string
+		db     map[int]internal.Vehicle
+		query  string
+		status int
+		groups []group
+	}{
+		{name: "empty fleet", db: map[int]internal.Vehicle{}, query: "group_by=fuel_type", status: http.StatusNotFound},
+		{name: "nothing matches the filter", db: mixed, query: "fuel_type=hydrogen", status: http.StatusNotFound},
+		{
+			name: "single vehicle", db: map[int]internal.Vehicle{1: vehicle(1, "gasoline", 180, 1000)},
+			query: "metrics=count,sum(max_speed),avg(max_speed),min(weight),max(weight),p90(max_speed)", status: http.StatusOK,
+			groups: []group{{Group: map[string]string{}, Metrics: map[string]float64{
+				"count": 1, "sum(max_speed)": 180, "avg(max_speed)": 180, "min(weight)": 1000, "max(weight)": 1000, "p90(max_speed)": 180,
+			}}},
+		},
+		{
+			name: "mixed fuel types", db: mixed,
+			query: "group_by=fuel_type&metrics=count,avg(max_speed),min(weight),max(weight),p50(max_speed)", status: http.StatusOK,
+			groups: []group{
+				{Group: map[string]string{"fuel_type": "diesel"}, Metrics: map[string]float64{"count": 1, "avg(max_speed)": 150, "min(weight)": 2000, "max(weight)": 2000, "p50(max_speed)": 150}},
+				{Group: map[string]string{"fuel_type": "electric"}, Metrics: map[string]float64{"count": 1, "avg(max_speed)": 220, "min(weight)": 1800, "max(weight)": 1800, "p50(max_speed)": 220}},
+				{Group: map[string]string{"fuel_type": "gasoline"}, Metrics: map[string]float64{"count": 3, "avg(max_speed)": 180, "min(weight)": 1000, "max(weight)": 1400, "p50(max_speed)": 180}},
+			},
+		},
+		{
+			name: "whole fleet counted by default", db: mixed, query: "", status: http.StatusOK,
+			groups: []group{{Group: map[string]string{}, Metrics: map[string]float64{"count": 5}}},
+		},
+		{
+			name: "filtered", db: mixed, query: "group_by=brand,fuel_type&fuel_type=gasoline&metrics=sum(weight)", status: http.StatusOK,
+			groups: []group{{Group: map[string]string{"brand": "Ford", "fuel_type": "gasoline"}, Metrics: map[string]float64{"sum(weight)": 3600}}},
+		},
+		{name: "unknown group by field", db: mixed, query: "group_by=owner", status: http.StatusBadRequest},
+		{name: "unknown metric", db: mixed, query: "metrics=median(max_speed)", status: http.StatusBadRequest},
+		{name: "metric of a text field", db: mixed, query: "metrics=avg(brand)", status: http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			newFleetHandler(c.db).GetStats()(res, httptest.NewRequest(http.MethodGet, "/vehicles/stats?"+c.query, nil))
+			if res.Code != c.status {
+				t.Fatalf("got %d, want %d: %s", res.Code, c.status, res.Body)
+			}
+			if c.status != http.StatusOK {
+				return
+			}
+			var body struct {
+				Data []group `json:"data"`
+			}
+			if err := json.Unmarshal(res.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(body.Data, c.groups) {
+				t.Errorf("got %+v, want %+v", body.Data, c.groups)
+			}
+		})
+	}
+}
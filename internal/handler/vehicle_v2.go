@@ -0,0 +1,582 @@
+package handler
+
+import (
+	"app/internal"
+	"app/platform/tools"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bootcamp-go/web/response"
+)
+
+// Page sizes of the lists of the version 2 of the API
+const (
+	// defaultPageSize is the number of items of a page when the request does not set a limit
+	defaultPageSize = 50
+	// maxPageSize is the largest number of items of a page
+	maxPageSize = 500
+)
+
+// DimensionsJSON is a struct that represents the dimensions of a vehicle in JSON format
+type DimensionsJSON struct {
+	Height float64 `json:"height"`
+	Length float64 `json:"length"`
+	Width  float64 `json:"width"`
+}
+
+// VehicleV2JSON is a struct that represents a vehicle in the JSON format of the version 2 of the API
+type VehicleV2JSON struct {
+	ID              int            `json:"id"`
+	UID             string         `json:"uid,omitempty"`
+	Version         int            `json:"version"`
+	Brand           string         `json:"brand"`
+	Model           string         `json:"model"`
+	Registration    string         `json:"registration"`
+	Color           string         `json:"color"`
+	FabricationYear int            `json:"year"`
+	Capacity        int            `json:"passengers"`
+	MaxSpeed        float64        `json:"max_speed"`
+	FuelType        string         `json:"fuel_type"`
+	Transmission    string         `json:"transmission"`
+	Weight          float64        `json:"weight"`
+	Dimensions      DimensionsJSON `json:"dimensions"`
+}
+
+// VehiclePageJSON is a struct that represents a page of vehicles in the JSON format of the version 2 of the API
+type VehiclePageJSON struct {
+	Items  []VehicleV2JSON `json:"items"`
+	Total  int             `json:"total"`
+	Limit  int             `json:"limit"`
+	Offset int             `json:"offset"`
+}
+
+// vehicleV2Fields is the list of the fields a vehicle is created or replaced with in the version 2 of the API
+var vehicleV2Fields = []string{"brand", "model", "registration", "color", "year", "passengers", "max_speed", "fuel_type", "transmission", "weight", "dimensions"}
+
+// vehicleV2OperatorFields is the list of the fields an operator may patch, the others require the admin role
+var vehicleV2OperatorFields = []string{"max_speed", "fuel_type"}
+
+// vehicleV2JSON is a function that returns a vehicle in the JSON format of the version 2 of the API
+func vehicleV2JSON(v internal.Vehicle) VehicleV2JSON {
+	return VehicleV2JSON{
+		ID:              v.Id,
+		UID:             v.UID,
+		Version:         v.Version,
+		Brand:           v.Brand,
+		Model:           v.Model,
+		Registration:    v.Registration,
+		Color:           v.Color,
+		FabricationYear: v.FabricationYear,
+		Capacity:        v.Capacity,
+		MaxSpeed:        v.MaxSpeed,
+		FuelType:        v.FuelType,
+		Transmission:    v.Transmission,
+		Weight:          v.Weight,
+		Dimensions: DimensionsJSON{
+			Height: v.Height,
+			Length: v.Length,
+			Width:  v.Width,
+		},
+	}
+}
+
+// vehicleFromV2JSON is a function that returns the vehicle of the JSON format of the version 2 of the API
+func vehicleFromV2JSON(v VehicleV2JSON) internal.Vehicle {
+	return internal.Vehicle{
+		Id: v.ID,
+		VehicleAttributes: internal.VehicleAttributes{
+			Brand:           v.Brand,
+			Model:           v.Model,
+			Registration:    v.Registration,
+			Color:           v.Color,
+			FabricationYear: v.FabricationYear,
+			Capacity:        v.Capacity,
+			MaxSpeed:        v.MaxSpeed,
+			FuelType:        v.FuelType,
+			Transmission:    v.Transmission,
+			Weight:          v.Weight,
+			Dimensions: internal.Dimensions{
+				Height: v.Dimensions.Height,
+				Length: v.Dimensions.Length,
+				Width:  v.Dimensions.Width,
+			},
+		},
+	}
+}
+
+// decodeVehicleV2 is a function that decodes a vehicle of the version 2 of the API, every field required and no other allowed
+func decodeVehicleV2(data []byte) (v VehicleV2JSON, err error) {
+	// validate fields
+	var fields map[string]any
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return
+	}
+	if err = tools.ValidateField(fields, vehicleV2Fields...); err != nil {
+		return
+	}
+	dimensions, _ := fields["dimensions"].(map[string]any)
+	if err = tools.ValidateField(dimensions, "height", "length", "width"); err != nil {
+		return
+	}
+
+	// decode vehicle
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	err = dec.Decode(&v)
+	return
+}
+
+// vehicleStatus is a function that returns the status code of an error of the vehicle service
+func vehicleStatus(err error) int {
+	switch {
+	case errors.Is(err, internal.ErrVehicleNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, internal.ErrVehicleVersionMismatch):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, internal.ErrVehicleAlreadyExists):
+		return http.StatusConflict
+	case errors.Is(err, internal.ErrFieldsMissing),
+		errors.Is(err, internal.ErrVehicleNotInCatalog),
+		errors.Is(err, internal.ErrVehicleIDAssigned),
+		errors.Is(err, internal.ErrInvalidAggregate),
+		errors.Is(err, internal.ErrHistoryNotKept):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// pagination is a function that returns the limit and offset query params of a request
+func pagination(r *http.Request) (limit int, offset int, err error) {
+	query := r.URL.Query()
+	limit = defaultPageSize
+	if value := query.Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			err = internal.NewError("parameter_positive", "limit")
+			return
+		}
+		limit = min(limit, maxPageSize)
+	}
+	if value := query.Get("offset"); value != "" {
+		offset, err = strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			err = internal.NewError("parameter_not_negative", "offset")
+			return
+		}
+	}
+	return
+}
+
+// setPageLinks is a function that sets the Link header to the next and previous pages of a list
+func setPageLinks(w http.ResponseWriter, r *http.Request, limit int, offset int, total int) {
+	link := func(offset int, rel string) string {
+		u := *r.URL
+		query := u.Query()
+		query.Set("limit", strconv.Itoa(limit))
+		query.Set("offset", strconv.Itoa(offset))
+		u.RawQuery = query.Encode()
+		return fmt.Sprintf("<%s>; rel=%q", u.RequestURI(), rel)
+	}
+	var links []string
+	if offset+limit < total {
+		links = append(links, link(offset+limit, "next"))
+	}
+	if offset > 0 {
+		links = append(links, link(max(offset-limit, 0), "prev"))
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+}
+
+// writeVehicleV2 is a function that answers a write of the version 2 of the API with the vehicle written and its ETag,
+// or with no body when the request prefers it minimal
+func writeVehicleV2(w http.ResponseWriter, r *http.Request, code int, v internal.Vehicle) {
+	w.Header().Set("ETag", etag(v.Version))
+	if returnMinimal(r) {
+		w.Header().Set("Preference-Applied", "return=minimal")
+		w.WriteHeader(code)
+		return
+	}
+	response.JSON(w, code, vehicleV2JSON(v))
+}
+
+// ListV2 is a method that returns a handler for the route
+// GET /v2/vehicles?{filters}&as_of={RFC 3339 time}&limit={limit}&offset={offset}, the vehicles sorted by id
+func (h *VehicleDefault) ListV2() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			problem(w, r, http.StatusNotFound, err)
+			return
+		}
+		// - get filter, moment and page from query
+		filter, err := vehicleFilter(r)
+		if err != nil {
+			problem(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, err))
+			return
+		}
+		at, err := asOf(r)
+		if err != nil {
+			problem(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, err))
+			return
+		}
+		limit, offset, err := pagination(r)
+		if err != nil {
+			problem(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, err))
+			return
+		}
+
+		// process
+		// - get the vehicles matching the filter
+		var v map[int]internal.Vehicle
+		if at.IsZero() {
+			v, err = sv.FindAll()
+		} else {
+			v, err = sv.FindAsOf(at)
+		}
+		if err != nil && !errors.Is(err, internal.ErrVehicleNotFound) {
+			problem(w, r, vehicleStatus(err), err)
+			return
+		}
+		ids := make([]int, 0, len(v))
+		for id, value := range v {
+			if filter.Match(value) {
+				ids = append(ids, id)
+			}
+		}
+		sort.Ints(ids)
+
+		// response
+		page := VehiclePageJSON{Items: make([]VehicleV2JSON, 0), Total: len(ids), Limit: limit, Offset: offset}
+		for _, id := range ids[min(offset, len(ids)):min(offset+limit, len(ids))] {
+			page.Items = append(page.Items, vehicleV2JSON(v[id]))
+		}
+		setPageLinks(w, r, limit, offset, len(ids))
+		response.JSON(w, http.StatusOK, page)
+	}
+}
+
+// GetByIDV2 is a method that returns a handler for the route GET /v2/vehicles/{id}
+func (h *VehicleDefault) GetByIDV2() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			problem(w, r, http.StatusNotFound, err)
+			return
+		}
+		// - get id from url
+		id, err := h.vehicleID(r)
+		if err != nil {
+			problem(w, r, vehicleStatus(err), err)
+			return
+		}
+
+		// process
+		// - get vehicle
+		v, err := sv.FindByID(id)
+		if err != nil {
+			problem(w, r, vehicleStatus(err), err)
+			return
+		}
+
+		// response
+		w.Header().Set("ETag", etag(v.Version))
+		if noneMatch(r, v.Version) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		response.JSON(w, http.StatusOK, vehicleV2JSON(v))
+	}
+}
+
+// CreateV2 is a method that returns a handler for the route POST /v2/vehicles
+func (h *VehicleDefault) CreateV2() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			problem(w, r, http.StatusNotFound, err)
+			return
+		}
+		// - decode vehicle from body
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			problem(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, err))
+			return
+		}
+		vehicle, err := decodeVehicleV2(body)
+		if err != nil {
+			problem(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, err))
+			return
+		}
+
+		// process
+		// - create vehicle
+		created, err := sv.WithActor(actor(r)).Create(vehicleFromV2JSON(vehicle))
+		if err != nil {
+			problem(w, r, vehicleStatus(err), err)
+			return
+		}
+
+		// response
+		w.Header().Set("Location", vehicleLocation(r, created))
+		writeVehicleV2(w, r, http.StatusCreated, created)
+	}
+}
+
+// CreateMultipleV2 is a method that returns a handler for the route POST /v2/vehicles/batch, whose body is an array of vehicles
+func (h *VehicleDefault) CreateMultipleV2() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			problem(w, r, http.StatusNotFound, err)
+			return
+		}
+		// - decode vehicles from body
+		var items []json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&items); err != nil {
+			problem(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, err))
+			return
+		}
+		vehicles := make([]internal.Vehicle, 0, len(items))
+		for _, item := range items {
+			vehicle, err := decodeVehicleV2(item)
+			if err != nil {
+				problem(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, err))
+				return
+			}
+			vehicles = append(vehicles, vehicleFromV2JSON(vehicle))
+		}
+
+		// process
+		// - create vehicles
+		created, err := sv.WithActor(actor(r)).CreateMultiple(vehicles)
+		if err != nil {
+			problem(w, r, vehicleStatus(err), err)
+			return
+		}
+
+		// response
+		if returnMinimal(r) {
+			w.Header().Set("Preference-Applied", "return=minimal")
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		data := make([]VehicleV2JSON, 0, len(created))
+		for _, v := range created {
+			data = append(data, vehicleV2JSON(v))
+		}
+		response.JSON(w, http.StatusCreated, data)
+	}
+}
+
+// ReplaceV2 is a method that returns a handler for the route PUT /v2/vehicles/{id}
+func (h *VehicleDefault) ReplaceV2() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			problem(w, r, http.StatusNotFound, err)
+			return
+		}
+		// - get id from url
+		id, err := h.vehicleID(r)
+		if err != nil {
+			problem(w, r, vehicleStatus(err), err)
+			return
+		}
+		// - decode vehicle from body
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			problem(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, err))
+			return
+		}
+		vehicle, err := decodeVehicleV2(body)
+		if err != nil {
+			problem(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, err))
+			return
+		}
+
+		// process
+		// - replace vehicle, the id of the url wins over the one of the body
+		vehicle.ID = id
+		v, err := sv.WithActor(actor(r)).Replace(vehicleFromV2JSON(vehicle), ifMatch(r))
+		if err != nil {
+			problem(w, r, vehicleStatus(err), err)
+			return
+		}
+
+		// response
+		writeVehicleV2(w, r, http.StatusOK, v)
+	}
+}
+
+// PatchV2 is a method that returns a handler for the route PATCH /v2/vehicles/{id}, whose body is a JSON merge patch
+// of the vehicle. Operators may patch the max_speed and fuel_type only
+func (h *VehicleDefault) PatchV2() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			problem(w, r, http.StatusNotFound, err)
+			return
+		}
+		// - get id from url
+		id, err := h.vehicleID(r)
+		if err != nil {
+			problem(w, r, vehicleStatus(err), err)
+			return
+		}
+		// - get patch from body
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			problem(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, err))
+			return
+		}
+		var fields map[string]any
+		if err = json.Unmarshal(body, &fields); err != nil {
+			problem(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, err))
+			return
+		}
+		if p, ok := principal(r); ok && !p.Role.Allows(internal.RoleAdmin) {
+			for field := range fields {
+				if !slices.Contains(vehicleV2OperatorFields, field) {
+					problem(w, r, http.StatusForbidden, internal.ErrForbidden)
+					return
+				}
+			}
+		}
+
+		// process
+		// - apply patch to the vehicle as it is now
+		sv = sv.WithActor(actor(r))
+		current, err := sv.FindByID(id)
+		if err != nil {
+			problem(w, r, vehicleStatus(err), err)
+			return
+		}
+		if version := ifMatch(r); version != 0 && version != current.Version {
+			problem(w, r, http.StatusPreconditionFailed, internal.ErrVehicleVersionMismatch)
+			return
+		}
+		patched := vehicleV2JSON(current)
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.DisallowUnknownFields()
+		if err = dec.Decode(&patched); err != nil {
+			problem(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, err))
+			return
+		}
+		// - the ids and the version are not patched
+		patched.ID = current.Id
+		// - replace vehicle, if nothing was written since it was read
+		v, err := sv.Replace(vehicleFromV2JSON(patched), current.Version)
+		if err != nil {
+			problem(w, r, vehicleStatus(err), err)
+			return
+		}
+
+		// response
+		writeVehicleV2(w, r, http.StatusOK, v)
+	}
+}
+
+// DeleteV2 is a method that returns a handler for the route DELETE /v2/vehicles/{id}?hard={true|false}
+func (h *VehicleDefault) DeleteV2() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			problem(w, r, http.StatusNotFound, err)
+			return
+		}
+		// - get id from url
+		id, err := h.vehicleID(r)
+		if err != nil {
+			problem(w, r, vehicleStatus(err), err)
+			return
+		}
+		// - get hard from query, vehicles are moved to the trash by default
+		hard := false
+		if value := r.URL.Query().Get("hard"); value != "" {
+			hard, err = strconv.ParseBool(value)
+			if err != nil {
+				problem(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, err))
+				return
+			}
+		}
+
+		// process
+		// - delete vehicle
+		sv = sv.WithActor(actor(r))
+		if hard {
+			err = sv.Purge(id, ifMatch(r))
+		} else {
+			err = sv.Delete(id, ifMatch(r))
+		}
+		if err != nil {
+			problem(w, r, vehicleStatus(err), err)
+			return
+		}
+
+		// response
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// GetStatsV2 is a method that returns a handler for the route
+// GET /v2/vehicles/stats?{filters}&group_by={fields}&metrics={metrics}&as_of={RFC 3339 time}
+func (h *VehicleDefault) GetStatsV2() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - get service of the tenant
+		sv, err := h.service(r)
+		if err != nil {
+			problem(w, r, http.StatusNotFound, err)
+			return
+		}
+		// - get query params
+		q, err := aggregateQuery(r)
+		if err != nil {
+			problem(w, r, http.StatusBadRequest, errors.Join(internal.ErrFieldsMissing, err))
+			return
+		}
+
+		// process
+		// - aggregate vehicles
+		groups, err := sv.Aggregate(q)
+		if err != nil {
+			problem(w, r, vehicleStatus(err), err)
+			return
+		}
+
+		// response
+		data := make([]map[string]any, 0, len(groups))
+		for _, group := range groups {
+			data = append(data, map[string]any{
+				"group":   group.Key,
+				"metrics": group.Values,
+			})
+		}
+		response.JSON(w, http.StatusOK, data)
+	}
+}
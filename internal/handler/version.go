@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+)
+
+// Deprecated is a function that returns a middleware marking the responses of a deprecated version of the API
+// with the Deprecation header, the Sunset header when the version has an end date, and a Link to its successor
+func Deprecated(successor string, sunset time.Time) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", "true")
+			if !sunset.IsZero() {
+				w.Header().Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+			}
+			w.Header().Add("Link", "<"+successor+`>; rel="successor-version"`)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
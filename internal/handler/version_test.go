@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"app/internal"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVehicleLocation(t *testing.T) {
+	tests := []struct {
+		path    string
+		vehicle internal.Vehicle
+		want    string
+	}{
+		{"/vehicles", internal.Vehicle{Id: 7}, "/vehicles/7"},
+		{"/v1/vehicles", internal.Vehicle{Id: 7}, "/v1/vehicles/7"},
+		{"/v1/vehicles/batch", internal.Vehicle{Id: 7, UID: "01J"}, "/v1/vehicles/01J"},
+		{"/v2/vehicles", internal.Vehicle{Id: 7}, "/v2/vehicles/7"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, tt.path, nil)
+		if got := vehicleLocation(r, tt.vehicle); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package handler
+
+import (
+	"app/internal"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bootcamp-go/web/response"
+	"github.com/go-chi/chi/v5"
+)
+
+// WebhookJSON is a struct that represents a webhook in JSON format, its secret is only shown once created
+type WebhookJSON struct {
+	ID        int       `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	Events    []string  `json:"events"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewWebhookDefault is a function that returns a new instance of WebhookDefault
+func NewWebhookDefault(sv internal.WebhookService) *WebhookDefault {
+	return &WebhookDefault{sv: sv}
+}
+
+// WebhookDefault is a struct with methods that represent handlers for webhooks
+type WebhookDefault struct {
+	// sv is the service that will be used by the handler
+	sv internal.WebhookService
+}
+
+// webhookStatus is a function that returns the status code for an error of the webhooks
+func webhookStatus(err error) int {
+	if errors.Is(err, internal.ErrFieldsMissing) {
+		return http.StatusBadRequest
+	}
+	return http.StatusNotFound
+}
+
+// readWebhook is a function that reads a webhook from the body {"url": "...", "secret": "...", "events": [...]}
+func readWebhook(r *http.Request) (w internal.Webhook, err error) {
+	var body struct {
+		URL    string   `json:"url"`
+		Secret string   `json:"secret"`
+		Events []string `json:"events"`
+	}
+	if err = json.NewDecoder(r.Body).Decode(&body); err != nil {
+		err = errors.Join(internal.ErrFieldsMissing, err)
+		return
+	}
+	w = internal.Webhook{URL: body.URL, Secret: body.Secret, Events: body.Events}
+	return
+}
+
+// webhookJSON is a function that returns a webhook in JSON format, without its secret
+func webhookJSON(w internal.Webhook) WebhookJSON {
+	events := w.Events
+	if events == nil {
+		events = []string{}
+	}
+	return WebhookJSON{ID: w.ID, URL: w.URL, Events: events, CreatedAt: w.CreatedAt}
+}
+
+// GetAll is a method that returns a handler for the route GET /webhooks
+func (h *WebhookDefault) GetAll() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// process
+		// - get webhooks
+		webhooks, err := h.sv.FindAll(tenant(r))
+		if err != nil {
+			response.JSON(w, http.StatusInternalServerError, nil)
+			return
+		}
+
+		// response
+		data := make([]WebhookJSON, 0, len(webhooks))
+		for _, value := range webhooks {
+			data = append(data, webhookJSON(value))
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    data,
+		})
+	}
+}
+
+// Create is a method that returns a handler for the route POST /webhooks
+func (h *WebhookDefault) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - read webhook from body
+		webhook, err := readWebhook(r)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, err)
+			return
+		}
+		webhook.Tenant = tenant(r)
+
+		// process
+		// - create webhook
+		webhook, err = h.sv.Create(webhook)
+		if err != nil {
+			writeError(w, r, webhookStatus(err), err)
+			return
+		}
+
+		// response
+		// - the secret is only shown here, to verify the signatures
+		data := webhookJSON(webhook)
+		data.Secret = webhook.Secret
+		response.JSON(w, http.StatusCreated, map[string]any{
+			"message": "success",
+			"data":    data,
+		})
+	}
+}
+
+// GetByID is a method that returns a handler for the route GET /webhooks/{id}
+func (h *WebhookDefault) GetByID() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - get id from url
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		// process
+		// - get webhook
+		webhook, err := h.sv.FindByID(tenant(r), id)
+		if err != nil {
+			writeError(w, r, webhookStatus(err), err)
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    webhookJSON(webhook),
+		})
+	}
+}
+
+// Update is a method that returns a handler for the route PUT /webhooks/{id}, an empty secret keeps the current one
+func (h *WebhookDefault) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - get id from url
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, err)
+			return
+		}
+		// - read webhook from body
+		webhook, err := readWebhook(r)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, err)
+			return
+		}
+		webhook.ID = id
+		webhook.Tenant = tenant(r)
+
+		// process
+		// - update webhook
+		if err = h.sv.Update(webhook); err != nil {
+			writeError(w, r, webhookStatus(err), err)
+			return
+		}
+		webhook, err = h.sv.FindByID(tenant(r), id)
+		if err != nil {
+			writeError(w, r, webhookStatus(err), err)
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    webhookJSON(webhook),
+		})
+	}
+}
+
+// Delete is a method that returns a handler for the route DELETE /webhooks/{id}
+func (h *WebhookDefault) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - get id from url
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		// process
+		// - delete webhook
+		if err = h.sv.Delete(tenant(r), id); err != nil {
+			writeError(w, r, webhookStatus(err), err)
+			return
+		}
+
+		// response
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// GetDeliveries is a method that returns a handler for the route GET /webhooks/{id}/deliveries
+func (h *WebhookDefault) GetDeliveries() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - get id from url
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		// process
+		// - get deliveries of the webhook
+		deliveries, err := h.sv.FindDeliveries(tenant(r), id)
+		if err != nil {
+			writeError(w, r, webhookStatus(err), err)
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    deliveries,
+		})
+	}
+}
+
+// GetDeadLetters is a method that returns a handler for the route GET /webhooks/dead-letters
+func (h *WebhookDefault) GetDeadLetters() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// process
+		// - get deliveries that failed for good
+		deliveries, err := h.sv.FindDeadLetters(tenant(r))
+		if err != nil {
+			writeError(w, r, webhookStatus(err), err)
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    deliveries,
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package internal
+
+import (
+	"time"
+)
+
+// IdempotencyRecord is a struct that represents a request made with an idempotency key and, once done, its response
+type IdempotencyRecord struct {
+	// Key is the idempotency key, scoped to the client that sent it
+	Key string `json:"key"`
+	// Fingerprint is the hash of the method, path and body of the request
+	Fingerprint string `json:"fingerprint"`
+	// Done is whether the response is stored, false while the request is in progress
+	Done bool `json:"done"`
+	// StatusCode is the status code of the response
+	StatusCode int `json:"status_code"`
+	// Header is the header of the response
+	Header map[string][]string `json:"header"`
+	// Body is the body of the response
+	Body []byte `json:"body"`
+	// ExpiresAt is the moment the record is forgotten
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// IdempotencyRepository is an interface that represents a store of idempotency records
+type IdempotencyRepository interface {
+	// Reserve is a method that adds a record in progress, unless an unexpired one exists with the key, which is returned
+	Reserve(r IdempotencyRecord, now time.Time) (existing IdempotencyRecord, found bool, err error)
+	// Complete is a method that stores the response of a record in progress
+	Complete(r IdempotencyRecord) (err error)
+	// Release is a method that removes a record in progress, so the key can be retried
+	Release(key string) (err error)
+	// PurgeExpired is a method that removes the records expired at a moment
+	PurgeExpired(now time.Time) (err error)
+}
+
+// IdempotencyService is an interface that represents a service of idempotency keys
+type IdempotencyService interface {
+	// Begin is a method that starts a request with a key, returning the stored record to replay when replay is true.
+	// It fails with ErrIdempotencyKeyReused when the key was used with another request, and with
+	// ErrIdempotencyInProgress when the first request with the key has not finished
+	Begin(key string, fingerprint string) (r IdempotencyRecord, replay bool, err error)
+	// End is a method that stores the response of a request started with Begin, or releases its key when r is not done
+	End(r IdempotencyRecord) (err error)
+}
+
+// Var for the errors of the idempotency keys
+var (
+	ErrIdempotencyKeyReused  = NewError("idempotency_key_reused")
+	ErrIdempotencyInProgress = NewError("idempotency_in_progress")
+)
@@ -0,0 +1,113 @@
+package loader
+
+import (
+	"app/internal"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// NewAuthJSONFile is a function that returns a new instance of AuthJSONFile
+func NewAuthJSONFile(path string) *AuthJSONFile {
+	return &AuthJSONFile{
+		path: path,
+	}
+}
+
+// AuthJSONFile is a struct that loads the credentials accepted by the API from a JSON file
+type AuthJSONFile struct {
+	// path is the path to the file that contains the credentials in JSON format
+	path string
+}
+
+// AuthJSON is a struct that represents the credentials in JSON format.
+// API keys are kept as the hex SHA-256 hash of the key, never the key itself
+type AuthJSON struct {
+	APIKeys []struct {
+		Name   string `json:"name"`
+		Hash   string `json:"hash"`
+		Role   string `json:"role"`
+		Tenant string `json:"tenant"`
+	} `json:"api_keys"`
+	JWT struct {
+		HS256Secret        string `json:"hs256_secret"`
+		RS256PublicKeyPath string `json:"rs256_public_key_path"`
+		Issuer             string `json:"issuer"`
+		Audience           string `json:"audience"`
+	} `json:"jwt"`
+}
+
+// Load is a method that loads the credentials, the public key path is relative to the file
+func (l *AuthJSONFile) Load() (c internal.AuthConfig, err error) {
+	// read file
+	file, err := os.Open(l.path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	var authJSON AuthJSON
+	if err = json.NewDecoder(file).Decode(&authJSON); err != nil {
+		return
+	}
+
+	// api keys
+	for _, k := range authJSON.APIKeys {
+		key := internal.APIKey{Name: k.Name, Hash: k.Hash, Role: internal.Role(k.Role), Tenant: k.Tenant}
+		if hash, e := hex.DecodeString(key.Hash); e != nil || len(hash) != 32 {
+			err = fmt.Errorf("api key %s: hash must be a hex SHA-256", key.Name)
+			return
+		}
+		if !key.Role.Valid() {
+			err = fmt.Errorf("api key %s: unknown role %s", key.Name, key.Role)
+			return
+		}
+		c.APIKeys = append(c.APIKeys, key)
+	}
+
+	// tokens
+	if authJSON.JWT.HS256Secret != "" {
+		c.HS256Secret = []byte(authJSON.JWT.HS256Secret)
+	}
+	if path := authJSON.JWT.RS256PublicKeyPath; path != "" {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(l.path), path)
+		}
+		if c.RS256PublicKey, err = readPublicKey(path); err != nil {
+			return
+		}
+	}
+	c.Issuer = authJSON.JWT.Issuer
+	c.Audience = authJSON.JWT.Audience
+	return
+}
+
+// readPublicKey is a function that reads an RSA public key from a PEM file, PKIX or PKCS #1
+func readPublicKey(path string) (key *rsa.PublicKey, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		err = errors.New("rs256 public key: no PEM block found")
+		return
+	}
+	if key, err = x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return
+	}
+	key, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		err = errors.New("rs256 public key: not an RSA key")
+	}
+	return
+}
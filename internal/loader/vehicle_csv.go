@@ -0,0 +1,121 @@
+package loader
+
+import (
+	"app/internal"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// NewVehicleCSVFile is a function that returns a new instance of VehicleCSVFile
+func NewVehicleCSVFile(path string) *VehicleCSVFile {
+	return &VehicleCSVFile{
+		path: path,
+	}
+}
+
+// VehicleCSVFile is a struct that implements the LoaderVehicle interface
+type VehicleCSVFile struct {
+	// path is the path to the file that contains the vehicles in CSV format, with a header row
+	path string
+}
+
+// CSVHeader is the list of columns of a vehicles CSV file
+var CSVHeader = []string{"id", "brand", "model", "registration", "color", "year", "passengers", "max_speed", "fuel_type", "transmission", "weight", "height", "length", "width"}
+
+// Load is a method that loads the vehicles
+func (l *VehicleCSVFile) Load() (v map[int]internal.Vehicle, err error) {
+	// read file
+	vehiclesJSON, err := l.Records()
+	if err != nil {
+		return
+	}
+
+	// serialize vehicles
+	v = serialize(vehiclesJSON)
+	return
+}
+
+// Records is a method that returns the vehicles of the file as they are written, duplicates included
+func (l *VehicleCSVFile) Records() (vehiclesJSON []VehicleJSON, err error) {
+	// open file
+	file, err := os.Open(l.path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	// read header
+	rd := csv.NewReader(file)
+	header, err := rd.Read()
+	if err != nil {
+		return
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[name] = i
+	}
+
+	// read rows
+	for line := 2; ; line++ {
+		var row []string
+		row, err = rd.Read()
+		if err == io.EOF {
+			err = nil
+			return
+		}
+		if err != nil {
+			return
+		}
+
+		var vh VehicleJSON
+		vh, err = parseCSVRow(columns, row)
+		if err != nil {
+			err = fmt.Errorf("line %d: %w", line, err)
+			return
+		}
+		vehiclesJSON = append(vehiclesJSON, vh)
+	}
+}
+
+// parseCSVRow is a function that converts a CSV row to a vehicle, missing columns keep their zero value
+func parseCSVRow(columns map[string]int, row []string) (vh VehicleJSON, err error) {
+	get := func(name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+	atoi := func(name string) (n int) {
+		if s := get(name); s != "" && err == nil {
+			n, err = strconv.Atoi(s)
+		}
+		return
+	}
+	atof := func(name string) (f float64) {
+		if s := get(name); s != "" && err == nil {
+			f, err = strconv.ParseFloat(s, 64)
+		}
+		return
+	}
+
+	vh = VehicleJSON{
+		Id:              atoi("id"),
+		Brand:           get("brand"),
+		Model:           get("model"),
+		Registration:    get("registration"),
+		Color:           get("color"),
+		FabricationYear: atoi("year"),
+		Capacity:        atoi("passengers"),
+		MaxSpeed:        atof("max_speed"),
+		FuelType:        get("fuel_type"),
+		Transmission:    get("transmission"),
+		Weight:          atof("weight"),
+		Height:          atof("height"),
+		Length:          atof("length"),
+		Width:           atof("width"),
+	}
+	return
+}
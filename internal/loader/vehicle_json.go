@@ -22,6 +22,7 @@ type VehicleJSONFile struct {
 // VehicleJSON is a struct that represents a vehicle in JSON format
 type VehicleJSON struct {
 	Id              int     `json:"id"`
+	UID             string  `json:"uid,omitempty"`
 	Brand           string  `json:"brand"`
 	Model           string  `json:"model"`
 	Registration    string  `json:"registration"`
@@ -39,6 +40,19 @@ type VehicleJSON struct {
 
 // Load is a method that loads the vehicles
 func (l *VehicleJSONFile) Load() (v map[int]internal.Vehicle, err error) {
+	// read file
+	vehiclesJSON, err := l.Records()
+	if err != nil {
+		return
+	}
+
+	// serialize vehicles
+	v = serialize(vehiclesJSON)
+	return
+}
+
+// Records is a method that returns the vehicles of the file as they are written, duplicates included
+func (l *VehicleJSONFile) Records() (vehiclesJSON []VehicleJSON, err error) {
 	// open file
 	file, err := os.Open(l.path)
 	if err != nil {
@@ -47,17 +61,17 @@ func (l *VehicleJSONFile) Load() (v map[int]internal.Vehicle, err error) {
 	defer file.Close()
 
 	// decode file
-	var vehiclesJSON []VehicleJSON
 	err = json.NewDecoder(file).Decode(&vehiclesJSON)
-	if err != nil {
-		return
-	}
+	return
+}
 
-	// serialize vehicles
+// serialize is a function that converts the records of a file to a map of vehicles
+func serialize(vehiclesJSON []VehicleJSON) (v map[int]internal.Vehicle) {
 	v = make(map[int]internal.Vehicle)
 	for _, vh := range vehiclesJSON {
 		v[vh.Id] = internal.Vehicle{
-			Id: vh.Id,
+			Id:  vh.Id,
+			UID: vh.UID,
 			VehicleAttributes: internal.VehicleAttributes{
 				Brand:           vh.Brand,
 				Model:           vh.Model,
@@ -77,6 +91,5 @@ func (l *VehicleJSONFile) Load() (v map[int]internal.Vehicle, err error) {
 			},
 		}
 	}
-
 	return
 }
@@ -0,0 +1,62 @@
+package loader
+
+import (
+	"app/internal"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// NewVehicleNDJSONFile is a function that returns a new instance of VehicleNDJSONFile
+func NewVehicleNDJSONFile(path string) *VehicleNDJSONFile {
+	return &VehicleNDJSONFile{
+		path: path,
+	}
+}
+
+// VehicleNDJSONFile is a struct that implements the LoaderVehicle interface
+type VehicleNDJSONFile struct {
+	// path is the path to the file that contains one vehicle in JSON format per line
+	path string
+}
+
+// Load is a method that loads the vehicles
+func (l *VehicleNDJSONFile) Load() (v map[int]internal.Vehicle, err error) {
+	// read file
+	vehiclesJSON, err := l.Records()
+	if err != nil {
+		return
+	}
+
+	// serialize vehicles
+	v = serialize(vehiclesJSON)
+	return
+}
+
+// Records is a method that returns the vehicles of the file as they are written, duplicates included
+func (l *VehicleNDJSONFile) Records() (vehiclesJSON []VehicleJSON, err error) {
+	// open file
+	file, err := os.Open(l.path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	// decode lines, skipping blank ones
+	sc := bufio.NewScanner(file)
+	for line := 1; sc.Scan(); line++ {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		var vh VehicleJSON
+		if err = json.Unmarshal(sc.Bytes(), &vh); err != nil {
+			err = fmt.Errorf("line %d: %w", line, err)
+			return
+		}
+		vehiclesJSON = append(vehiclesJSON, vh)
+	}
+	err = sc.Err()
+	return
+}
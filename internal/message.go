@@ -0,0 +1,177 @@
+package internal
+
+import "app/platform/i18n"
+
+// Languages of the messages of the API
+const (
+	// LanguageSpanish is the Spanish language
+	LanguageSpanish = "es"
+	// LanguageEnglish is the English language
+	LanguageEnglish = "en"
+	// DefaultLanguage is the language of the messages when the client accepts none of the others
+	DefaultLanguage = LanguageSpanish
+)
+
+// Error is a struct that represents an error of the API, identified by a stable code with a message in each language
+type Error struct {
+	// Code is the machine-readable code of the error
+	Code string
+	// Args are the values formatted into the message
+	Args []any
+}
+
+// NewError is a function that returns a new error of the API with a code, whose message is formatted with args
+func NewError(code string, args ...any) error {
+	return &Error{Code: code, Args: args}
+}
+
+// Error is a method that returns the message of the error in the default language
+func (e *Error) Error() string {
+	return e.Message(DefaultLanguage)
+}
+
+// Message is a method that returns the message of the error in a language
+func (e *Error) Message(lang string) string {
+	return Message(lang, e.Code, e.Args...)
+}
+
+// Message is a function that returns the message of a code in a language, formatted with args,
+// the code itself when it has no message
+func Message(lang string, code string, args ...any) string {
+	if text, ok := Messages.Text(lang, DefaultLanguage, code, args...); ok {
+		return text
+	}
+	return code
+}
+
+// Messages is the catalog of the messages of the API, by language and code
+var Messages = i18n.Catalog{
+	LanguageSpanish: {
+		// messages
+		"vehicle_created":       "201 Created: Vehículo creado exitosamente.",
+		"vehicle_speed_updated": "200 OK: Velocidad del vehículo actualizada exitosamente.",
+		"vehicle_fuel_updated":  "200 OK: Tipo de combustible del vehículo actualizado exitosamente.",
+		"vehicle_restored":      "200 OK: Vehículo restaurado exitosamente.",
+
+		// errors
+		"vehicle_already_exists":            "409 Conflict: Identificador del vehículo ya existente.",
+		"fields_missing":                    "400 Bad Request: Datos del vehículo mal formados o incompletos.",
+		"vehicle_not_found":                 "404 Not Found: No se encontraron vehículos con esos criterios.",
+		"vehicle_not_found_by_brand":        "404 Not Found: No se encontraron vehículos de esa marca.",
+		"vehicle_not_found_by_transmission": "404 Not Found: No se encontraron vehiculos con ese tipo de transmisión.",
+		"vehicle_not_found_by_dimensions":   "404 Not Found: No se encontraron vehículos con esas dimensiones.",
+		"vehicle_not_found_by_weight":       "404 Not Found: No se encontraron vehículos con ese peso.",
+		"vehicle_version_mismatch":          "412 Precondition Failed: El vehículo fue modificado por otra operación.",
+		"vehicle_id_assigned":               "400 Bad Request: El identificador del vehículo lo asigna el servidor.",
+		"invalid_year_range":                "400 Bad Request: Rango de años inválido.",
+		"invalid_speed":                     "400 Bad Request: Velocidad mal formada o fuera de rango.",
+		"internal_error":                    "500 Internal Server Error: Error interno.",
+		"catalog_not_found":                 "404 Not Found: Catálogo inexistente.",
+		"catalog_value_not_found":           "404 Not Found: Valor inexistente en el catálogo.",
+		"catalog_value_already_exists":      "409 Conflict: Valor ya existente en el catálogo.",
+		"vehicle_not_in_catalog":            "400 Bad Request: Atributo del vehículo fuera de catálogo.",
+		"vehicle_events_expired":            "410 Gone: Los eventos solicitados ya no están disponibles.",
+		"invalid_aggregate":                 "400 Bad Request: Agregación mal formada.",
+		"snapshot_not_found":                "404 Not Found: No se encontró la instantánea.",
+		"snapshot_already_exists":           "409 Conflict: Nombre de la instantánea ya existente.",
+		"history_not_kept":                  "400 Bad Request: No se conserva el historial de ese momento.",
+		"tenant_not_found":                  "404 Not Found: Tenant inexistente.",
+		"webhook_not_found":                 "404 Not Found: Webhook inexistente.",
+		"webhook_deliveries_not_found":      "404 Not Found: No se encontraron entregas.",
+		"audit_entries_not_found":           "404 Not Found: No se encontraron registros de auditoría.",
+		"unauthenticated":                   "401 Unauthorized: Credenciales ausentes o inválidas.",
+		"forbidden":                         "403 Forbidden: Permisos insuficientes para esta operación.",
+		"rate_limited":                      "429 Too Many Requests: Límite de solicitudes excedido, reintente más tarde.",
+		"quota_exceeded":                    "429 Too Many Requests: Cuota diaria de solicitudes agotada.",
+		"idempotency_key_reused":            "422 Unprocessable Entity: Idempotency-Key ya usada con otra solicitud.",
+		"idempotency_in_progress":           "409 Conflict: Solicitud con la misma Idempotency-Key en curso.",
+		"graphql_too_deep":                  "400 Bad Request: Consulta GraphQL demasiado anidada, el máximo es %d niveles.",
+		"graphql_too_complex":               "400 Bad Request: Consulta GraphQL demasiado compleja, el máximo es %d.",
+
+		// details
+		"field_required":         "el campo %s es obligatorio",
+		"parameter_required":     "el parámetro %s es obligatorio",
+		"parameter_positive":     "%s debe ser un número positivo",
+		"parameter_not_negative": "%s debe ser un número mayor o igual que cero",
+		"parameter_time":         "%s debe ser una fecha RFC 3339",
+		"parameter_too_long":     "%s debe tener como máximo %d caracteres",
+		"parameter_cursor":       "%s debe ser un cursor devuelto por la consulta",
+		"range_format":           "%[1]s debe tener el formato %[1]s={mínimo}-{máximo}",
+		"range_order":            "%s inválido, el máximo debe ser mayor que el mínimo",
+		"model_of_brand":         "modelo %s de la marca %s",
+		"history_since":          "el historial empieza el %s",
+		"webhook_url":            "la url debe ser una url absoluta http o https",
+		"webhook_url_private":    "la url no debe apuntar a una dirección privada, de loopback, link-local o de metadatos",
+		"webhook_event":          "evento desconocido %s",
+		"aggregate_group_by":     "campo de agrupación desconocido %s",
+		"aggregate_no_metrics":   "sin métricas",
+		"aggregate_metric_field": "campo desconocido en %s",
+		"aggregate_metric_func":  "función desconocida en %s",
+		"unknown_subscription":   "suscripción desconocida",
+		"unknown_message_type":   "tipo de mensaje desconocido %q",
+	},
+	LanguageEnglish: {
+		// messages
+		"vehicle_created":       "201 Created: Vehicle created successfully.",
+		"vehicle_speed_updated": "200 OK: Vehicle speed updated successfully.",
+		"vehicle_fuel_updated":  "200 OK: Vehicle fuel type updated successfully.",
+		"vehicle_restored":      "200 OK: Vehicle restored successfully.",
+
+		// errors
+		"vehicle_already_exists":            "409 Conflict: Vehicle id already exists.",
+		"fields_missing":                    "400 Bad Request: Vehicle data malformed or incomplete.",
+		"vehicle_not_found":                 "404 Not Found: No vehicles found with those criteria.",
+		"vehicle_not_found_by_brand":        "404 Not Found: No vehicles found of that brand.",
+		"vehicle_not_found_by_transmission": "404 Not Found: No vehicles found with that transmission type.",
+		"vehicle_not_found_by_dimensions":   "404 Not Found: No vehicles found with those dimensions.",
+		"vehicle_not_found_by_weight":       "404 Not Found: No vehicles found with that weight.",
+		"vehicle_version_mismatch":          "412 Precondition Failed: The vehicle was modified by another operation.",
+		"vehicle_id_assigned":               "400 Bad Request: The vehicle id is assigned by the server.",
+		"invalid_year_range":                "400 Bad Request: Invalid year range.",
+		"invalid_speed":                     "400 Bad Request: Speed malformed or out of range.",
+		"internal_error":                    "500 Internal Server Error: Internal error.",
+		"catalog_not_found":                 "404 Not Found: Catalog not found.",
+		"catalog_value_not_found":           "404 Not Found: Value not found in the catalog.",
+		"catalog_value_already_exists":      "409 Conflict: Value already exists in the catalog.",
+		"vehicle_not_in_catalog":            "400 Bad Request: Vehicle attribute not in the catalog.",
+		"vehicle_events_expired":            "410 Gone: The requested events are no longer available.",
+		"invalid_aggregate":                 "400 Bad Request: Malformed aggregation.",
+		"snapshot_not_found":                "404 Not Found: Snapshot not found.",
+		"snapshot_already_exists":           "409 Conflict: Snapshot name already exists.",
+		"history_not_kept":                  "400 Bad Request: The history of that moment is not kept.",
+		"tenant_not_found":                  "404 Not Found: Tenant not found.",
+		"webhook_not_found":                 "404 Not Found: Webhook not found.",
+		"webhook_deliveries_not_found":      "404 Not Found: No deliveries found.",
+		"audit_entries_not_found":           "404 Not Found: No audit entries found.",
+		"unauthenticated":                   "401 Unauthorized: Missing or invalid credentials.",
+		"forbidden":                         "403 Forbidden: Insufficient permissions for this operation.",
+		"rate_limited":                      "429 Too Many Requests: Rate limit exceeded, retry later.",
+		"quota_exceeded":                    "429 Too Many Requests: Daily request quota exhausted.",
+		"idempotency_key_reused":            "422 Unprocessable Entity: Idempotency-Key already used with another request.",
+		"idempotency_in_progress":           "409 Conflict: A request with the same Idempotency-Key is in progress.",
+		"graphql_too_deep":                  "400 Bad Request: GraphQL query nested too deep, the maximum is %d levels.",
+		"graphql_too_complex":               "400 Bad Request: GraphQL query too complex, the maximum is %d.",
+
+		// details
+		"field_required":         "field %s is required",
+		"parameter_required":     "parameter %s is required",
+		"parameter_positive":     "%s must be a positive number",
+		"parameter_not_negative": "%s must be a number not less than zero",
+		"parameter_time":         "%s must be a RFC 3339 time",
+		"parameter_too_long":     "%s must be up to %d characters",
+		"parameter_cursor":       "%s must be a cursor returned by the query",
+		"range_format":           "%[1]s must have the format %[1]s={min}-{max}",
+		"range_order":            "invalid %s, the max has to be greater than the min",
+		"model_of_brand":         "model %s of brand %s",
+		"history_since":          "the history starts at %s",
+		"webhook_url":            "url must be an absolute http or https url",
+		"webhook_url_private":    "url must not point to a private, loopback, link-local or metadata address",
+		"webhook_event":          "unknown event %s",
+		"aggregate_group_by":     "unknown group by field %s",
+		"aggregate_no_metrics":   "no metrics",
+		"aggregate_metric_field": "unknown field in %s",
+		"aggregate_metric_func":  "unknown function in %s",
+		"unknown_subscription":   "unknown subscription",
+		"unknown_message_type":   "unknown message type %q",
+	},
+}
@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+)
+
+// verbs is the expression of the verbs of a message, e.g. %s or %[1]s
+var verbs = regexp.MustCompile(`%(\[\d+\])?[a-z]`)
+
+func TestMessages(t *testing.T) {
+	for lang, messages := range Messages {
+		for code, text := range messages {
+			for other, translations := range Messages {
+				translation, ok := translations[code]
+				if !ok {
+					t.Errorf("code %s of %s has no message in %s", code, lang, other)
+					continue
+				}
+				// the translations take the same args
+				got, want := verbs.FindAllString(translation, -1), verbs.FindAllString(text, -1)
+				slices.Sort(got)
+				slices.Sort(want)
+				if !slices.Equal(got, want) {
+					t.Errorf("code %s takes %v in %s and %v in %s", code, got, other, want, lang)
+				}
+			}
+		}
+	}
+}
+
+func TestError_Message(t *testing.T) {
+	err := NewError("parameter_required", "q")
+	if got, want := err.(*Error).Message(LanguageEnglish), Messages[LanguageEnglish]["parameter_required"]; got == want || got == "parameter_required" {
+		t.Errorf("got %q, want the message formatted with the args", got)
+	}
+	if got := err.Error(); got != err.(*Error).Message(DefaultLanguage) {
+		t.Errorf("got %q, want the message in the default language", got)
+	}
+	if got := Message(LanguageEnglish, "no_such_code"); got != "no_such_code" {
+		t.Errorf("got %q, want the code of a message missing", got)
+	}
+}
@@ -0,0 +1,33 @@
+package internal
+
+import (
+	"time"
+)
+
+// RateLimit is a struct that represents the requests a client may make in a window, in bursts of up to Requests
+type RateLimit struct {
+	// Requests is the number of requests allowed per window, 0 for no limit
+	Requests int
+	// Window is the time the requests are allowed in
+	Window time.Duration
+}
+
+// QuotaRepository is an interface that represents the persisted counters of the daily quotas
+type QuotaRepository interface {
+	// Increment is a method that adds one to the counter of a key on a day and returns it,
+	// the counters of the previous days are dropped
+	Increment(key string, day string) (count int, err error)
+}
+
+// QuotaService is an interface that represents a service of daily quotas
+type QuotaService interface {
+	// Take is a method that counts a request of a key against its daily quota, returning the requests left,
+	// -1 without a quota, and when the quota starts again
+	Take(key string, now time.Time) (remaining int, reset time.Time, err error)
+}
+
+// Var for the errors of the rate limits
+var (
+	ErrRateLimited   = NewError("rate_limited")
+	ErrQuotaExceeded = NewError("quota_exceeded")
+)
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"app/internal"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// NewAuditFile is a function that returns a new instance of AuditFile, loading the entries already in the file
+func NewAuditFile(path string) (r *AuditFile, err error) {
+	r = &AuditFile{path: path}
+
+	// read previous entries, if any
+	err = readLines(path, func(line []byte) (err error) {
+		var e internal.AuditEntry
+		if err = json.Unmarshal(line, &e); err != nil {
+			return
+		}
+		r.entries = append(r.entries, e)
+		return
+	})
+	return
+}
+
+// AuditFile is a struct that represents an audit repository kept in an append-only file, one JSON entry per line
+type AuditFile struct {
+	// mu is the lock that serializes the writes to the file
+	mu sync.RWMutex
+	// path is the path to the file
+	path string
+	// entries is the list of entries of the file, oldest first
+	entries []internal.AuditEntry
+}
+
+// Append is a method that adds an entry to the log
+func (r *AuditFile) Append(e internal.AuditEntry) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	file, err := os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	if _, err = file.Write(append(line, '\n')); err != nil {
+		return
+	}
+	if err = file.Sync(); err != nil {
+		return
+	}
+
+	r.entries = append(r.entries, e)
+	return
+}
+
+// FindByVehicle is a method that returns the entries of a vehicle of a tenant, oldest first
+func (r *AuditFile) FindByVehicle(tenant string, id int) (entries []internal.AuditEntry, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, e := range r.entries {
+		if e.EntryTenant() == tenant && e.VehicleID == id {
+			entries = append(entries, e)
+		}
+	}
+
+	if len(entries) == 0 {
+		err = internal.ErrAuditEntriesNotFound
+	}
+	return
+}
+
+// Find is a method that returns the entries of a tenant since a moment, of an actor if not empty, oldest first
+func (r *AuditFile) Find(tenant string, since time.Time, actor string) (entries []internal.AuditEntry, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, e := range r.entries {
+		if e.EntryTenant() != tenant || e.Timestamp.Before(since) || (actor != "" && e.Actor != actor) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+
+	if len(entries) == 0 {
+		err = internal.ErrAuditEntriesNotFound
+	}
+	return
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+)
+
+// NewCatalogFile is a function that returns a new instance of CatalogFile.
+// The catalogs in the file replace the seeded ones of rp, and the file is created with them when it does not exist
+func NewCatalogFile(rp *CatalogMap, path string) (r *CatalogFile, err error) {
+	r = &CatalogFile{CatalogMap: rp, path: path}
+
+	// read previous catalogs, if any
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		err = r.save()
+		return
+	}
+	if err != nil {
+		return
+	}
+	var catalogJSON CatalogJSON
+	if err = json.Unmarshal(data, &catalogJSON); err != nil {
+		return
+	}
+	rp.restore(catalogJSON)
+	return
+}
+
+// CatalogJSON is a struct that represents the catalogs in JSON format
+type CatalogJSON struct {
+	Values map[string][]string `json:"values"`
+	Models map[string][]string `json:"models"`
+}
+
+// CatalogFile is a struct that represents a catalog repository kept in memory and saved to a JSON file on every write
+type CatalogFile struct {
+	// CatalogMap is the repository the catalogs are kept in
+	*CatalogMap
+	// mu is the lock of the writes, held until the file is saved so it is saved in the order of the writes
+	mu sync.Mutex
+	// path is the path to the file
+	path string
+}
+
+// Create is a method that adds a value to a catalog
+func (r *CatalogFile) Create(catalog string, value string) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err = r.CatalogMap.Create(catalog, value); err != nil {
+		return
+	}
+	err = r.save()
+	return
+}
+
+// Delete is a method that removes a value from a catalog, and the models of a brand
+func (r *CatalogFile) Delete(catalog string, value string) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err = r.CatalogMap.Delete(catalog, value); err != nil {
+		return
+	}
+	err = r.save()
+	return
+}
+
+// CreateModel is a method that adds a model to the catalog of a brand, registering the brand if needed
+func (r *CatalogFile) CreateModel(brand string, model string) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err = r.CatalogMap.CreateModel(brand, model); err != nil {
+		return
+	}
+	err = r.save()
+	return
+}
+
+// DeleteModel is a method that removes a model from the catalog of a brand
+func (r *CatalogFile) DeleteModel(brand string, model string) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err = r.CatalogMap.DeleteModel(brand, model); err != nil {
+		return
+	}
+	err = r.save()
+	return
+}
+
+// save is a method that writes the catalogs to the file, replacing it atomically
+func (r *CatalogFile) save() (err error) {
+	data, err := json.Marshal(r.CatalogMap.dump())
+	if err != nil {
+		return
+	}
+
+	err = writeFile(r.path, data)
+	return
+}
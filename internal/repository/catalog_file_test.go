@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"app/internal"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestCatalogFile_Concurrent(t *testing.T) {
+	rp, err := NewCatalogFile(NewCatalogMap(nil), filepath.Join(t.TempDir(), "catalogs.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// writes and reads at once must not race, run with -race
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			if err := rp.Create(internal.CatalogColors, fmt.Sprintf("color%d", i)); err != nil {
+				t.Error(err)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			if _, err := rp.FindAll(internal.CatalogColors); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	colors, _ := rp.FindAll(internal.CatalogColors)
+	if len(colors) != 20 {
+		t.Errorf("got %d colors, want 20", len(colors))
+	}
+}
+
+func TestCatalogFile_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "catalogs.json")
+	seed := map[int]internal.Vehicle{1: {Id: 1, VehicleAttributes: internal.VehicleAttributes{Brand: "Ford", Model: "Focus", Color: "Red"}}}
+	rp, err := NewCatalogFile(NewCatalogMap(seed), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = rp.Delete(internal.CatalogColors, "red"); err != nil {
+		t.Fatal(err)
+	}
+	if err = rp.CreateModel("Ford", "Fiesta"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the file wins over the seed, so the deleted color does not come back
+	rp, err = NewCatalogFile(NewCatalogMap(seed), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if colors, _ := rp.FindAll(internal.CatalogColors); len(colors) != 0 {
+		t.Errorf("got colors %v, want none", colors)
+	}
+	models, _ := rp.FindModels("ford")
+	if !slices.Equal(models, []string{"Fiesta", "Focus"}) {
+		t.Errorf("got models %v, want [Fiesta Focus]", models)
+	}
+}
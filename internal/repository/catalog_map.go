@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"app/internal"
+	"app/platform/tools"
+	"sort"
+	"sync"
+)
+
+// NewCatalogMap is a function that returns a new instance of CatalogMap, seeded with the values used by the vehicles
+func NewCatalogMap(vehicles map[int]internal.Vehicle) *CatalogMap {
+	r := &CatalogMap{
+		db:     make(map[string]map[string]string),
+		models: make(map[string]map[string]string),
+	}
+	for catalog := range internal.CatalogAttributes {
+		r.db[catalog] = make(map[string]string)
+	}
+	for _, value := range vehicles {
+		value = value.Normalize()
+		for catalog, attribute := range map[string]string{
+			internal.CatalogBrands:        value.Brand,
+			internal.CatalogColors:        value.Color,
+			internal.CatalogFuelTypes:     value.FuelType,
+			internal.CatalogTransmissions: value.Transmission,
+		} {
+			if attribute != "" {
+				r.db[catalog][tools.Fold(attribute)] = attribute
+			}
+		}
+		if value.Brand != "" && value.Model != "" {
+			r.createModel(value.Brand, value.Model)
+		}
+	}
+	return r
+}
+
+// CatalogMap is a struct that represents a catalog repository
+type CatalogMap struct {
+	// mu is the lock of the catalogs
+	mu sync.RWMutex
+	// db is the values of each catalog by catalog name and folded value
+	db map[string]map[string]string
+	// models is the models of each brand by folded brand and folded model
+	models map[string]map[string]string
+}
+
+// values is a method that returns the values of a catalog by folded value
+func (r *CatalogMap) values(catalog string) (values map[string]string, err error) {
+	values, ok := r.db[catalog]
+	if !ok {
+		err = internal.ErrCatalogNotFound
+	}
+	return
+}
+
+// FindAll is a method that returns the values of a catalog
+func (r *CatalogMap) FindAll(catalog string) (values []string, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	db, err := r.values(catalog)
+	if err != nil {
+		return
+	}
+	values = sorted(db)
+	return
+}
+
+// Exists is a method that reports whether a value is in a catalog
+func (r *CatalogMap) Exists(catalog string, value string) (ok bool, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	db, err := r.values(catalog)
+	if err != nil {
+		return
+	}
+	_, ok = db[key(catalog, value)]
+	return
+}
+
+// Create is a method that adds a value to a catalog
+func (r *CatalogMap) Create(catalog string, value string) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	db, err := r.values(catalog)
+	if err != nil {
+		return
+	}
+	k := key(catalog, value)
+	if _, ok := db[k]; ok || k == "" {
+		err = internal.ErrCatalogValueAlreadyExists
+		return
+	}
+	db[k] = internal.Canonical(internal.CatalogAttributes[catalog], value)
+	return
+}
+
+// Delete is a method that removes a value from a catalog, and the models of a brand
+func (r *CatalogMap) Delete(catalog string, value string) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	db, err := r.values(catalog)
+	if err != nil {
+		return
+	}
+	k := key(catalog, value)
+	if _, ok := db[k]; !ok {
+		err = internal.ErrCatalogValueNotFound
+		return
+	}
+	delete(db, k)
+	if catalog == internal.CatalogBrands {
+		delete(r.models, k)
+	}
+	return
+}
+
+// FindModels is a method that returns the models of a brand
+func (r *CatalogMap) FindModels(brand string) (models []string, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.db[internal.CatalogBrands][key(internal.CatalogBrands, brand)]; !ok {
+		err = internal.ErrCatalogValueNotFound
+		return
+	}
+	models = sorted(r.models[key(internal.CatalogBrands, brand)])
+	return
+}
+
+// ExistsModel is a method that reports whether a model is in the catalog of a brand
+func (r *CatalogMap) ExistsModel(brand string, model string) (ok bool, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok = r.models[key(internal.CatalogBrands, brand)][key("model", model)]
+	return
+}
+
+// CreateModel is a method that adds a model to the catalog of a brand, registering the brand if needed
+func (r *CatalogMap) CreateModel(brand string, model string) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err = r.createModel(brand, model)
+	return
+}
+
+// createModel is a method that adds a model to the catalog of a brand, with the lock held
+func (r *CatalogMap) createModel(brand string, model string) (err error) {
+	b, m := key(internal.CatalogBrands, brand), key("model", model)
+	if b == "" || m == "" {
+		err = internal.ErrFieldsMissing
+		return
+	}
+	if _, ok := r.models[b][m]; ok {
+		err = internal.ErrCatalogValueAlreadyExists
+		return
+	}
+	if _, ok := r.db[internal.CatalogBrands][b]; !ok {
+		r.db[internal.CatalogBrands][b] = internal.Canonical("brand", brand)
+	}
+	if r.models[b] == nil {
+		r.models[b] = make(map[string]string)
+	}
+	r.models[b][m] = internal.Canonical("model", model)
+	return
+}
+
+// DeleteModel is a method that removes a model from the catalog of a brand
+func (r *CatalogMap) DeleteModel(brand string, model string) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, m := key(internal.CatalogBrands, brand), key("model", model)
+	if _, ok := r.models[b][m]; !ok {
+		err = internal.ErrCatalogValueNotFound
+		return
+	}
+	delete(r.models[b], m)
+	return
+}
+
+// dump is a method that returns the catalogs in JSON format
+func (r *CatalogMap) dump() (c CatalogJSON) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c = CatalogJSON{Values: make(map[string][]string, len(r.db)), Models: make(map[string][]string, len(r.models))}
+	for catalog, values := range r.db {
+		c.Values[catalog] = sorted(values)
+	}
+	for brand, models := range r.models {
+		if len(models) > 0 {
+			c.Models[r.db[internal.CatalogBrands][brand]] = sorted(models)
+		}
+	}
+	return
+}
+
+// restore is a method that replaces the catalogs with the ones in JSON format
+func (r *CatalogMap) restore(c CatalogJSON) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.models = make(map[string]map[string]string)
+	for catalog := range internal.CatalogAttributes {
+		r.db[catalog] = make(map[string]string)
+		for _, value := range c.Values[catalog] {
+			r.db[catalog][key(catalog, value)] = value
+		}
+	}
+	for brand, models := range c.Models {
+		for _, model := range models {
+			r.createModel(brand, model)
+		}
+	}
+}
+
+// key is a function that returns the folded canonical form of a catalog value, used to compare values
+func key(catalog string, value string) string {
+	attribute, ok := internal.CatalogAttributes[catalog]
+	if !ok {
+		attribute = catalog
+	}
+	return tools.Fold(internal.Canonical(attribute, value))
+}
+
+// sorted is a function that returns the values of a map in order
+func sorted(m map[string]string) (values []string) {
+	values = make([]string, 0, len(m))
+	for _, value := range m {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	return
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// readLines is a function that calls fn with each line of an append-only file, none when it does not exist.
+// A last line torn by a crash mid-write, either without its newline or rejected by fn, is cut from the file
+// so the next appends start on a line of their own; a line rejected before it is an error
+func readLines(path string, fn func(line []byte) error) (err error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		err = nil
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	valid := 0
+	for valid < len(data) {
+		end := bytes.IndexByte(data[valid:], '\n')
+		if end < 0 {
+			break
+		}
+		if err = fn(data[valid : valid+end]); err != nil {
+			if valid+end+1 < len(data) {
+				return
+			}
+			err = nil
+			break
+		}
+		valid += end + 1
+	}
+
+	if valid < len(data) {
+		err = os.Truncate(path, int64(valid))
+	}
+	return
+}
+
+// writeFile is a function that replaces a file atomically, writing a temporary file next to it
+func writeFile(path string, data []byte) (err error) {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return
+	}
+	defer os.Remove(file.Name())
+	if _, err = file.Write(data); err != nil {
+		file.Close()
+		return
+	}
+	if err = file.Sync(); err != nil {
+		file.Close()
+		return
+	}
+	if err = file.Close(); err != nil {
+		return
+	}
+	err = os.Rename(file.Name(), path)
+	return
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"app/internal"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewAuditFile_TornLastLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	complete := `{"vehicle_id":1,"operation":"create"}` + "\n"
+	if err := os.WriteFile(path, []byte(complete+`{"vehicle_id":2,"oper`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rp, err := NewAuditFile(path)
+	if err != nil {
+		t.Fatalf("torn last line: %v", err)
+	}
+	if len(rp.entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(rp.entries))
+	}
+	// the torn line is cut so the next entry starts on a line of its own
+	if err = rp.Append(internal.AuditEntry{VehicleID: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if rp, err = NewAuditFile(path); err != nil || len(rp.entries) != 2 {
+		t.Fatalf("got %d entries and %v, want 2", len(rp.entries), err)
+	}
+}
+
+func TestNewIdempotencyFile_TornLastLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "idempotency.log")
+	// a torn line may end with its newline when the crash cut the JSON but not the file
+	if err := os.WriteFile(path, []byte("{\"Key\":\"a\",\"Do\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewIdempotencyFile(path); err != nil {
+		t.Fatalf("torn last line: %v", err)
+	}
+	if data, _ := os.ReadFile(path); len(data) != 0 {
+		t.Errorf("got file %q, want it empty", data)
+	}
+}
+
+func TestReadLines_Corrupted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	if err := os.WriteFile(path, []byte("not json\n{}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// a bad line before the last one is not a torn write
+	if _, err := NewAuditFile(path); err == nil {
+		t.Fatal("got no error for a corrupted line")
+	}
+}
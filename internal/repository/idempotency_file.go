@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"app/internal"
+	"bytes"
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// NewIdempotencyFile is a function that returns a new instance of IdempotencyFile, loading the unexpired records already in the file
+func NewIdempotencyFile(path string) (r *IdempotencyFile, err error) {
+	r = &IdempotencyFile{IdempotencyMap: NewIdempotencyMap(), path: path}
+
+	// read previous records, if any, the last one of a key wins
+	now := time.Now()
+	err = readLines(path, func(line []byte) (err error) {
+		var rc internal.IdempotencyRecord
+		if err = json.Unmarshal(line, &rc); err != nil {
+			return
+		}
+		if rc.Done && now.Before(rc.ExpiresAt) {
+			r.db[rc.Key] = rc
+		}
+		return
+	})
+	return
+}
+
+// IdempotencyFile is a struct that represents an idempotency repository kept in memory, whose stored responses
+// are appended to a file, one JSON record per line, and survive restarts. The records in progress are not kept
+type IdempotencyFile struct {
+	*IdempotencyMap
+	// path is the path to the file
+	path string
+}
+
+// Complete is a method that stores the response of a record in progress, appending it to the file.
+// The response is kept in memory even when the file fails, so the retries are replayed rather than left in progress
+func (r *IdempotencyFile) Complete(rc internal.IdempotencyRecord) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rc.Done = true
+	r.db[rc.Key] = rc
+	line, err := json.Marshal(rc)
+	if err != nil {
+		return
+	}
+	file, err := os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	if _, err = file.Write(append(line, '\n')); err != nil {
+		return
+	}
+	err = file.Sync()
+	return
+}
+
+// PurgeExpired is a method that removes the records expired at a moment, rewriting the file with the others
+func (r *IdempotencyFile) PurgeExpired(now time.Time) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.purgeExpired(now)
+
+	var data bytes.Buffer
+	enc := json.NewEncoder(&data)
+	for _, rc := range r.db {
+		if !rc.Done {
+			continue
+		}
+		if err = enc.Encode(rc); err != nil {
+			return
+		}
+	}
+	err = writeFile(r.path, data.Bytes())
+	return
+}
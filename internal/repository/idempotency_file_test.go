@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"app/internal"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyFile_CompleteFails(t *testing.T) {
+	// a file that cannot be written
+	r, err := NewIdempotencyFile(filepath.Join(t.TempDir(), "missing", "idempotency.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	rc := internal.IdempotencyRecord{Key: "k1", Fingerprint: "f", ExpiresAt: now.Add(time.Hour)}
+	if _, _, err = r.Reserve(rc, now); err != nil {
+		t.Fatal(err)
+	}
+	rc.StatusCode = 201
+	if err = r.Complete(rc); err == nil {
+		t.Fatal("got no error")
+	}
+
+	// the retries are replayed, not left in progress
+	existing, found, err := r.Reserve(rc, now)
+	if err != nil || !found || !existing.Done || existing.StatusCode != 201 {
+		t.Errorf("got %+v %v %v, want the record done", existing, found, err)
+	}
+}
+
+func TestIdempotencyFile_Restart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "idempotency.log")
+	r, err := NewIdempotencyFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	for key, expires := range map[string]time.Time{"kept": now.Add(time.Hour), "expired": now.Add(time.Millisecond)} {
+		if err = r.Complete(internal.IdempotencyRecord{Key: key, ExpiresAt: expires, StatusCode: 201}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = r.PurgeExpired(now.Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the responses done survive the restart, the expired ones do not
+	if r, err = NewIdempotencyFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, found, _ := r.Reserve(internal.IdempotencyRecord{Key: "kept", ExpiresAt: now.Add(time.Hour)}, now); !found {
+		t.Error("got kept not found")
+	}
+	if _, found, _ := r.Reserve(internal.IdempotencyRecord{Key: "expired", ExpiresAt: now.Add(time.Hour)}, now); found {
+		t.Error("got expired found")
+	}
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"app/internal"
+	"sync"
+	"time"
+)
+
+// NewIdempotencyMap is a function that returns a new instance of IdempotencyMap
+func NewIdempotencyMap() *IdempotencyMap {
+	return &IdempotencyMap{db: make(map[string]internal.IdempotencyRecord)}
+}
+
+// IdempotencyMap is a struct that represents an idempotency repository kept in memory
+type IdempotencyMap struct {
+	// mu is the lock of the records
+	mu sync.Mutex
+	// db is the record of each key
+	db map[string]internal.IdempotencyRecord
+}
+
+// Reserve is a method that adds a record in progress, unless an unexpired one exists with the key, which is returned
+func (r *IdempotencyMap) Reserve(rc internal.IdempotencyRecord, now time.Time) (existing internal.IdempotencyRecord, found bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, found = r.db[rc.Key]
+	if found && now.Before(existing.ExpiresAt) {
+		return
+	}
+	existing, found = internal.IdempotencyRecord{}, false
+	rc.Done = false
+	r.db[rc.Key] = rc
+	return
+}
+
+// Complete is a method that stores the response of a record in progress
+func (r *IdempotencyMap) Complete(rc internal.IdempotencyRecord) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rc.Done = true
+	r.db[rc.Key] = rc
+	return
+}
+
+// Release is a method that removes a record in progress, so the key can be retried
+func (r *IdempotencyMap) Release(key string) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if rc, ok := r.db[key]; ok && !rc.Done {
+		delete(r.db, key)
+	}
+	return
+}
+
+// PurgeExpired is a method that removes the records expired at a moment
+func (r *IdempotencyMap) PurgeExpired(now time.Time) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.purgeExpired(now)
+	return
+}
+
+// purgeExpired is a method that removes the records expired at a moment, the lock is held by the caller
+func (r *IdempotencyMap) purgeExpired(now time.Time) {
+	for key, rc := range r.db {
+		if !now.Before(rc.ExpiresAt) {
+			delete(r.db, key)
+		}
+	}
+}
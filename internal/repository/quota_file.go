@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+)
+
+// NewQuotaFile is a function that returns a new instance of QuotaFile, loading the counters already in the file
+func NewQuotaFile(path string) (r *QuotaFile, err error) {
+	r = &QuotaFile{path: path, counts: make(map[string]int)}
+
+	// read previous counters, if any
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		err = nil
+		return
+	}
+	if err != nil {
+		return
+	}
+	var quotaJSON QuotaJSON
+	if err = json.Unmarshal(data, &quotaJSON); err != nil {
+		return
+	}
+	r.day = quotaJSON.Day
+	for key, count := range quotaJSON.Counts {
+		r.counts[key] = count
+	}
+	return
+}
+
+// QuotaJSON is a struct that represents the counters of a day in JSON format
+type QuotaJSON struct {
+	Day    string         `json:"day"`
+	Counts map[string]int `json:"counts"`
+}
+
+// QuotaFile is a struct that represents a quota repository kept in memory and saved to a JSON file on Sync
+type QuotaFile struct {
+	// mu is the lock of the counters
+	mu sync.Mutex
+	// path is the path to the file
+	path string
+	// day is the day of the counters
+	day string
+	// counts is the counter of each key
+	counts map[string]int
+	// dirty is whether the counters changed since the last sync
+	dirty bool
+}
+
+// Increment is a method that adds one to the counter of a key on a day and returns it,
+// the counters of the previous days are dropped
+func (r *QuotaFile) Increment(key string, day string) (count int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if day != r.day {
+		r.day = day
+		r.counts = make(map[string]int)
+	}
+	r.counts[key]++
+	r.dirty = true
+	count = r.counts[key]
+	return
+}
+
+// Sync is a method that saves the counters to the file, if they changed, replacing it atomically
+func (r *QuotaFile) Sync() (err error) {
+	r.mu.Lock()
+	if !r.dirty {
+		r.mu.Unlock()
+		return
+	}
+	data, err := json.Marshal(QuotaJSON{Day: r.day, Counts: r.counts})
+	r.dirty = false
+	r.mu.Unlock()
+	if err != nil {
+		return
+	}
+	defer func() {
+		// - the counters are saved again on the next sync
+		if err != nil {
+			r.mu.Lock()
+			r.dirty = true
+			r.mu.Unlock()
+		}
+	}()
+
+	// replace the file
+	err = writeFile(r.path, data)
+	return
+}
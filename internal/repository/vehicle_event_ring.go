@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"app/internal"
+	"sync"
+)
+
+// NewVehicleEventRing is a function that returns a new instance of VehicleEventRing keeping the last size events,
+// whose subscribers fall behind once buffer events they want are queued and not received
+func NewVehicleEventRing(size int, buffer int) *VehicleEventRing {
+	return &VehicleEventRing{
+		events:      make([]internal.VehicleEvent, 0, size),
+		size:        size,
+		buffer:      buffer,
+		subscribers: make(map[int]subscriber),
+	}
+}
+
+// subscriber is a struct that represents a subscription to the ring
+type subscriber struct {
+	// ch is the channel the events are queued to
+	ch chan internal.VehicleEvent
+	// match is the function that reports whether the subscriber wants an event, nil for every event
+	match func(e internal.VehicleEvent) bool
+}
+
+// VehicleEventRing is a struct that represents a vehicle event repository kept in memory, as a ring of the last events
+type VehicleEventRing struct {
+	// mu is the lock that makes the ring safe for concurrent use
+	mu sync.Mutex
+	// events is the list of the last events, oldest first
+	events []internal.VehicleEvent
+	// size is the number of events kept
+	size int
+	// buffer is the number of events queued to each subscriber
+	buffer int
+	// last is the id of the last event published
+	last int64
+	// subscribers is each subscriber, by subscription
+	subscribers map[int]subscriber
+	// next is the key of the next subscription
+	next int
+}
+
+// Publish is a method that adds an event to the feed, assigning its id
+func (r *VehicleEventRing) Publish(e internal.VehicleEvent) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.last++
+	e.ID = r.last
+	if len(r.events) == r.size {
+		r.events = r.events[1:]
+	}
+	r.events = append(r.events, e)
+
+	// notify the subscribers that want the event, dropping those that fell behind so they resume from the ring
+	for key, sub := range r.subscribers {
+		if sub.match != nil && !sub.match(e) {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+			close(sub.ch)
+			delete(r.subscribers, key)
+		}
+	}
+	return
+}
+
+// Subscribe is a method that returns the events of the feed after an id (0 for none) and a channel
+// with the events published from then on, closed when the subscriber falls behind or cancel is called.
+// Only the events match reports are returned and queued, every one when it is nil
+func (r *VehicleEventRing) Subscribe(after int64, match func(e internal.VehicleEvent) bool) (backlog []internal.VehicleEvent, events <-chan internal.VehicleEvent, cancel func(), err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// backlog, the ids after the last one are of a feed before a restart
+	if after > r.last {
+		err = internal.ErrVehicleEventsExpired
+	} else if after > 0 && after < r.last {
+		if r.events[0].ID > after+1 {
+			err = internal.ErrVehicleEventsExpired
+		}
+		for _, e := range r.events {
+			if e.ID > after && (match == nil || match(e)) {
+				backlog = append(backlog, e)
+			}
+		}
+	}
+
+	// subscription
+	key := r.next
+	r.next++
+	ch := make(chan internal.VehicleEvent, r.buffer)
+	r.subscribers[key] = subscriber{ch: ch, match: match}
+	events = ch
+	cancel = func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if _, ok := r.subscribers[key]; ok {
+			close(ch)
+			delete(r.subscribers, key)
+		}
+	}
+	return
+}
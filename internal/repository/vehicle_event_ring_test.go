@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"app/internal"
+	"testing"
+)
+
+func TestVehicleEventRing_Subscribe(t *testing.T) {
+	rp := NewVehicleEventRing(16, 2)
+	fords := func(e internal.VehicleEvent) bool { return e.After != nil && e.After.Brand == "Ford" }
+	_, events, cancel, err := rp.Subscribe(0, fords)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+
+	// the events filtered out are not queued, so they do not fill the buffer of the subscriber
+	for i := 1; i <= 10; i++ {
+		v := internal.Vehicle{Id: i, VehicleAttributes: internal.VehicleAttributes{Brand: "Fiat"}}
+		if err = rp.Publish(internal.VehicleEvent{Type: internal.AuditCreate, VehicleID: i, After: &v}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	v := internal.Vehicle{Id: 11, VehicleAttributes: internal.VehicleAttributes{Brand: "Ford"}}
+	if err = rp.Publish(internal.VehicleEvent{Type: internal.AuditCreate, VehicleID: 11, After: &v}); err != nil {
+		t.Fatal(err)
+	}
+	e, ok := <-events
+	if !ok || e.VehicleID != 11 {
+		t.Fatalf("got %v and %t, want the event of vehicle 11", e, ok)
+	}
+
+	// the backlog is filtered too
+	backlog, _, cancelBacklog, err := rp.Subscribe(1, fords)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancelBacklog()
+	if len(backlog) != 1 || backlog[0].VehicleID != 11 {
+		t.Errorf("got %v, want the event of vehicle 11", backlog)
+	}
+}
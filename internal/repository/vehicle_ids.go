@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"app/internal"
+	"app/platform/tools"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// strategies of the ids of the vehicles
+const (
+	// VehicleIDSequence is the strategy that allocates increasing integer ids only
+	VehicleIDSequence = "sequence"
+	// VehicleIDUUIDv7 is the strategy that also allocates a UUIDv7 string id
+	VehicleIDUUIDv7 = "uuidv7"
+	// VehicleIDULID is the strategy that also allocates a ULID string id
+	VehicleIDULID = "ulid"
+)
+
+// NewVehicleIDs is a function that returns a new instance of VehicleIDs, moving the sequence past the ids of rp.
+// With clientIDs the vehicles created with an id keep it, otherwise they are rejected with ErrVehicleIDAssigned
+func NewVehicleIDs(rp internal.VehicleRepository, seq internal.VehicleSequence, strategy string, clientIDs bool) (r *VehicleIDs, err error) {
+	switch strategy {
+	case VehicleIDSequence, VehicleIDUUIDv7, VehicleIDULID:
+	default:
+		err = fmt.Errorf("unknown vehicle id strategy %s", strategy)
+		return
+	}
+	r = &VehicleIDs{VehicleRepository: rp, seq: seq, strategy: strategy, clientIDs: clientIDs}
+
+	// the ids in use, in the trash too, are never allocated
+	v, err := rp.FindAll()
+	if err != nil {
+		return
+	}
+	trash, _ := rp.FindTrash()
+	last := 0
+	for id := range v {
+		last = max(last, id)
+	}
+	for id := range trash {
+		last = max(last, id)
+	}
+	err = seq.Advance(last)
+	return
+}
+
+// VehicleIDs is a struct that represents a vehicle repository that allocates the ids of the vehicles created without one
+type VehicleIDs struct {
+	internal.VehicleRepository
+	// mu is the lock that makes the allocation of an id and the create that takes it atomic
+	mu sync.Mutex
+	// seq is the sequence of the integer ids
+	seq internal.VehicleSequence
+	// strategy is the strategy of the ids
+	strategy string
+	// clientIDs is whether the vehicles may be created with an id of their own
+	clientIDs bool
+}
+
+// allocate is a method that sets the ids of a vehicle to create, skipping the integer ids in taken,
+// the lock is held by the caller
+func (r *VehicleIDs) allocate(v internal.Vehicle, taken map[int]bool) (allocated internal.Vehicle, err error) {
+	switch {
+	case v.Id != 0 && !r.clientIDs:
+		err = internal.ErrVehicleIDAssigned
+		return
+	case v.Id == 0:
+		for v.Id == 0 || taken[v.Id] {
+			if v.Id, err = r.seq.Next(); err != nil {
+				return
+			}
+		}
+	}
+
+	switch r.strategy {
+	case VehicleIDUUIDv7:
+		v.UID, err = tools.NewUUIDv7(time.Now())
+	case VehicleIDULID:
+		v.UID, err = tools.NewULID(time.Now())
+	default:
+		v.UID = ""
+	}
+	allocated = v
+	return
+}
+
+// Create is a method that creates a vehicle, allocating its id when 0, and returns it as stored
+func (r *VehicleIDs) Create(v internal.Vehicle) (created internal.Vehicle, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := v.Id
+	if v, err = r.allocate(v, nil); err != nil {
+		return
+	}
+	if created, err = r.VehicleRepository.Create(v); err != nil {
+		return
+	}
+	// - the sequence moves past an id of the client once it is taken, a failed create leaves it free,
+	//   and the vehicle is purged when it cannot move so the id is never allocated again
+	if id != 0 {
+		if err = r.seq.Advance(id); err != nil {
+			err = errors.Join(err, r.VehicleRepository.Purge(created.Id, created.Version))
+			created = internal.Vehicle{}
+		}
+	}
+	return
+}
+
+// CreateMultiple is a method that creates multiple vehicles, allocating the ids that are 0, and returns them as stored
+func (r *VehicleIDs) CreateMultiple(v []internal.Vehicle) (created []internal.Vehicle, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// - the ids of the client are not allocated to the other vehicles of the batch
+	taken := make(map[int]bool)
+	last := 0
+	for _, vehicle := range v {
+		if vehicle.Id != 0 {
+			if taken[vehicle.Id] {
+				err = internal.ErrVehicleAlreadyExists
+				return
+			}
+			taken[vehicle.Id] = true
+			last = max(last, vehicle.Id)
+		}
+	}
+	allocated := make([]internal.Vehicle, len(v))
+	for i, vehicle := range v {
+		if allocated[i], err = r.allocate(vehicle, taken); err != nil {
+			return
+		}
+	}
+	if created, err = r.VehicleRepository.CreateMultiple(allocated); err != nil {
+		return
+	}
+	// - the vehicles are purged when the sequence cannot move past the ids of the client
+	if last != 0 {
+		if err = r.seq.Advance(last); err != nil {
+			for _, vehicle := range created {
+				err = errors.Join(err, r.VehicleRepository.Purge(vehicle.Id, vehicle.Version))
+			}
+			created = nil
+		}
+	}
+	return
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"app/internal"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// newTestIDs is a function that returns a repository with client ids on top of vehicle 1, with the sequence in a file
+func newTestIDs(t *testing.T) *VehicleIDs {
+	t.Helper()
+	seq, err := NewVehicleSequenceFile(filepath.Join(t.TempDir(), "vehicles.seq"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rp := NewVehicleMap(map[int]internal.Vehicle{1: {Id: 1, VehicleAttributes: internal.VehicleAttributes{Brand: "Ford"}}})
+	r, err := NewVehicleIDs(rp, seq, VehicleIDSequence, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestVehicleIDs_FailedCreateKeepsSequence(t *testing.T) {
+	r := newTestIDs(t)
+
+	// a client id that is rejected does not move the sequence
+	if _, err := r.Create(internal.Vehicle{Id: 1}); !errors.Is(err, internal.ErrVehicleAlreadyExists) {
+		t.Fatalf("got %v, want %v", err, internal.ErrVehicleAlreadyExists)
+	}
+	if _, err := r.CreateMultiple([]internal.Vehicle{{Id: 50}, {Id: 1}}); !errors.Is(err, internal.ErrVehicleAlreadyExists) {
+		t.Fatalf("got %v, want %v", err, internal.ErrVehicleAlreadyExists)
+	}
+	created, err := r.Create(internal.Vehicle{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.Id != 2 {
+		t.Errorf("got id %d, want 2", created.Id)
+	}
+
+	// one that is taken does
+	if _, err := r.Create(internal.Vehicle{Id: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if created, err = r.Create(internal.Vehicle{}); err != nil {
+		t.Fatal(err)
+	}
+	if created.Id != 11 {
+		t.Errorf("got id %d, want 11", created.Id)
+	}
+}
+
+func TestVehicleIDs_BatchSkipsClientIDs(t *testing.T) {
+	r := newTestIDs(t)
+
+	// the id allocated to the first vehicle would be the one of the second
+	created, err := r.CreateMultiple([]internal.Vehicle{{}, {Id: 2}, {}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []int{created[0].Id, created[1].Id, created[2].Id}
+	if ids[0] != 3 || ids[1] != 2 || ids[2] != 4 {
+		t.Errorf("got ids %v, want [3 2 4]", ids)
+	}
+}
+
+// failingSequence is a sequence that allocates ids but cannot move past the ones of the clients
+type failingSequence struct {
+	last int
+}
+
+func (s *failingSequence) Next() (id int, err error) {
+	s.last++
+	return s.last, nil
+}
+
+func (s *failingSequence) Advance(id int) (err error) {
+	if id <= s.last {
+		return
+	}
+	return errors.New("sequence not saved")
+}
+
+func TestVehicleIDs_FailedAdvanceUndoesCreate(t *testing.T) {
+	rp := NewVehicleMap(map[int]internal.Vehicle{1: {Id: 1}})
+	r, err := NewVehicleIDs(rp, &failingSequence{last: 1}, VehicleIDSequence, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = r.Create(internal.Vehicle{Id: 10}); err == nil {
+		t.Fatal("got no error")
+	}
+	if _, err = r.CreateMultiple([]internal.Vehicle{{}, {Id: 20}}); err == nil {
+		t.Fatal("got no error")
+	}
+	v, err := rp.FindAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := v[1]; len(v) != 1 || !ok {
+		t.Errorf("got %d vehicles, want vehicle 1 only", len(v))
+	}
+	trash, _ := rp.FindTrash()
+	if len(trash) != 0 {
+		t.Errorf("got %d vehicles in the trash, want 0", len(trash))
+	}
+}
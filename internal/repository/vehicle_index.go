@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"app/internal"
+	"app/platform/tools"
+)
+
+// indexedFields is the weight of each searchable field of a vehicle
+var indexedFields = map[string]func(v internal.Vehicle) (text string, weight float64){
+	"model":        func(v internal.Vehicle) (string, float64) { return v.Model, 3 },
+	"brand":        func(v internal.Vehicle) (string, float64) { return v.Brand, 2 },
+	"registration": func(v internal.Vehicle) (string, float64) { return v.Registration, 2 },
+	"color":        func(v internal.Vehicle) (string, float64) { return v.Color, 1 },
+}
+
+// spelledFields is the value of each field of a vehicle whose spelling is shared by the vehicles stored
+var spelledFields = map[string]func(v internal.Vehicle) string{
+	"brand": func(v internal.Vehicle) string { return v.Brand },
+	"model": func(v internal.Vehicle) string { return v.Model },
+}
+
+// newVehicleIndex is a function that returns an inverted index over the vehicles of db
+func newVehicleIndex(db map[int]internal.Vehicle) *vehicleIndex {
+	ix := &vehicleIndex{
+		postings:  make(map[string]map[int]float64),
+		terms:     make(map[int][]string),
+		spellings: make(map[string]map[string]map[string]int),
+		spelled:   make(map[int]map[string]string),
+	}
+	for _, value := range db {
+		ix.add(value)
+	}
+	return ix
+}
+
+// vehicleIndex is a struct that represents an inverted index from folded words to vehicles,
+// and from the folded canonical brands and models to the spellings they are stored with
+type vehicleIndex struct {
+	// postings is the weight of each term for each vehicle id, the highest of the fields it appears in
+	postings map[string]map[int]float64
+	// terms is the list of terms of each vehicle id, used to remove it
+	terms map[int][]string
+	// spellings is the number of vehicles stored with each spelling, by field and folded canonical value
+	spellings map[string]map[string]map[string]int
+	// spelled is the spelling of each field of each vehicle id, used to remove it
+	spelled map[int]map[string]string
+}
+
+// add is a method that indexes a vehicle, replacing any previous version of it
+func (ix *vehicleIndex) add(v internal.Vehicle) {
+	ix.remove(v.Id)
+	ix.spelled[v.Id] = make(map[string]string, len(spelledFields))
+	for field, value := range spelledFields {
+		spelling := value(v)
+		key := tools.Fold(internal.Canonical(field, spelling))
+		if ix.spellings[field] == nil {
+			ix.spellings[field] = make(map[string]map[string]int)
+		}
+		if ix.spellings[field][key] == nil {
+			ix.spellings[field][key] = make(map[string]int)
+		}
+		ix.spellings[field][key][spelling]++
+		ix.spelled[v.Id][field] = spelling
+	}
+	for _, field := range indexedFields {
+		text, weight := field(v)
+		for _, term := range tools.Tokenize(text) {
+			ids, ok := ix.postings[term]
+			if !ok {
+				ids = make(map[int]float64)
+				ix.postings[term] = ids
+			}
+			if _, ok := ids[v.Id]; !ok {
+				ix.terms[v.Id] = append(ix.terms[v.Id], term)
+			}
+			ids[v.Id] = max(ids[v.Id], weight)
+		}
+	}
+}
+
+// remove is a method that removes a vehicle from the index
+func (ix *vehicleIndex) remove(id int) {
+	for _, term := range ix.terms[id] {
+		delete(ix.postings[term], id)
+		if len(ix.postings[term]) == 0 {
+			delete(ix.postings, term)
+		}
+	}
+	delete(ix.terms, id)
+	for field, spelling := range ix.spelled[id] {
+		key := tools.Fold(internal.Canonical(field, spelling))
+		spellings := ix.spellings[field][key]
+		if spellings[spelling]--; spellings[spelling] == 0 {
+			delete(spellings, spelling)
+		}
+		if len(spellings) == 0 {
+			delete(ix.spellings[field], key)
+		}
+	}
+	delete(ix.spelled, id)
+}
+
+// spelling is a method that returns the spelling a field of the vehicles stored has for a value, which only differs
+// from it in case, accents or spaces, or the value itself when it is already stored or no vehicle has it
+func (ix *vehicleIndex) spelling(field string, value string) string {
+	spellings := ix.spellings[field][tools.Fold(internal.Canonical(field, value))]
+	if len(spellings) == 0 || spellings[value] > 0 {
+		return value
+	}
+	// the lowest of the spellings stored, so the choice does not depend on the order of the map
+	first := ""
+	for spelling := range spellings {
+		if first == "" || spelling < first {
+			first = spelling
+		}
+	}
+	return first
+}
+
+// search is a method that returns the score of the vehicles matching every word of the query
+func (ix *vehicleIndex) search(query string) (scores map[int]float64) {
+	words := tools.Tokenize(query)
+	if len(words) == 0 {
+		return
+	}
+
+	for i, word := range words {
+		// best score of the word for each vehicle
+		wordScores := make(map[int]float64)
+		for term, ids := range ix.postings {
+			sim := similarity(word, term)
+			if sim == 0 {
+				continue
+			}
+			for id, weight := range ids {
+				wordScores[id] = max(wordScores[id], sim*weight)
+			}
+		}
+
+		// every word has to match
+		if i == 0 {
+			scores = wordScores
+			continue
+		}
+		for id := range scores {
+			if s, ok := wordScores[id]; ok {
+				scores[id] += s
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+	return
+}
+
+// similarity is a function that returns how well a term matches a word of a query, 0 when it does not
+func similarity(word string, term string) float64 {
+	if word == term {
+		return 1
+	}
+	// prefixes of at least two letters, e.g. "yuk" for "yukon"
+	if len(word) >= 2 && len(term) > len(word) && term[:len(word)] == word {
+		return 0.8
+	}
+	// typos: one edit for short words, two for long ones
+	allowed := 0
+	switch {
+	case len(word) >= 8:
+		allowed = 2
+	case len(word) >= 4:
+		allowed = 1
+	}
+	if allowed == 0 || abs(len(word)-len(term)) > allowed {
+		return 0
+	}
+	if d := tools.EditDistance(word, term); d <= allowed {
+		return 0.6 / float64(d)
+	}
+	return 0
+}
+
+// abs is a function that returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
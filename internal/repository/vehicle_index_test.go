@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"app/internal"
+	"testing"
+)
+
+func TestVehicleMap_CanonicalSpelling(t *testing.T) {
+	vehicle := func(id int, brand string, model string) internal.Vehicle {
+		return internal.Vehicle{Id: id, VehicleAttributes: internal.VehicleAttributes{Brand: brand, Model: model}}
+	}
+	rp := NewVehicleMap(map[int]internal.Vehicle{
+		1: vehicle(1, "Ford", "Focus"),
+		2: vehicle(2, "Land Rover", "Range Rover"),
+		3: vehicle(3, "Citroën", "C3"),
+	})
+
+	cases := []struct {
+		name  string
+		brand string
+		model string
+		want  [2]string
+	}{
+		{name: "case", brand: "FORD", model: "focus", want: [2]string{"Ford", "Focus"}},
+		{name: "spaces", brand: "  land   rover ", model: "range\trover", want: [2]string{"Land Rover", "Range Rover"}},
+		{name: "accents", brand: "CITROEN", model: "c3", want: [2]string{"Citroën", "C3"}},
+		{name: "new value", brand: " Kia ", model: "Ceed  SW", want: [2]string{"Kia", "Ceed SW"}},
+	}
+	for i, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			v, err := rp.Create(vehicle(10+i, c.brand, c.model))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := [2]string{v.Brand, v.Model}; got != c.want {
+				t.Errorf("got %q, want %q", got, c.want)
+			}
+		})
+	}
+
+	// the spelling of the vehicles created is reused as well
+	if v, _ := rp.Create(vehicle(20, "KIA", "ceed sw")); v.Brand != "Kia" || v.Model != "Ceed SW" {
+		t.Errorf("got %q %q, want the spelling of the vehicle created before", v.Brand, v.Model)
+	}
+}
+
+func TestVehicleMap_CanonicalSpellingAfterWrites(t *testing.T) {
+	rp := NewVehicleMap(map[int]internal.Vehicle{1: {Id: 1, VehicleAttributes: internal.VehicleAttributes{Brand: "Ford", Model: "Focus"}}})
+
+	// a vehicle replaced keeps the spelling while it is the only one with it
+	v, _ := rp.FindByID(1)
+	v.Brand = "FORD"
+	if v, _ = rp.Replace(v, 0); v.Brand != "Ford" {
+		t.Errorf("got %q, want Ford", v.Brand)
+	}
+
+	// once no vehicle has a spelling, the next one sets it
+	if err := rp.Delete(1, 0); err != nil {
+		t.Fatal(err)
+	}
+	v, err := rp.Create(internal.Vehicle{Id: 2, VehicleAttributes: internal.VehicleAttributes{Brand: "FORD", Model: "FOCUS"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Brand != "FORD" || v.Model != "FOCUS" {
+		t.Errorf("got %q %q, want the spelling of the vehicle created", v.Brand, v.Model)
+	}
+
+	// and the vehicle restored keeps its own
+	if v, _ = rp.Restore(1); v.Brand != "Ford" {
+		t.Errorf("got %q, want the vehicle restored as it was", v.Brand)
+	}
+	if v, _ = rp.Create(internal.Vehicle{Id: 3, VehicleAttributes: internal.VehicleAttributes{Brand: "ford"}}); v.Brand != "FORD" {
+		t.Errorf("got %q, want the lowest of the spellings stored", v.Brand)
+	}
+}
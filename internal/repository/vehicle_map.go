@@ -2,7 +2,9 @@ package repository
 
 import (
 	"app/internal"
-	"fmt"
+	"errors"
+	"sync"
+	"time"
 )
 
 // NewVehicleMap is a function that returns a new instance of VehicleMap
@@ -12,17 +14,57 @@ func NewVehicleMap(db map[int]internal.Vehicle) *VehicleMap {
 	if db != nil {
 		defaultDb = db
 	}
-	return &VehicleMap{db: defaultDb}
+	// store categorical attributes in canonical form, starting at version 1
+	for key, value := range defaultDb {
+		value = value.Normalize()
+		if value.Version == 0 {
+			value.Version = 1
+		}
+		defaultDb[key] = value
+	}
+	// the history starts now, with the vehicles loaded
+	since := time.Now().UTC()
+	history := make(map[int][]revision)
+	for key, value := range defaultDb {
+		vehicle := value
+		history[key] = []revision{{at: since, vehicle: &vehicle}}
+	}
+	return &VehicleMap{db: defaultDb, trash: make(map[int]internal.TrashedVehicle), index: newVehicleIndex(defaultDb), history: history, since: since}
 }
 
 // VehicleMap is a struct that represents a vehicle repository
 type VehicleMap struct {
+	// mu is the lock that makes the repository safe for concurrent use and its writes atomic
+	mu sync.RWMutex
 	// db is a map of vehicles
 	db map[int]internal.Vehicle
+	// trash is a map of the vehicles deleted and not purged yet, hidden from every query
+	trash map[int]internal.TrashedVehicle
+	// summary is the cached summary of db, nil until requested and after every write
+	summary *internal.FleetSummary
+	// index is the inverted index used to search db by text
+	index *vehicleIndex
+	// history is the list of revisions of each vehicle, oldest first, used to answer queries on the past
+	history map[int][]revision
+	// since is the moment the history starts, the queries on the past before it are rejected
+	since time.Time
+	// writeAt is the moment the writes are made at, zero for now; set by a log replaying the writes at their moments
+	writeAt time.Time
+	// snapshots is the list of snapshots saved, oldest first
+	snapshots []internal.Snapshot
 }
 
 // FindAll is a method that returns a map of all vehicles
 func (r *VehicleMap) FindAll() (v map[int]internal.Vehicle, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	v, err = r.findAll()
+	return
+}
+
+// findAll is a method that returns a map of all vehicles, the caller has to hold the lock
+func (r *VehicleMap) findAll() (v map[int]internal.Vehicle, err error) {
 	v = make(map[int]internal.Vehicle)
 
 	// copy db
@@ -33,27 +75,52 @@ func (r *VehicleMap) FindAll() (v map[int]internal.Vehicle, err error) {
 	return
 }
 
-// Create is a method that creates a vehicle
-func (r *VehicleMap) Create(v internal.Vehicle) (err error) {
-	// validate vehicle ID
+// Create is a method that creates a vehicle with its id, and returns it as stored
+func (r *VehicleMap) Create(v internal.Vehicle) (created internal.Vehicle, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// validate vehicle ID, the ids of the trash are still in use
 	for _, value := range r.db {
 		if value.Id == v.Id {
 			err = internal.ErrVehicleAlreadyExists
 			return
 		}
 	}
+	if _, ok := r.trash[v.Id]; ok {
+		err = internal.ErrVehicleAlreadyExists
+		return
+	}
 	// add vehicle to db
+	v = r.canonical(v)
+	v.Version = 1
 	r.db[v.Id] = v
+	r.index.add(v)
+	r.remember(v.Id)
+	r.summary = nil
+	created = v
 	return
 }
 
+// canonical is a method that returns the vehicle in canonical form, reusing the spelling of
+// the brand and model already stored when they only differ in case or accents
+func (r *VehicleMap) canonical(v internal.Vehicle) internal.Vehicle {
+	v = v.Normalize()
+	v.Brand = r.index.spelling("brand", v.Brand)
+	v.Model = r.index.spelling("model", v.Model)
+	return v
+}
+
 // GetByColorAndYear is a method that returns a map of vehicles by color and year
 func (r *VehicleMap) GetByColorAndYear(color string, year int) (v map[int]internal.Vehicle, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	v = make(map[int]internal.Vehicle)
 
 	// copy db
 	for index, value := range r.db {
-		if value.Color == color && value.FabricationYear == year {
+		if internal.SameValue("color", value.Color, color) && value.FabricationYear == year {
 			v[index] = value
 		}
 	}
@@ -67,11 +134,14 @@ func (r *VehicleMap) GetByColorAndYear(color string, year int) (v map[int]intern
 
 // GetByBrandAndYearRange is a method that returns a map of vehicles by brand and year range
 func (r *VehicleMap) GetByBrandAndYearRange(brand string, startYear int, finishYear int) (v map[int]internal.Vehicle, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	v = make(map[int]internal.Vehicle)
 
 	// copy db
 	for index, value := range r.db {
-		if value.Brand == brand && value.FabricationYear >= startYear && value.FabricationYear <= finishYear {
+		if internal.SameValue("brand", value.Brand, brand) && value.FabricationYear >= startYear && value.FabricationYear <= finishYear {
 			v[index] = value
 		}
 	}
@@ -85,52 +155,68 @@ func (r *VehicleMap) GetByBrandAndYearRange(brand string, startYear int, finishY
 
 // GetAverageSpeedByBrand is a method that returns the average speed of vehicles by brand
 func (r *VehicleMap) GetAverageSpeedByBrand(brand string) (averageSpeed float64, err error) {
-	averageSpeed = 0.0
-	count := 0
-
-	for _, value := range r.db {
-		if value.Brand == brand {
-			averageSpeed += value.MaxSpeed
-			count++
-		}
-	}
-
-	if count == 0 {
-		err = internal.ErrVehicleNotFoundByBrand
-	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 
-	averageSpeed /= float64(count)
+	averageSpeed, err = r.averageByBrand(brand, "max_speed")
 	return
 }
 
-// CreateMultiple is a method that creates multiple vehicles
-func (r *VehicleMap) CreateMultiple(v []internal.Vehicle) (err error) {
-	// Validate vehicles ID
+// CreateMultiple is a method that creates multiple vehicles with their ids, and returns them as stored
+func (r *VehicleMap) CreateMultiple(v []internal.Vehicle) (created []internal.Vehicle, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Validate vehicles ID, a batch does not repeat one either
+	seen := make(map[int]bool, len(v))
 	for _, vehicle := range v {
+		if seen[vehicle.Id] {
+			err = internal.ErrVehicleAlreadyExists
+			return
+		}
+		seen[vehicle.Id] = true
 		for _, value := range r.db {
 			if value.Id == vehicle.Id {
 				err = internal.ErrVehicleAlreadyExists
 				return
 			}
 		}
+		if _, ok := r.trash[vehicle.Id]; ok {
+			err = internal.ErrVehicleAlreadyExists
+			return
+		}
 
 	}
 
 	// Add vehicles to db
 	for _, vehicle := range v {
+		vehicle = r.canonical(vehicle)
+		vehicle.Version = 1
 		r.db[vehicle.Id] = vehicle
+		r.index.add(vehicle)
+		r.remember(vehicle.Id)
+		created = append(created, vehicle)
 	}
+	r.summary = nil
 
 	return
 }
 
-// UpdateSpeed is a method that updates the speed of a vehicle
-func (r *VehicleMap) Update(id int, fields map[string]any) (err error) {
+// UpdateSpeed is a method that updates the speed of a vehicle, if its version is the given one (0 for any),
+// and returns it as stored
+func (r *VehicleMap) Update(id int, fields map[string]any, version int) (updated internal.Vehicle, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	vehicle, ok := r.db[id]
 	if !ok {
 		err = internal.ErrVehicleNotFound
 		return
 	}
+	if version != 0 && version != vehicle.Version {
+		err = internal.ErrVehicleVersionMismatch
+		return
+	}
 
 	/*vehicle.MaxSpeed = speed // Update the speed of the copied vehicle
 	r.db[id] = vehicle       // Assign the updated vehicle back to the map
@@ -150,22 +236,31 @@ func (r *VehicleMap) Update(id int, fields map[string]any) (err error) {
 				err = internal.ErrFieldsMissing
 				return
 			}
+			vehicle.FuelType = internal.Canonical("fuel_type", vehicle.FuelType)
 		default:
 			err = internal.ErrFieldsMissing
 			return
 		}
 	}
+	vehicle.Version++
 	r.db[id] = vehicle
+	r.index.add(vehicle)
+	r.remember(id)
+	r.summary = nil
+	updated = vehicle
 	return
 }
 
 // GetByFuelType is a method that returns a map of vehicles by fuel type
 func (r *VehicleMap) GetByFuelType(fuelType string) (v map[int]internal.Vehicle, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	v = make(map[int]internal.Vehicle)
 
 	// copy db
 	for index, value := range r.db {
-		if value.FuelType == fuelType {
+		if internal.SameValue("fuel_type", value.FuelType, fuelType) {
 			v[index] = value
 		}
 	}
@@ -177,24 +272,39 @@ func (r *VehicleMap) GetByFuelType(fuelType string) (v map[int]internal.Vehicle,
 	return
 }
 
-// Delete is a method that deletes a vehicle
-func (r *VehicleMap) Delete(id int) (err error) {
-	if _, ok := r.db[id]; !ok {
+// Delete is a method that moves a vehicle to the trash, if its version is the given one (0 for any)
+func (r *VehicleMap) Delete(id int, version int) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	vehicle, ok := r.db[id]
+	if !ok {
 		err = internal.ErrVehicleNotFound
 		return
 	}
+	if version != 0 && version != vehicle.Version {
+		err = internal.ErrVehicleVersionMismatch
+		return
+	}
 
 	delete(r.db, id)
+	r.trash[id] = internal.TrashedVehicle{Vehicle: vehicle, DeletedAt: r.now()}
+	r.index.remove(id)
+	r.remember(id)
+	r.summary = nil
 	return
 }
 
 // GetByTransmission is a method that returns a map of vehicles by transmission type
 func (r *VehicleMap) GetByTransmission(transmission string) (v map[int]internal.Vehicle, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	v = make(map[int]internal.Vehicle)
 
 	// copy db
 	for index, value := range r.db {
-		if value.Transmission == transmission {
+		if internal.SameValue("transmission", value.Transmission, transmission) {
 			v[index] = value
 		}
 	}
@@ -208,36 +318,44 @@ func (r *VehicleMap) GetByTransmission(transmission string) (v map[int]internal.
 
 // GetAverageCapacityByBrand is a method that returns the average capacity of vehicles by brand
 func (r *VehicleMap) GetAverageCapacityByBrand(brand string) (averageCapacity float64, err error) {
-	averageCapacity = 0.0
-	count := 0
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 
-	for _, value := range r.db {
-		if value.Brand == brand {
-			averageCapacity += float64(value.Capacity)
-			count++
-		}
-	}
+	averageCapacity, err = r.averageByBrand(brand, "capacity")
+	return
+}
 
-	if count == 0 {
-		err = internal.ErrVehicleNotFoundByBrand
+// averageByBrand is a method that returns the average of a numeric field of vehicles by brand, the caller has to hold the lock
+func (r *VehicleMap) averageByBrand(brand string, field string) (average float64, err error) {
+	metric := internal.Metric{Func: "avg", Field: field}
+	groups, err := r.aggregate(internal.AggregateQuery{
+		Metrics: []internal.Metric{metric},
+		Filter:  internal.VehicleFilter{Brand: brand},
+	})
+	if err != nil {
+		if errors.Is(err, internal.ErrVehicleNotFound) {
+			err = internal.ErrVehicleNotFoundByBrand
+		}
+		return
 	}
 
-	averageCapacity /= float64(count)
+	average = groups[0].Values[metric.String()]
 	return
 }
 
 // GetByDimensions is a method that returns a map of vehicles by dimension
 func (r *VehicleMap) GetByDimensions(dimensions map[string]float64) (v map[int]internal.Vehicle, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	v = make(map[int]internal.Vehicle)
 
 	// copy db
 	_, ok_max_length := dimensions["max_length"]
 	_, ok_max_width := dimensions["max_width"]
 
-	fmt.Println(dimensions)
-
 	if !ok_max_length && !ok_max_width {
-		v, err = r.FindAll()
+		v, err = r.findAll()
 	} else if !ok_max_length {
 		for index, value := range r.db {
 			if value.Width <= dimensions["max_width"] && value.Width >= dimensions["min_width"] {
@@ -267,16 +385,17 @@ func (r *VehicleMap) GetByDimensions(dimensions map[string]float64) (v map[int]i
 
 // GetByWeight is a method that returns a map of vehicles by weight
 func (r *VehicleMap) GetByWeight(weight map[string]float64) (v map[int]internal.Vehicle, err error) {
-	v = make(map[int]internal.Vehicle)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 
-	fmt.Println(weight)
+	v = make(map[int]internal.Vehicle)
 
 	// copy db
 	_, ok_max_weight := weight["max"]
 	_, ok_min_weight := weight["min"]
 
 	if !ok_max_weight && !ok_min_weight {
-		v, err = r.FindAll()
+		v, err = r.findAll()
 	} else if !ok_max_weight {
 		for index, value := range r.db {
 			if value.Weight >= weight["min"] {
@@ -303,3 +422,79 @@ func (r *VehicleMap) GetByWeight(weight map[string]float64) (v map[int]internal.
 
 	return
 }
+
+// FindByID is a method that returns a vehicle by its id
+func (r *VehicleMap) FindByID(id int) (v internal.Vehicle, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	v, ok := r.db[id]
+	if !ok {
+		err = internal.ErrVehicleNotFound
+	}
+	return
+}
+
+// Replace is a method that replaces every attribute of a vehicle, if its version is the given one (0 for any),
+// and returns it as stored
+func (r *VehicleMap) Replace(v internal.Vehicle, version int) (replaced internal.Vehicle, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	vehicle, ok := r.db[v.Id]
+	if !ok {
+		err = internal.ErrVehicleNotFound
+		return
+	}
+	if version != 0 && version != vehicle.Version {
+		err = internal.ErrVehicleVersionMismatch
+		return
+	}
+
+	v = r.canonical(v)
+	v.Version = vehicle.Version + 1
+	// the string id is kept, it is not an attribute
+	v.UID = vehicle.UID
+	r.db[v.Id] = v
+	r.index.add(v)
+	r.remember(v.Id)
+	r.summary = nil
+	replaced = v
+	return
+}
+
+// state is a method that returns copies of the vehicles, the trash and the snapshots, taken at once
+func (r *VehicleMap) state() (v map[int]internal.Vehicle, trash map[int]internal.TrashedVehicle, snapshots []internal.Snapshot) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	v, _ = r.findAll()
+	trash = make(map[int]internal.TrashedVehicle, len(r.trash))
+	for key, value := range r.trash {
+		trash[key] = value
+	}
+	snapshots = append([]internal.Snapshot(nil), r.snapshots...)
+	return
+}
+
+// load is a method that replaces the vehicles, the trash and the snapshots, as written by state.
+// The vehicles keep their versions and the history starts again with them
+func (r *VehicleMap) load(v map[int]internal.Vehicle, trash map[int]internal.TrashedVehicle, snapshots []internal.Snapshot) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if v == nil {
+		v = make(map[int]internal.Vehicle)
+	}
+	if trash == nil {
+		trash = make(map[int]internal.TrashedVehicle)
+	}
+	r.db, r.trash, r.snapshots = v, trash, snapshots
+	r.index = newVehicleIndex(v)
+	r.summary = nil
+	r.history = make(map[int][]revision, len(v))
+	for id, value := range v {
+		vehicle := value
+		r.history[id] = []revision{{at: r.since, vehicle: &vehicle}}
+	}
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"app/internal"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Aggregate is a method that groups the vehicles matching the filter and computes the metrics of each group
+func (r *VehicleMap) Aggregate(q internal.AggregateQuery) (groups []internal.AggregateGroup, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	groups, err = r.aggregate(q)
+	return
+}
+
+// aggregate is a method that groups the vehicles and computes the metrics of each group, the caller has to hold the lock
+func (r *VehicleMap) aggregate(q internal.AggregateQuery) (groups []internal.AggregateGroup, err error) {
+	if err = q.Validate(); err != nil {
+		return
+	}
+
+	// group vehicles, keeping the order of the groups by key
+	type bucket struct {
+		key      map[string]string
+		vehicles []internal.Vehicle
+	}
+	buckets := make(map[string]*bucket)
+	var keys []string
+	db := r.db
+	if !q.AsOf.IsZero() {
+		if db, err = r.findAsOf(q.AsOf); err != nil {
+			return
+		}
+	}
+	for _, value := range db {
+		if !q.Filter.Match(value) {
+			continue
+		}
+		key := make(map[string]string, len(q.GroupBy))
+		parts := make([]string, len(q.GroupBy))
+		for i, field := range q.GroupBy {
+			key[field] = internal.VehicleGroupFields[field](value)
+			parts[i] = key[field]
+		}
+		id := strings.Join(parts, "\x00")
+		b, ok := buckets[id]
+		if !ok {
+			b = &bucket{key: key}
+			buckets[id] = b
+			keys = append(keys, id)
+		}
+		b.vehicles = append(b.vehicles, value)
+	}
+
+	if len(buckets) == 0 {
+		err = internal.ErrVehicleNotFound
+		return
+	}
+
+	// compute metrics
+	sort.Strings(keys)
+	for _, id := range keys {
+		b := buckets[id]
+		group := internal.AggregateGroup{Key: b.key, Values: make(map[string]float64, len(q.Metrics))}
+		for _, m := range q.Metrics {
+			group.Values[m.String()] = compute(m, b.vehicles)
+		}
+		groups = append(groups, group)
+	}
+	return
+}
+
+// compute is a function that returns the value of a metric over a non empty list of vehicles
+func compute(m internal.Metric, vehicles []internal.Vehicle) (result float64) {
+	if m.Func == "count" {
+		return float64(len(vehicles))
+	}
+
+	values := make([]float64, len(vehicles))
+	for i, value := range vehicles {
+		values[i] = internal.VehicleNumericFields[m.Field](value)
+	}
+
+	if p, ok := m.Percentile(); ok {
+		// nearest rank
+		sort.Float64s(values)
+		rank := int(math.Ceil(float64(p) / 100 * float64(len(values))))
+		return values[rank-1]
+	}
+
+	switch m.Func {
+	case "sum", "avg":
+		for _, v := range values {
+			result += v
+		}
+		if m.Func == "avg" {
+			result /= float64(len(values))
+		}
+	case "min":
+		result = values[0]
+		for _, v := range values[1:] {
+			result = math.Min(result, v)
+		}
+	case "max":
+		result = values[0]
+		for _, v := range values[1:] {
+			result = math.Max(result, v)
+		}
+	}
+	return
+}
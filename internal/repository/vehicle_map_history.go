@@ -0,0 +1,216 @@
+package repository
+
+import (
+	"app/internal"
+	"errors"
+	"time"
+)
+
+// revision is a struct that represents a vehicle as it was from a moment on
+type revision struct {
+	// at is the moment the revision was written, the start of the history for the vehicles known before it
+	at time.Time
+	// vehicle is the vehicle from at on, nil if it was deleted
+	vehicle *internal.Vehicle
+}
+
+// now is a method that returns the moment a write is made at, the caller has to hold the write lock
+func (r *VehicleMap) now() time.Time {
+	if !r.writeAt.IsZero() {
+		return r.writeAt
+	}
+	return time.Now().UTC()
+}
+
+// setWriteAt is a method that makes the next writes at a moment, until it is set back to zero
+func (r *VehicleMap) setWriteAt(at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.writeAt = at
+}
+
+// remember is a method that adds the vehicle as it is now in db to its history, the caller has to hold the write lock
+func (r *VehicleMap) remember(id int) {
+	rev := revision{at: r.now()}
+	if value, ok := r.db[id]; ok {
+		rev.vehicle = &value
+	}
+	r.history[id] = append(r.history[id], rev)
+}
+
+// resetHistory is a method that starts the history again from now, with the vehicles as they are,
+// so the writes replayed on start are not taken for writes made at the moment they are replayed
+func (r *VehicleMap) resetHistory() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.since = time.Now().UTC()
+	r.history = make(map[int][]revision, len(r.db))
+	for id, value := range r.db {
+		vehicle := value
+		r.history[id] = []revision{{at: r.since, vehicle: &vehicle}}
+	}
+}
+
+// historyState is a method that returns the start of the history and a copy of the revisions, taken at once
+func (r *VehicleMap) historyState() (since time.Time, history map[int][]revision) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	history = make(map[int][]revision, len(r.history))
+	for id, revisions := range r.history {
+		history[id] = append([]revision(nil), revisions...)
+	}
+	since = r.since
+	return
+}
+
+// loadHistory is a method that replaces the history, as written by historyState
+func (r *VehicleMap) loadHistory(since time.Time, history map[int][]revision) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.since, r.history = since, history
+}
+
+// FindAsOf is a method that returns a map of all vehicles as they were at a moment
+func (r *VehicleMap) FindAsOf(at time.Time) (v map[int]internal.Vehicle, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	v, err = r.findAsOf(at)
+	return
+}
+
+// findAsOf is a method that returns a map of all vehicles as they were at a moment, the caller has to hold the lock.
+// The history is not kept before the repository starts nor past its retention, so those moments are rejected
+func (r *VehicleMap) findAsOf(at time.Time) (v map[int]internal.Vehicle, err error) {
+	if at.Before(r.since) {
+		err = errors.Join(internal.ErrHistoryNotKept, internal.NewError("history_since", r.since.Format(time.RFC3339)))
+		return
+	}
+
+	v = make(map[int]internal.Vehicle)
+	for id, revisions := range r.history {
+		// latest revision written not after the moment
+		var last *internal.Vehicle
+		for _, rev := range revisions {
+			if rev.at.After(at) {
+				break
+			}
+			last = rev.vehicle
+		}
+		if last != nil {
+			v[id] = *last
+		}
+	}
+	return
+}
+
+// PurgeHistory is a method that forgets the revisions superseded before a moment, which becomes the start of the history
+func (r *VehicleMap) PurgeHistory(before time.Time) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !before.After(r.since) {
+		return
+	}
+	r.since = before.UTC()
+	for id, revisions := range r.history {
+		// the latest revision written not after the moment is kept, it is the vehicle as it was then
+		first := 0
+		for i, rev := range revisions {
+			if rev.at.After(before) {
+				break
+			}
+			first = i
+		}
+		revisions = revisions[first:]
+		if len(revisions) == 1 && revisions[0].vehicle == nil {
+			delete(r.history, id)
+			continue
+		}
+		r.history[id] = append([]revision(nil), revisions...)
+	}
+	return
+}
+
+// CreateSnapshot is a method that saves under a name the fleet as it was at a moment
+func (r *VehicleMap) CreateSnapshot(name string, at time.Time) (snapshot internal.Snapshot, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, value := range r.snapshots {
+		if value.Name == name {
+			err = internal.ErrSnapshotAlreadyExists
+			return
+		}
+	}
+
+	vehicles, err := r.findAsOf(at)
+	if err != nil {
+		return
+	}
+	snapshot = internal.Snapshot{
+		Name:      name,
+		CreatedAt: time.Now().UTC(),
+		AsOf:      at.UTC(),
+		Vehicles:  vehicles,
+	}
+	r.snapshots = append(r.snapshots, snapshot)
+	return
+}
+
+// restoreSnapshot is a method that adds a snapshot saved before, replayed from a log
+func (r *VehicleMap) restoreSnapshot(snapshot internal.Snapshot) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, value := range r.snapshots {
+		if value.Name == snapshot.Name {
+			err = internal.ErrSnapshotAlreadyExists
+			return
+		}
+	}
+	r.snapshots = append(r.snapshots, snapshot)
+	return
+}
+
+// deleteSnapshot is a method that removes a snapshot by its name
+func (r *VehicleMap) deleteSnapshot(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, value := range r.snapshots {
+		if value.Name == name {
+			r.snapshots = append(r.snapshots[:i:i], r.snapshots[i+1:]...)
+			return
+		}
+	}
+}
+
+// FindSnapshots is a method that returns the snapshots saved, oldest first
+func (r *VehicleMap) FindSnapshots() (snapshots []internal.Snapshot, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	snapshots = make([]internal.Snapshot, len(r.snapshots))
+	copy(snapshots, r.snapshots)
+	return
+}
+
+// FindSnapshot is a method that returns a snapshot by its name
+func (r *VehicleMap) FindSnapshot(name string) (snapshot internal.Snapshot, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, value := range r.snapshots {
+		if value.Name == name {
+			snapshot = value
+			return
+		}
+	}
+	err = internal.ErrSnapshotNotFound
+	return
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"app/internal"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestVehicleMap_FindAsOf(t *testing.T) {
+	start := time.Now()
+	rp := NewVehicleMap(map[int]internal.Vehicle{1: {Id: 1, VehicleAttributes: internal.VehicleAttributes{Brand: "Ford"}}})
+
+	// before the repository started there is no history
+	if _, err := rp.FindAsOf(start.Add(-time.Hour)); !errors.Is(err, internal.ErrHistoryNotKept) {
+		t.Fatalf("got %v, want ErrHistoryNotKept", err)
+	}
+
+	time.Sleep(time.Millisecond)
+	created := time.Now()
+	time.Sleep(time.Millisecond)
+	if err := rp.Delete(1, 0); err != nil {
+		t.Fatal(err)
+	}
+	v, err := rp.FindAsOf(created)
+	if err != nil || len(v) != 1 {
+		t.Fatalf("got %v and %v, want the vehicle before its delete", v, err)
+	}
+
+	// once purged, the vehicle deleted is forgotten and the moments before are rejected
+	if err = rp.PurgeHistory(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = rp.FindAsOf(created); !errors.Is(err, internal.ErrHistoryNotKept) {
+		t.Fatalf("got %v, want ErrHistoryNotKept", err)
+	}
+	if len(rp.history) != 0 {
+		t.Errorf("got %d vehicles in the history, want 0", len(rp.history))
+	}
+}
+
+func TestVehicleWAL_Snapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vehicles.wal")
+	seed := func() *VehicleMap {
+		return NewVehicleMap(map[int]internal.Vehicle{1: {Id: 1, VehicleAttributes: internal.VehicleAttributes{Brand: "Ford"}}})
+	}
+	wal, err := NewVehicleWAL(seed(), path, WALSyncAlways)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = wal.CreateSnapshot("before", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	wal.Close()
+
+	// the snapshots survive a restart
+	wal, err = NewVehicleWAL(seed(), path, WALSyncAlways)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wal.Close()
+	snapshot, err := wal.FindSnapshot("before")
+	if err != nil || len(snapshot.Vehicles) != 1 {
+		t.Fatalf("got %v and %v, want the snapshot with its vehicle", snapshot, err)
+	}
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"app/internal"
+	"sort"
+)
+
+// Search is a method that returns the vehicles matching every word of a text query, most relevant first
+func (r *VehicleMap) Search(query string) (results []internal.SearchResult, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for id, score := range r.index.search(query) {
+		results = append(results, internal.SearchResult{Vehicle: r.db[id], Score: score})
+	}
+
+	if len(results) == 0 {
+		err = internal.ErrVehicleNotFound
+		return
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Id < results[j].Id
+	})
+	return
+}
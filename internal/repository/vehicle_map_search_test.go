@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"app/internal"
+	"errors"
+	"math"
+	"slices"
+	"testing"
+)
+
+// newSearchMap is a function that returns a repository of a small fleet to search
+func newSearchMap() *VehicleMap {
+	vehicle := func(id int, brand string, model string, color string, registration string) internal.Vehicle {
+		return internal.Vehicle{Id: id, VehicleAttributes: internal.VehicleAttributes{Brand: brand, Model: model, Color: color, Registration: registration}}
+	}
+	return NewVehicleMap(map[int]internal.Vehicle{
+		1: vehicle(1, "Ford", "Focus", "Red", "ABC-123"),
+		2: vehicle(2, "Ford", "Fiesta", "Blue", "DEF-456"),
+		3: vehicle(3, "Citroën", "C3", "Red", "GHI-789"),
+		4: vehicle(4, "Shelby", "Ford GT", "White", "JKL-012"),
+	})
+}
+
+// searchIDs is a function that returns the ids of the vehicles found by a search, in order
+func searchIDs(rp *VehicleMap, query string) (ids []int, err error) {
+	results, err := rp.Search(query)
+	for _, r := range results {
+		ids = append(ids, r.Id)
+	}
+	return
+}
+
+func TestVehicleMap_Search(t *testing.T) {
+	rp := newSearchMap()
+	cases := []struct {
+		name  string
+		query string
+		ids   []int
+	}{
+		{name: "model ranks over brand", query: "ford", ids: []int{4, 1, 2}},
+		{name: "every word matches, in any case", query: "FORD  focus", ids: []int{1}},
+		{name: "words of different fields", query: "ford blue", ids: []int{2}},
+		{name: "accents", query: "citroen", ids: []int{3}},
+		{name: "prefixes", query: "fo", ids: []int{1, 4, 2}},
+		{name: "typos", query: "fiestq", ids: []int{2}},
+		{name: "ties by id", query: "red", ids: []int{1, 3}},
+		{name: "punctuation splits words", query: "abc-123", ids: []int{1}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ids, err := searchIDs(rp, c.query)
+			if err != nil || !slices.Equal(ids, c.ids) {
+				t.Errorf("got %v and %v, want %v", ids, err, c.ids)
+			}
+		})
+	}
+
+	for _, query := range []string{"tesla", " ,; ", "ford tesla"} {
+		if _, err := rp.Search(query); !errors.Is(err, internal.ErrVehicleNotFound) {
+			t.Errorf("got %v searching %q, want %v", err, query, internal.ErrVehicleNotFound)
+		}
+	}
+}
+
+func TestVehicleMap_SearchScores(t *testing.T) {
+	rp := newSearchMap()
+	results, err := rp.Search("ford")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the model weighs 3 and the brand 2, an exact word counts in full
+	if results[0].Score != 3 || results[1].Score != 2 {
+		t.Errorf("got scores %v and %v, want 3 and 2", results[0].Score, results[1].Score)
+	}
+	if results, _ = rp.Search("fies"); math.Abs(results[0].Score-2.4) > 1e-9 {
+		t.Errorf("got score %v for a prefix of the model, want 2.4", results[0].Score)
+	}
+}
+
+func TestVehicleMap_SearchAfterWrites(t *testing.T) {
+	rp := newSearchMap()
+	v, _ := rp.FindByID(2)
+
+	// replace
+	v.Model = "Mondeo"
+	if _, err := rp.Replace(v, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rp.Search("fiesta"); !errors.Is(err, internal.ErrVehicleNotFound) {
+		t.Errorf("got %v, want the old model gone from the index", err)
+	}
+	if ids, _ := searchIDs(rp, "mondeo"); !slices.Equal(ids, []int{2}) {
+		t.Errorf("got %v, want [2]", ids)
+	}
+
+	// delete, hidden in the trash
+	if err := rp.Delete(2, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rp.Search("mondeo"); !errors.Is(err, internal.ErrVehicleNotFound) {
+		t.Errorf("got %v, want the vehicle deleted gone from the index", err)
+	}
+
+	// restore
+	if _, err := rp.Restore(2); err != nil {
+		t.Fatal(err)
+	}
+	if ids, _ := searchIDs(rp, "mondeo"); !slices.Equal(ids, []int{2}) {
+		t.Errorf("got %v, want the vehicle restored back in the index", ids)
+	}
+
+	// purge
+	if err := rp.Purge(2, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rp.Search("mondeo"); !errors.Is(err, internal.ErrVehicleNotFound) {
+		t.Errorf("got %v, want the vehicle purged gone from the index", err)
+	}
+}
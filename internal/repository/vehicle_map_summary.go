@@ -0,0 +1,47 @@
+package repository
+
+import "app/internal"
+
+// Summary is a method that returns the figures of the whole fleet, computed in a single pass and cached until the next write
+func (r *VehicleMap) Summary() (s internal.FleetSummary, err error) {
+	// write lock, as the summary is cached
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.summary != nil {
+		s = r.summary.Clone()
+		return
+	}
+
+	s = internal.FleetSummary{
+		ByBrand:        make(map[string]int),
+		ByFuelType:     make(map[string]int),
+		ByTransmission: make(map[string]int),
+		ByColor:        make(map[string]int),
+		ByDecade:       make(map[int]int),
+	}
+	for _, value := range r.db {
+		v := value
+		s.Total++
+		s.ByBrand[v.Brand]++
+		s.ByFuelType[v.FuelType]++
+		s.ByTransmission[v.Transmission]++
+		s.ByColor[v.Color]++
+		s.ByDecade[v.FabricationYear/10*10]++
+		s.TotalCapacity += v.Capacity
+		// ties are resolved by the lowest id so the result does not depend on the map order
+		if s.Fastest == nil || v.MaxSpeed > s.Fastest.MaxSpeed || (v.MaxSpeed == s.Fastest.MaxSpeed && v.Id < s.Fastest.Id) {
+			s.Fastest = &v
+		}
+		if s.Heaviest == nil || v.Weight > s.Heaviest.Weight || (v.Weight == s.Heaviest.Weight && v.Id < s.Heaviest.Id) {
+			s.Heaviest = &v
+		}
+	}
+	if s.Total > 0 {
+		s.AverageCapacity = float64(s.TotalCapacity) / float64(s.Total)
+	}
+
+	cached := s.Clone()
+	r.summary = &cached
+	return
+}
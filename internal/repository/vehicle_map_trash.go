@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"app/internal"
+	"time"
+)
+
+// FindTrash is a method that returns a map of the vehicles in the trash
+func (r *VehicleMap) FindTrash() (v map[int]internal.TrashedVehicle, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	v = make(map[int]internal.TrashedVehicle)
+
+	// copy trash
+	for key, value := range r.trash {
+		v[key] = value
+	}
+
+	if len(v) == 0 {
+		err = internal.ErrVehicleNotFound
+	}
+	return
+}
+
+// Restore is a method that moves a vehicle back from the trash, and returns it as stored
+func (r *VehicleMap) Restore(id int) (restored internal.Vehicle, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	trashed, ok := r.trash[id]
+	if !ok {
+		err = internal.ErrVehicleNotFound
+		return
+	}
+
+	vehicle := trashed.Vehicle
+	vehicle.Version++
+	delete(r.trash, id)
+	r.db[id] = vehicle
+	r.index.add(vehicle)
+	r.remember(id)
+	r.summary = nil
+	restored = vehicle
+	return
+}
+
+// Purge is a method that deletes a vehicle for good, from the store if its version is the given one (0 for any) or from the trash
+func (r *VehicleMap) Purge(id int, version int) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.trash[id]; ok {
+		delete(r.trash, id)
+		return
+	}
+
+	vehicle, ok := r.db[id]
+	if !ok {
+		err = internal.ErrVehicleNotFound
+		return
+	}
+	if version != 0 && version != vehicle.Version {
+		err = internal.ErrVehicleVersionMismatch
+		return
+	}
+
+	delete(r.db, id)
+	r.index.remove(id)
+	r.remember(id)
+	r.summary = nil
+	return
+}
+
+// PurgeTrash is a method that deletes for good the vehicles moved to the trash before a moment
+func (r *VehicleMap) PurgeTrash(before time.Time) (ids []int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, value := range r.trash {
+		if value.DeletedAt.Before(before) {
+			delete(r.trash, id)
+			ids = append(ids, id)
+		}
+	}
+	return
+}
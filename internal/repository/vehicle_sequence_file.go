@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// NewVehicleSequenceFile is a function that returns a new instance of VehicleSequenceFile, resuming the sequence kept in the file
+func NewVehicleSequenceFile(path string) (r *VehicleSequenceFile, err error) {
+	r = &VehicleSequenceFile{path: path}
+
+	// read last id, if any
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		err = nil
+		return
+	}
+	if err != nil {
+		return
+	}
+	r.last, err = strconv.Atoi(strings.TrimSpace(string(data)))
+	return
+}
+
+// VehicleSequenceFile is a struct that represents a sequence of vehicle ids whose last id is kept in a file,
+// saved before the id is handed out so no id is handed out twice across restarts
+type VehicleSequenceFile struct {
+	// mu is the lock of the sequence
+	mu sync.Mutex
+	// path is the path to the file
+	path string
+	// last is the last id handed out or taken elsewhere
+	last int
+}
+
+// Next is a method that returns the next id of the sequence
+func (r *VehicleSequenceFile) Next() (id int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err = r.save(r.last + 1); err != nil {
+		return
+	}
+	r.last++
+	id = r.last
+	return
+}
+
+// Advance is a method that moves the sequence past an id taken elsewhere, such as one chosen by a client
+func (r *VehicleSequenceFile) Advance(id int) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if id <= r.last {
+		return
+	}
+	if err = r.save(id); err != nil {
+		return
+	}
+	r.last = id
+	return
+}
+
+// save is a method that replaces the file with the last id atomically, the lock is held by the caller
+func (r *VehicleSequenceFile) save(last int) (err error) {
+	file, err := os.CreateTemp(filepath.Dir(r.path), filepath.Base(r.path)+".*.tmp")
+	if err != nil {
+		return
+	}
+	defer os.Remove(file.Name())
+	if _, err = file.WriteString(strconv.Itoa(last) + "\n"); err != nil {
+		file.Close()
+		return
+	}
+	if err = file.Sync(); err != nil {
+		file.Close()
+		return
+	}
+	if err = file.Close(); err != nil {
+		return
+	}
+	err = os.Rename(file.Name(), r.path)
+	return
+}
@@ -0,0 +1,479 @@
+package repository
+
+import (
+	"app/internal"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// WALSyncAlways is the policy that syncs the log to disk after every write, no acknowledged write is lost
+	WALSyncAlways = "always"
+	// WALSyncInterval is the policy that syncs the log to disk when Sync is called, periodically
+	WALSyncInterval = "interval"
+	// WALSyncNever is the policy that leaves the sync of the log to the operating system
+	WALSyncNever = "never"
+)
+
+// operations of the records of the log
+const (
+	walCreate   = "create"
+	walReplace  = "replace"
+	walUpdate   = "update"
+	walDelete   = "delete"
+	walRestore  = "restore"
+	walPurge    = "purge"
+	walSnapshot = "snapshot"
+)
+
+// walRecord is a struct that represents a write in the log
+type walRecord struct {
+	// Seq is the number of the record, increasing across compactions, 0 for the records written before they were numbered
+	Seq int64 `json:"seq,omitempty"`
+	// Op is the operation of the write
+	Op string `json:"op"`
+	// Vehicles is the list of vehicles created or replaced
+	Vehicles []internal.Vehicle `json:"vehicles,omitempty"`
+	// ID is the id of the vehicle updated, deleted, restored or purged
+	ID int `json:"id,omitempty"`
+	// Fields is the map of fields updated
+	Fields map[string]any `json:"fields,omitempty"`
+	// At is the moment of the write, replayed as it was into the history and the trash; nil for the records written before it was kept
+	At *time.Time `json:"at,omitempty"`
+	// Snapshot is the snapshot created
+	Snapshot *internal.Snapshot `json:"snapshot,omitempty"`
+}
+
+// walState is a struct that represents the repository as it was when the log was compacted
+type walState struct {
+	// Seq is the number of the last record the state holds, the records up to it are not replayed on top of it
+	Seq int64 `json:"seq"`
+	// Vehicles is the list of vehicles, with their versions
+	Vehicles []internal.Vehicle `json:"vehicles"`
+	// Trash is the list of vehicles in the trash, with the moment they were deleted
+	Trash []internal.TrashedVehicle `json:"trash"`
+	// Snapshots is the list of snapshots saved, oldest first
+	Snapshots []internal.Snapshot `json:"snapshots"`
+	// Since is the moment the history starts, zero for the states written before the history was kept
+	Since time.Time `json:"since"`
+	// History is the list of revisions of the vehicles, oldest first for each one
+	History []walRevision `json:"history"`
+}
+
+// walRevision is a struct that represents a revision of a vehicle in the state
+type walRevision struct {
+	// ID is the id of the vehicle
+	ID int `json:"id"`
+	// At is the moment the revision was written
+	At time.Time `json:"at"`
+	// Vehicle is the vehicle from At on, nil if it was deleted
+	Vehicle *internal.Vehicle `json:"vehicle"`
+}
+
+// NewVehicleWAL is a function that returns a new instance of VehicleWAL, replaying the log on top of the state
+// of its last compaction, kept next to it as {path}.snapshot, or on top of rp until the first one.
+// A record torn or corrupted by a crash mid-write ends the log: it and everything after it are dropped.
+// A new log is compacted right away, so the history of the vehicles of rp is kept from the moment it starts
+func NewVehicleWAL(rp *VehicleMap, path string, sync string) (r *VehicleWAL, err error) {
+	switch sync {
+	case WALSyncAlways, WALSyncInterval, WALSyncNever:
+	default:
+		err = fmt.Errorf("unknown wal sync policy %s", sync)
+		return
+	}
+	r = &VehicleWAL{VehicleMap: rp, sync: sync, statePath: path + ".snapshot"}
+
+	// load the state of the last compaction, if any
+	var fresh, kept bool
+	data, err := os.ReadFile(r.statePath)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		err = nil
+		fresh = true
+	case err != nil:
+		return
+	default:
+		var state walState
+		if err = json.Unmarshal(data, &state); err != nil {
+			err = fmt.Errorf("%s: %w", r.statePath, err)
+			return
+		}
+		v := make(map[int]internal.Vehicle, len(state.Vehicles))
+		for _, vehicle := range state.Vehicles {
+			v[vehicle.Id] = vehicle
+		}
+		trash := make(map[int]internal.TrashedVehicle, len(state.Trash))
+		for _, vehicle := range state.Trash {
+			trash[vehicle.Id] = vehicle
+		}
+		rp.load(v, trash, state.Snapshots)
+		r.seq = state.Seq
+		if kept = !state.Since.IsZero(); kept {
+			history := make(map[int][]revision)
+			for _, rev := range state.History {
+				history[rev.ID] = append(history[rev.ID], revision{at: rev.At, vehicle: rev.Vehicle})
+			}
+			rp.loadHistory(state.Since, history)
+		}
+	}
+
+	// replay log, but the records the state already holds
+	data, err = os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	err = nil
+	fresh = fresh && len(data) == 0
+	base := r.seq
+	valid := 0
+	for valid < len(data) {
+		end := bytes.IndexByte(data[valid:], '\n')
+		if end < 0 {
+			break
+		}
+		rec, ok := decodeRecord(data[valid : valid+end])
+		if !ok {
+			break
+		}
+		if rec.Seq == 0 || rec.Seq > base {
+			// the writes that failed when logged fail the same way when replayed
+			_, _ = r.apply(rec)
+		}
+		r.seq = max(r.seq, rec.Seq)
+		valid += end + 1
+	}
+
+	// the logs written before the moments of the writes were kept start the history once replayed
+	if !fresh && !kept {
+		rp.resetHistory()
+	}
+
+	// open log, dropping what could not be replayed
+	r.file, err = os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	r.size = int64(valid)
+	if valid < len(data) {
+		if err = r.file.Truncate(r.size); err != nil {
+			return
+		}
+		if err = r.file.Sync(); err != nil {
+			return
+		}
+	}
+	if fresh {
+		err = r.Compact()
+	}
+	return
+}
+
+// VehicleWAL is a struct that represents a vehicle repository that writes every write to a log before applying
+// it to an in-memory repository, so the writes survive a restart. Snapshots and the history are kept too, so the
+// queries on the past survive it as well
+type VehicleWAL struct {
+	// VehicleMap is the repository the writes are applied to and the reads answered by
+	*VehicleMap
+	// mu is the lock that keeps the order of the log the order of the writes
+	mu sync.Mutex
+	// file is the log, one record per line
+	file *os.File
+	// size is the size of the log, where the next record starts
+	size int64
+	// seq is the number of the last record logged
+	seq int64
+	// statePath is the path to the file with the state of the last compaction
+	statePath string
+	// sync is the policy the log is synced to disk with
+	sync string
+	// dirty is whether the log has records not synced yet
+	dirty bool
+}
+
+// encodeRecord is a function that returns the line of a record: its checksum and its JSON
+func encodeRecord(rec walRecord) (line []byte, err error) {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return
+	}
+	line = fmt.Appendf(nil, "%08x %s\n", crc32.ChecksumIEEE(data), data)
+	return
+}
+
+// decodeRecord is a function that returns the record of a line, not ok if it is malformed or its checksum differs
+func decodeRecord(line []byte) (rec walRecord, ok bool) {
+	sum, data, found := bytes.Cut(line, []byte(" "))
+	if !found {
+		return
+	}
+	checksum, err := strconv.ParseUint(string(sum), 16, 32)
+	if err != nil || uint32(checksum) != crc32.ChecksumIEEE(data) {
+		return
+	}
+	ok = json.Unmarshal(data, &rec) == nil
+	return
+}
+
+// append is a method that writes a record to the log, numbering it, the caller has to hold the lock
+func (r *VehicleWAL) append(rec walRecord) (err error) {
+	rec.Seq = r.seq + 1
+	line, err := encodeRecord(rec)
+	if err != nil {
+		return
+	}
+	if _, err = r.file.Write(line); err != nil {
+		// a part of the line may be written
+		err = errors.Join(err, r.truncate(r.size))
+		return
+	}
+	r.seq = rec.Seq
+	r.size += int64(len(line))
+	r.dirty = true
+	if r.sync == WALSyncAlways {
+		err = r.flush()
+	}
+	return
+}
+
+// truncate is a method that drops the records of the log from an offset on, the caller has to hold the lock
+func (r *VehicleWAL) truncate(size int64) (err error) {
+	if err = r.file.Truncate(size); err != nil {
+		return
+	}
+	r.size = size
+	r.dirty = true
+	return
+}
+
+// flush is a method that syncs the log to disk, the caller has to hold the lock
+func (r *VehicleWAL) flush() (err error) {
+	if !r.dirty {
+		return
+	}
+	if err = r.file.Sync(); err != nil {
+		return
+	}
+	r.dirty = false
+	return
+}
+
+// apply is a method that applies a record to the in-memory repository at the moment of the write, and returns the vehicles it stored
+func (r *VehicleWAL) apply(rec walRecord) (stored []internal.Vehicle, err error) {
+	if rec.At != nil {
+		r.VehicleMap.setWriteAt(*rec.At)
+		defer r.VehicleMap.setWriteAt(time.Time{})
+	}
+	var v internal.Vehicle
+	switch rec.Op {
+	case walCreate:
+		stored, err = r.VehicleMap.CreateMultiple(rec.Vehicles)
+	case walReplace:
+		for _, vehicle := range rec.Vehicles {
+			if v, err = r.VehicleMap.Replace(vehicle, 0); err != nil {
+				return
+			}
+			stored = append(stored, v)
+		}
+	case walUpdate:
+		v, err = r.VehicleMap.Update(rec.ID, rec.Fields, 0)
+		stored = []internal.Vehicle{v}
+	case walDelete:
+		err = r.VehicleMap.Delete(rec.ID, 0)
+	case walRestore:
+		v, err = r.VehicleMap.Restore(rec.ID)
+		stored = []internal.Vehicle{v}
+	case walPurge:
+		err = r.VehicleMap.Purge(rec.ID, 0)
+	case walSnapshot:
+		err = r.VehicleMap.restoreSnapshot(*rec.Snapshot)
+	default:
+		err = fmt.Errorf("unknown wal operation %s", rec.Op)
+	}
+	return
+}
+
+// write is a method that logs a record and applies it, once the version of the vehicle is checked:
+// the record is logged as unconditional, as the replay makes the same writes on the same versions.
+// A write that fails is dropped from the log, so it is not replayed. It returns the vehicles stored by the write
+func (r *VehicleWAL) write(rec walRecord, id int, version int) (stored []internal.Vehicle, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if version != 0 {
+		if vehicle, e := r.VehicleMap.FindByID(id); e == nil && vehicle.Version != version {
+			err = internal.ErrVehicleVersionMismatch
+			return
+		}
+	}
+	at := time.Now().UTC()
+	rec.At = &at
+	size := r.size
+	if err = r.append(rec); err != nil {
+		return
+	}
+	if stored, err = r.apply(rec); err != nil {
+		if e := r.truncate(size); e != nil {
+			err = errors.Join(err, e)
+		}
+		return
+	}
+	return
+}
+
+// Create is a method that creates a vehicle with its id, and returns it as stored
+func (r *VehicleWAL) Create(v internal.Vehicle) (created internal.Vehicle, err error) {
+	stored, err := r.write(walRecord{Op: walCreate, Vehicles: []internal.Vehicle{v}}, v.Id, 0)
+	if err != nil {
+		return
+	}
+	created = stored[0]
+	return
+}
+
+// CreateMultiple is a method that creates multiple vehicles with their ids, and returns them as stored
+func (r *VehicleWAL) CreateMultiple(v []internal.Vehicle) (created []internal.Vehicle, err error) {
+	created, err = r.write(walRecord{Op: walCreate, Vehicles: v}, 0, 0)
+	return
+}
+
+// Replace is a method that replaces every attribute of a vehicle, if its version is the given one (0 for any),
+// and returns it as stored
+func (r *VehicleWAL) Replace(v internal.Vehicle, version int) (replaced internal.Vehicle, err error) {
+	stored, err := r.write(walRecord{Op: walReplace, Vehicles: []internal.Vehicle{v}}, v.Id, version)
+	if err != nil {
+		return
+	}
+	replaced = stored[0]
+	return
+}
+
+// Update is a method that updates any field of a vehicle, if its version is the given one (0 for any),
+// and returns it as stored
+func (r *VehicleWAL) Update(id int, fields map[string]any, version int) (updated internal.Vehicle, err error) {
+	stored, err := r.write(walRecord{Op: walUpdate, ID: id, Fields: fields}, id, version)
+	if err != nil {
+		return
+	}
+	updated = stored[0]
+	return
+}
+
+// Delete is a method that moves a vehicle to the trash, if its version is the given one (0 for any)
+func (r *VehicleWAL) Delete(id int, version int) (err error) {
+	_, err = r.write(walRecord{Op: walDelete, ID: id}, id, version)
+	return
+}
+
+// Restore is a method that moves a vehicle back from the trash, and returns it as stored
+func (r *VehicleWAL) Restore(id int) (restored internal.Vehicle, err error) {
+	stored, err := r.write(walRecord{Op: walRestore, ID: id}, id, 0)
+	if err != nil {
+		return
+	}
+	restored = stored[0]
+	return
+}
+
+// Purge is a method that deletes a vehicle for good, from the store if its version is the given one (0 for any) or from the trash
+func (r *VehicleWAL) Purge(id int, version int) (err error) {
+	_, err = r.write(walRecord{Op: walPurge, ID: id}, id, version)
+	return
+}
+
+// PurgeTrash is a method that deletes for good the vehicles moved to the trash before a moment,
+// logged as a purge of each vehicle since the moments of the trash are not kept by the snapshot
+func (r *VehicleWAL) PurgeTrash(before time.Time) (ids []int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	trash, _ := r.VehicleMap.FindTrash()
+	for id, value := range trash {
+		if !value.DeletedAt.Before(before) {
+			continue
+		}
+		if err = r.append(walRecord{Op: walPurge, ID: id}); err != nil {
+			return
+		}
+	}
+	ids, err = r.VehicleMap.PurgeTrash(before)
+	return
+}
+
+// CreateSnapshot is a method that saves under a name the fleet as it was at a moment, and logs it
+func (r *VehicleWAL) CreateSnapshot(name string, at time.Time) (snapshot internal.Snapshot, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if snapshot, err = r.VehicleMap.CreateSnapshot(name, at); err != nil {
+		return
+	}
+	if err = r.append(walRecord{Op: walSnapshot, Snapshot: &snapshot}); err != nil {
+		r.VehicleMap.deleteSnapshot(name)
+	}
+	return
+}
+
+// Sync is a method that syncs the log to disk, if it has records not synced yet
+func (r *VehicleWAL) Sync() (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err = r.flush()
+	return
+}
+
+// Compact is a method that writes the vehicles, the trash and the snapshots to the state file next to the log,
+// and truncates the log. A crash in between replays the log on top of the new state, which skips its records
+func (r *VehicleWAL) Compact() (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// write state
+	v, trash, snapshots := r.VehicleMap.state()
+	since, history := r.VehicleMap.historyState()
+	state := walState{Seq: r.seq, Vehicles: make([]internal.Vehicle, 0, len(v)), Trash: make([]internal.TrashedVehicle, 0, len(trash)), Snapshots: snapshots, Since: since}
+	for _, vehicle := range v {
+		state.Vehicles = append(state.Vehicles, vehicle)
+	}
+	for _, vehicle := range trash {
+		state.Trash = append(state.Trash, vehicle)
+	}
+	for id, revisions := range history {
+		for _, rev := range revisions {
+			state.History = append(state.History, walRevision{ID: id, At: rev.at, Vehicle: rev.vehicle})
+		}
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return
+	}
+	if err = writeFile(r.statePath, data); err != nil {
+		return
+	}
+
+	// truncate log
+	if err = r.truncate(0); err != nil {
+		return
+	}
+	err = r.flush()
+	return
+}
+
+// Close is a method that syncs and closes the log
+func (r *VehicleWAL) Close() (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err = r.flush(); err != nil {
+		return
+	}
+	err = r.file.Close()
+	return
+}
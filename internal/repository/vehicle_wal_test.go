@@ -0,0 +1,253 @@
+package repository
+
+import (
+	"app/internal"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestWAL is a function that returns a log at path on top of one vehicle, as the loader file would hold
+func newTestWAL(t *testing.T, path string) *VehicleWAL {
+	t.Helper()
+	rp := NewVehicleMap(map[int]internal.Vehicle{1: {Id: 1, VehicleAttributes: internal.VehicleAttributes{Brand: "Ford", MaxSpeed: 100}}})
+	wal, err := NewVehicleWAL(rp, path, WALSyncAlways)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { wal.Close() })
+	return wal
+}
+
+func TestVehicleWAL_TornLastLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vehicles.wal")
+	wal := newTestWAL(t, path)
+	if _, err := wal.Create(internal.Vehicle{Id: 2, VehicleAttributes: internal.VehicleAttributes{Brand: "Kia"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wal.Update(1, map[string]any{"speed": 120.0}, 0); err != nil {
+		t.Fatal(err)
+	}
+	wal.Close()
+
+	// a crash mid-write leaves the last record without its end
+	data, _ := os.ReadFile(path)
+	if err := os.WriteFile(path, data[:len(data)-10], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	wal = newTestWAL(t, path)
+	if _, err := wal.FindByID(2); err != nil {
+		t.Errorf("vehicle created before the torn record: %v", err)
+	}
+	if v, _ := wal.FindByID(1); v.MaxSpeed != 100 {
+		t.Errorf("got speed %v, want the torn update dropped", v.MaxSpeed)
+	}
+	if data, _ := os.ReadFile(path); !bytes.HasSuffix(data, []byte("\n")) {
+		t.Errorf("got the torn record kept in the log")
+	}
+}
+
+func TestVehicleWAL_BadChecksum(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vehicles.wal")
+	wal := newTestWAL(t, path)
+	for id := 2; id <= 3; id++ {
+		if _, err := wal.Create(internal.Vehicle{Id: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wal.Close()
+
+	// a record whose checksum differs ends the log, the records after it are dropped too
+	data, _ := os.ReadFile(path)
+	first := bytes.IndexByte(data, '\n') + 1
+	data[first] ^= 1
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	wal = newTestWAL(t, path)
+	if _, err := wal.FindByID(2); err != nil {
+		t.Errorf("vehicle of the record before the bad one: %v", err)
+	}
+	if _, err := wal.FindByID(3); !errors.Is(err, internal.ErrVehicleNotFound) {
+		t.Errorf("got %v, want the vehicle of the bad record dropped", err)
+	}
+	if info, _ := os.Stat(path); info.Size() != int64(first) {
+		t.Errorf("got log of %d bytes, want it truncated to %d", info.Size(), first)
+	}
+}
+
+func TestVehicleWAL_ReplayAfterCompaction(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "vehicles.wal")
+	wal := newTestWAL(t, path)
+	if _, err := wal.Update(1, map[string]any{"speed": 120.0}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := wal.Delete(1, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wal.Create(internal.Vehicle{Id: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := wal.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wal.Restore(1); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := wal.FindByID(1)
+	wal.Close()
+
+	// the state is written next to the log, never to the loader file
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Fatalf("got %d files, want the log and its state", len(entries))
+	}
+
+	wal = newTestWAL(t, path)
+	v, err := wal.FindByID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Version != want.Version || v.MaxSpeed != 120 {
+		t.Errorf("got version %d and speed %v, want %d and 120", v.Version, v.MaxSpeed, want.Version)
+	}
+	if v, err = wal.FindByID(2); err != nil || v.Version != 1 {
+		t.Errorf("got %v and %v, want vehicle 2 at version 1", v, err)
+	}
+}
+
+func TestVehicleWAL_CrashDuringCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vehicles.wal")
+	wal := newTestWAL(t, path)
+	if _, err := wal.Update(1, map[string]any{"speed": 120.0}, 0); err != nil {
+		t.Fatal(err)
+	}
+	wal.Close()
+	log, _ := os.ReadFile(path)
+
+	wal = newTestWAL(t, path)
+	if err := wal.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	wal.Close()
+
+	// a crash after the state is written but before the log is truncated replays nothing twice
+	if err := os.WriteFile(path, log, 0644); err != nil {
+		t.Fatal(err)
+	}
+	wal = newTestWAL(t, path)
+	if v, _ := wal.FindByID(1); v.Version != 2 {
+		t.Errorf("got version %d, want 2", v.Version)
+	}
+}
+
+func TestVehicleWAL_FailedWriteNotLogged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vehicles.wal")
+	wal := newTestWAL(t, path)
+	if _, err := wal.Create(internal.Vehicle{Id: 1}); !errors.Is(err, internal.ErrVehicleAlreadyExists) {
+		t.Fatalf("got %v, want ErrVehicleAlreadyExists", err)
+	}
+	if err := wal.Delete(7, 0); !errors.Is(err, internal.ErrVehicleNotFound) {
+		t.Fatalf("got %v, want ErrVehicleNotFound", err)
+	}
+
+	if info, _ := os.Stat(path); info.Size() != 0 {
+		t.Errorf("got log of %d bytes, want it empty", info.Size())
+	}
+}
+
+func TestVehicleWAL_ReplayDeletedAt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vehicles.wal")
+	wal := newTestWAL(t, path)
+	if err := wal.Delete(1, 0); err != nil {
+		t.Fatal(err)
+	}
+	trash, _ := wal.FindTrash()
+	deletedAt := trash[1].DeletedAt
+	wal.Close()
+
+	// the replay keeps the moment of the deletion, not the one of the restart
+	time.Sleep(10 * time.Millisecond)
+	wal = newTestWAL(t, path)
+	trash, _ = wal.FindTrash()
+	if got, ok := trash[1]; !ok || !got.DeletedAt.Equal(deletedAt) {
+		t.Errorf("got %v deleted at %v, want the deletion at %v", ok, got.DeletedAt, deletedAt)
+	}
+}
+
+func TestVehicleWAL_HistoryAfterRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vehicles.wal")
+	wal := newTestWAL(t, path)
+	loaded := time.Now()
+	if _, err := wal.Update(1, map[string]any{"speed": 120.0}, 0); err != nil {
+		t.Fatal(err)
+	}
+	updated := time.Now()
+	if err := wal.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if err := wal.Delete(1, 0); err != nil {
+		t.Fatal(err)
+	}
+	wal.Close()
+
+	// the past is the same after a restart, from the state of the compaction and from the log
+	wal = newTestWAL(t, path)
+	cases := []struct {
+		name  string
+		at    time.Time
+		speed float64
+		found bool
+	}{
+		{name: "as loaded", at: loaded, speed: 100, found: true},
+		{name: "as updated", at: updated, speed: 120, found: true},
+		{name: "as deleted", at: time.Now(), found: false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			v, err := wal.FindAsOf(c.at)
+			if err != nil {
+				t.Fatal(err)
+			}
+			vehicle, ok := v[1]
+			if ok != c.found || vehicle.MaxSpeed != c.speed {
+				t.Errorf("got %v with speed %v, want %v with speed %v", ok, vehicle.MaxSpeed, c.found, c.speed)
+			}
+		})
+	}
+}
+
+func TestVehicleWAL_WritesReturnStored(t *testing.T) {
+	wal := newTestWAL(t, filepath.Join(t.TempDir(), "vehicles.wal"))
+
+	// each write returns the vehicle as the store holds it, with its new version
+	updated, err := wal.Update(1, map[string]any{"speed": 120.0}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.MaxSpeed != 120 || updated.Version != 2 {
+		t.Errorf("got speed %v version %d, want 120 and 2", updated.MaxSpeed, updated.Version)
+	}
+	replaced, err := wal.Replace(internal.Vehicle{Id: 1, VehicleAttributes: internal.VehicleAttributes{Brand: "Kia"}}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replaced.Brand != "Kia" || replaced.Version != 3 {
+		t.Errorf("got brand %s version %d, want Kia and 3", replaced.Brand, replaced.Version)
+	}
+	if err = wal.Delete(1, 0); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := wal.Restore(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored, _ := wal.FindByID(1); restored != stored {
+		t.Errorf("got %+v, want %+v", restored, stored)
+	}
+}
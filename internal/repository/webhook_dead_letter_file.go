@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"app/internal"
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// NewWebhookDeadLetterFile is a function that returns a new instance of WebhookDeadLetterFile, loading the deliveries already in the file
+func NewWebhookDeadLetterFile(path string) (r *WebhookDeadLetterFile, err error) {
+	r = &WebhookDeadLetterFile{path: path}
+
+	// read previous deliveries, if any
+	err = readLines(path, func(line []byte) (err error) {
+		var d internal.WebhookDelivery
+		if err = json.Unmarshal(line, &d); err != nil {
+			return
+		}
+		r.deliveries = append(r.deliveries, d)
+		return
+	})
+	return
+}
+
+// WebhookDeadLetterFile is a struct that represents a dead-letter queue of webhook deliveries kept in an append-only file, one JSON delivery per line
+type WebhookDeadLetterFile struct {
+	// mu is the lock that serializes the writes to the file
+	mu sync.RWMutex
+	// path is the path to the file
+	path string
+	// deliveries is the list of deliveries of the file, oldest first
+	deliveries []internal.WebhookDelivery
+}
+
+// Append is a method that adds a delivery to the queue
+func (r *WebhookDeadLetterFile) Append(d internal.WebhookDelivery) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	line, err := json.Marshal(d)
+	if err != nil {
+		return
+	}
+	file, err := os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	if _, err = file.Write(append(line, '\n')); err != nil {
+		return
+	}
+	if err = file.Sync(); err != nil {
+		return
+	}
+
+	r.deliveries = append(r.deliveries, d)
+	return
+}
+
+// FindAll is a method that returns the deliveries of the queue, oldest first
+func (r *WebhookDeadLetterFile) FindAll() (d []internal.WebhookDelivery, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	d = append(d, r.deliveries...)
+	if len(d) == 0 {
+		err = internal.ErrWebhookDeliveriesNotFound
+	}
+	return
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"app/internal"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// NewWebhookFile is a function that returns a new instance of WebhookFile, loading the webhooks and the deliveries
+// pending already in the file. The log of the deliveries is not kept, only the ones awaiting a retry
+func NewWebhookFile(rp *WebhookMap, path string) (r *WebhookFile, err error) {
+	r = &WebhookFile{WebhookMap: rp, path: path}
+
+	// read previous webhooks, if any
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		err = nil
+		return
+	}
+	if err != nil {
+		return
+	}
+	var webhookJSON WebhookFileJSON
+	if err = json.Unmarshal(data, &webhookJSON); err != nil {
+		return
+	}
+	rp.restore(webhookJSON)
+	return
+}
+
+// WebhookFileJSON is a struct that represents the webhooks and the deliveries pending in JSON format
+type WebhookFileJSON struct {
+	LastID         int                  `json:"last_id"`
+	LastDeliveryID int64                `json:"last_delivery_id"`
+	Webhooks       []internal.Webhook   `json:"webhooks"`
+	Pending        []WebhookPendingJSON `json:"pending"`
+}
+
+// WebhookPendingJSON is a struct that represents a delivery awaiting its next attempt in JSON format
+type WebhookPendingJSON struct {
+	Delivery    internal.WebhookDelivery `json:"delivery"`
+	NextAttempt time.Time                `json:"next_attempt"`
+}
+
+// WebhookFile is a struct that represents a webhook repository kept in memory and saved to a JSON file
+// on every write of the webhooks and of the deliveries pending
+type WebhookFile struct {
+	// WebhookMap is the repository the webhooks are kept in
+	*WebhookMap
+	// mu is the lock of the writes, held until the file is saved so it is saved in the order of the writes
+	mu sync.Mutex
+	// path is the path to the file
+	path string
+}
+
+// Create is a method that creates a webhook, assigning its id
+func (r *WebhookFile) Create(w internal.Webhook) (created internal.Webhook, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if created, err = r.WebhookMap.Create(w); err != nil {
+		return
+	}
+	err = r.save()
+	return
+}
+
+// Update is a method that replaces the url, secret and events of a webhook
+func (r *WebhookFile) Update(w internal.Webhook) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err = r.WebhookMap.Update(w); err != nil {
+		return
+	}
+	err = r.save()
+	return
+}
+
+// Delete is a method that deletes a webhook and its deliveries
+func (r *WebhookFile) Delete(id int) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err = r.WebhookMap.Delete(id); err != nil {
+		return
+	}
+	err = r.save()
+	return
+}
+
+// SavePending is a method that adds or replaces a delivery awaiting its next attempt, by its id
+func (r *WebhookFile) SavePending(d internal.WebhookDelivery) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err = r.WebhookMap.SavePending(d); err != nil {
+		return
+	}
+	err = r.save()
+	return
+}
+
+// DeletePending is a method that removes a delivery awaiting its next attempt, once delivered or given up
+func (r *WebhookFile) DeletePending(id int64) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err = r.WebhookMap.DeletePending(id); err != nil {
+		return
+	}
+	err = r.save()
+	return
+}
+
+// save is a method that writes the webhooks and the deliveries pending to the file, replacing it atomically
+func (r *WebhookFile) save() (err error) {
+	data, err := json.Marshal(r.WebhookMap.dump())
+	if err != nil {
+		return
+	}
+
+	err = writeFile(r.path, data)
+	return
+}
@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"app/internal"
+	"sort"
+	"sync"
+)
+
+// NewWebhookMap is a function that returns a new instance of WebhookMap keeping the last size deliveries of each webhook
+func NewWebhookMap(size int) *WebhookMap {
+	return &WebhookMap{
+		db:         make(map[int]internal.Webhook),
+		deliveries: make(map[int][]internal.WebhookDelivery),
+		pending:    make(map[int64]internal.WebhookDelivery),
+		size:       size,
+	}
+}
+
+// WebhookMap is a struct that represents a webhook repository kept in memory
+type WebhookMap struct {
+	// mu is the lock that makes the repository safe for concurrent use
+	mu sync.RWMutex
+	// db is a map of webhooks
+	db map[int]internal.Webhook
+	// lastID is the id of the last webhook created
+	lastID int
+	// deliveries is the list of the last deliveries of each webhook, oldest first
+	deliveries map[int][]internal.WebhookDelivery
+	// lastDeliveryID is the id of the last delivery saved
+	lastDeliveryID int64
+	// size is the number of deliveries kept of each webhook
+	size int
+	// pending is the deliveries awaiting their next attempt, by id
+	pending map[int64]internal.WebhookDelivery
+}
+
+// FindAll is a method that returns the webhooks, by id
+func (r *WebhookMap) FindAll() (w []internal.Webhook, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	w = make([]internal.Webhook, 0, len(r.db))
+	for _, value := range r.db {
+		w = append(w, value)
+	}
+	sort.Slice(w, func(i, j int) bool { return w[i].ID < w[j].ID })
+	return
+}
+
+// FindByID is a method that returns a webhook by its id
+func (r *WebhookMap) FindByID(id int) (w internal.Webhook, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	w, ok := r.db[id]
+	if !ok {
+		err = internal.ErrWebhookNotFound
+	}
+	return
+}
+
+// Create is a method that creates a webhook, assigning its id
+func (r *WebhookMap) Create(w internal.Webhook) (created internal.Webhook, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastID++
+	w.ID = r.lastID
+	r.db[w.ID] = w
+	created = w
+	return
+}
+
+// Update is a method that replaces the url, secret and events of a webhook
+func (r *WebhookMap) Update(w internal.Webhook) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	webhook, ok := r.db[w.ID]
+	if !ok {
+		err = internal.ErrWebhookNotFound
+		return
+	}
+	webhook.URL = w.URL
+	webhook.Secret = w.Secret
+	webhook.Events = w.Events
+	r.db[w.ID] = webhook
+	return
+}
+
+// Delete is a method that deletes a webhook and its deliveries
+func (r *WebhookMap) Delete(id int) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.db[id]; !ok {
+		err = internal.ErrWebhookNotFound
+		return
+	}
+	delete(r.db, id)
+	delete(r.deliveries, id)
+	for key, d := range r.pending {
+		if d.WebhookID == id {
+			delete(r.pending, key)
+		}
+	}
+	return
+}
+
+// SaveDelivery is a method that adds or replaces a delivery of the log, assigning its id when 0
+func (r *WebhookMap) SaveDelivery(d internal.WebhookDelivery) (saved internal.WebhookDelivery, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deliveries := r.deliveries[d.WebhookID]
+	if d.ID != 0 {
+		for i, value := range deliveries {
+			if value.ID == d.ID {
+				deliveries[i] = d
+				saved = d
+				return
+			}
+		}
+	} else {
+		r.lastDeliveryID++
+		d.ID = r.lastDeliveryID
+	}
+	if len(deliveries) == r.size {
+		deliveries = deliveries[1:]
+	}
+	r.deliveries[d.WebhookID] = append(deliveries, d)
+	saved = d
+	return
+}
+
+// FindDeliveries is a method that returns the deliveries of a webhook, oldest first
+func (r *WebhookMap) FindDeliveries(webhookID int) (d []internal.WebhookDelivery, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	d = append(d, r.deliveries[webhookID]...)
+	if len(d) == 0 {
+		err = internal.ErrWebhookDeliveriesNotFound
+	}
+	return
+}
+
+// SavePending is a method that adds or replaces a delivery awaiting its next attempt, by its id
+func (r *WebhookMap) SavePending(d internal.WebhookDelivery) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.pending[d.ID] = d
+	return
+}
+
+// DeletePending is a method that removes a delivery awaiting its next attempt, once delivered or given up
+func (r *WebhookMap) DeletePending(id int64) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.pending, id)
+	return
+}
+
+// FindPending is a method that returns the deliveries awaiting their next attempt, the soonest first
+func (r *WebhookMap) FindPending() (d []internal.WebhookDelivery, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	d = make([]internal.WebhookDelivery, 0, len(r.pending))
+	for _, value := range r.pending {
+		d = append(d, value)
+	}
+	sort.Slice(d, func(i, j int) bool { return d[i].NextAttempt.Before(d[j].NextAttempt) })
+	return
+}
+
+// dump is a method that returns the webhooks and the deliveries pending in JSON format
+func (r *WebhookMap) dump() (w WebhookFileJSON) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	w.LastID = r.lastID
+	w.LastDeliveryID = r.lastDeliveryID
+	w.Webhooks = make([]internal.Webhook, 0, len(r.db))
+	for _, value := range r.db {
+		w.Webhooks = append(w.Webhooks, value)
+	}
+	sort.Slice(w.Webhooks, func(i, j int) bool { return w.Webhooks[i].ID < w.Webhooks[j].ID })
+	w.Pending = make([]WebhookPendingJSON, 0, len(r.pending))
+	for _, value := range r.pending {
+		w.Pending = append(w.Pending, WebhookPendingJSON{Delivery: value, NextAttempt: value.NextAttempt})
+	}
+	sort.Slice(w.Pending, func(i, j int) bool { return w.Pending[i].Delivery.ID < w.Pending[j].Delivery.ID })
+	return
+}
+
+// restore is a method that replaces the webhooks and the deliveries pending with the ones in JSON format
+func (r *WebhookMap) restore(w WebhookFileJSON) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastID = w.LastID
+	r.lastDeliveryID = w.LastDeliveryID
+	r.db = make(map[int]internal.Webhook, len(w.Webhooks))
+	for _, value := range w.Webhooks {
+		r.db[value.ID] = value
+	}
+	r.pending = make(map[int64]internal.WebhookDelivery, len(w.Pending))
+	for _, value := range w.Pending {
+		value.Delivery.NextAttempt = value.NextAttempt
+		r.pending[value.Delivery.ID] = value.Delivery
+	}
+}
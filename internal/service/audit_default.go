@@ -0,0 +1,53 @@
+package service
+
+import (
+	"app/internal"
+	"time"
+)
+
+// NewAuditDefault is a function that returns a new instance of AuditDefault
+func NewAuditDefault(rp internal.AuditRepository) *AuditDefault {
+	return &AuditDefault{rp: rp}
+}
+
+// AuditDefault is a struct that represents the default service for audit entries
+type AuditDefault struct {
+	// rp is the repository that will be used by the service
+	rp internal.AuditRepository
+}
+
+// FindByVehicle is a method that returns the entries of a vehicle of a tenant, oldest first
+func (s *AuditDefault) FindByVehicle(tenant string, id int) (entries []internal.AuditEntry, err error) {
+	entries, err = s.rp.FindByVehicle(tenant, id)
+	return
+}
+
+// Find is a method that returns the entries of a tenant since a moment, of an actor if not empty, oldest first
+func (s *AuditDefault) Find(tenant string, since time.Time, actor string) (entries []internal.AuditEntry, err error) {
+	entries, err = s.rp.Find(tenant, since, actor)
+	return
+}
+
+// Record is a method that appends a write published on the event bus to the audit log, as a synchronous subscriber
+func (s *AuditDefault) Record(e internal.DomainEvent) (err error) {
+	meta := e.Meta()
+	entry := internal.AuditEntry{
+		Tenant:    meta.Tenant,
+		Timestamp: meta.Timestamp,
+		Actor:     meta.Actor,
+		Operation: meta.Operation,
+		VehicleID: e.EventVehicleID(),
+	}
+	switch e := e.(type) {
+	case internal.VehicleCreated:
+		entry.Changes = internal.Diff(nil, &e.Vehicle)
+	case internal.VehicleRestored:
+		entry.Changes = internal.Diff(nil, &e.Vehicle)
+	case internal.VehicleUpdated:
+		entry.Changes = e.Changes
+	case internal.VehicleDeleted:
+		entry.Changes = internal.Diff(&e.Vehicle, nil)
+	}
+	err = s.rp.Append(entry)
+	return
+}
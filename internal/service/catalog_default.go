@@ -0,0 +1,87 @@
+package service
+
+import (
+	"app/internal"
+	"errors"
+	"fmt"
+)
+
+// NewCatalogDefault is a function that returns a new instance of CatalogDefault
+func NewCatalogDefault(rp internal.CatalogRepository, lenient bool) *CatalogDefault {
+	return &CatalogDefault{CatalogRepository: rp, lenient: lenient}
+}
+
+// CatalogDefault is a struct that represents the default service for catalogs
+type CatalogDefault struct {
+	// CatalogRepository is the repository that will be used by the service
+	internal.CatalogRepository
+	// lenient is whether unknown values of a vehicle are registered instead of rejected
+	lenient bool
+}
+
+// Check is a method that validates the categorical attributes of a vehicle against the catalogs,
+// letting the unknown values through when the service is lenient
+func (s *CatalogDefault) Check(v internal.Vehicle) (err error) {
+	if s.lenient {
+		return
+	}
+	for catalog, value := range attributes(v) {
+		if value == "" {
+			continue
+		}
+		var ok bool
+		ok, err = s.Exists(catalog, value)
+		if err != nil {
+			return
+		}
+		if !ok {
+			err = fmt.Errorf("%w %s %s", internal.ErrVehicleNotInCatalog, internal.CatalogAttributes[catalog], value)
+			return
+		}
+	}
+
+	if v.Brand == "" || v.Model == "" {
+		return
+	}
+	ok, err := s.ExistsModel(v.Brand, v.Model)
+	if err != nil || ok {
+		return
+	}
+	err = errors.Join(internal.ErrVehicleNotInCatalog, internal.NewError("model_of_brand", v.Model, v.Brand))
+	return
+}
+
+// Register is a method that adds the unknown categorical attributes of a vehicle, once written, to the catalogs
+// when the service is lenient
+func (s *CatalogDefault) Register(v internal.Vehicle) (err error) {
+	if !s.lenient {
+		return
+	}
+	for catalog, value := range attributes(v) {
+		if value == "" {
+			continue
+		}
+		if err = s.Create(catalog, value); err != nil && !errors.Is(err, internal.ErrCatalogValueAlreadyExists) {
+			return
+		}
+		err = nil
+	}
+
+	if v.Brand == "" || v.Model == "" {
+		return
+	}
+	if err = s.CreateModel(v.Brand, v.Model); errors.Is(err, internal.ErrCatalogValueAlreadyExists) {
+		err = nil
+	}
+	return
+}
+
+// attributes is a function that returns the categorical attributes of a vehicle by the catalog they belong to
+func attributes(v internal.Vehicle) map[string]string {
+	return map[string]string{
+		internal.CatalogBrands:        v.Brand,
+		internal.CatalogColors:        v.Color,
+		internal.CatalogFuelTypes:     v.FuelType,
+		internal.CatalogTransmissions: v.Transmission,
+	}
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"app/internal"
+	"time"
+)
+
+// NewIdempotencyDefault is a function that returns a new instance of IdempotencyDefault, which keeps the responses for ttl
+func NewIdempotencyDefault(rp internal.IdempotencyRepository, ttl time.Duration) *IdempotencyDefault {
+	return &IdempotencyDefault{rp: rp, ttl: ttl}
+}
+
+// IdempotencyDefault is a struct that represents the default service for idempotency keys
+type IdempotencyDefault struct {
+	// rp is the repository that will be used by the service
+	rp internal.IdempotencyRepository
+	// ttl is how long a response is kept for the retries of its request
+	ttl time.Duration
+}
+
+// Begin is a method that starts a request with a key, returning the stored record to replay when replay is true.
+// It fails with ErrIdempotencyKeyReused when the key was used with another request, and with
+// ErrIdempotencyInProgress when the first request with the key has not finished
+func (s *IdempotencyDefault) Begin(key string, fingerprint string) (r internal.IdempotencyRecord, replay bool, err error) {
+	now := time.Now()
+	r = internal.IdempotencyRecord{Key: key, Fingerprint: fingerprint, ExpiresAt: now.Add(s.ttl)}
+	existing, found, err := s.rp.Reserve(r, now)
+	if err != nil || !found {
+		return
+	}
+
+	switch {
+	case existing.Fingerprint != fingerprint:
+		err = internal.ErrIdempotencyKeyReused
+	case !existing.Done:
+		err = internal.ErrIdempotencyInProgress
+	default:
+		r, replay = existing, true
+	}
+	return
+}
+
+// End is a method that stores the response of a request started with Begin, or releases its key when r is not done
+func (s *IdempotencyDefault) End(r internal.IdempotencyRecord) (err error) {
+	if !r.Done {
+		err = s.rp.Release(r.Key)
+		return
+	}
+	err = s.rp.Complete(r)
+	return
+}
+
+// PurgeExpired is a method that removes the responses kept longer than the ttl
+func (s *IdempotencyDefault) PurgeExpired() (err error) {
+	err = s.rp.PurgeExpired(time.Now())
+	return
+}
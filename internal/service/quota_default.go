@@ -0,0 +1,42 @@
+package service
+
+import (
+	"app/internal"
+	"time"
+)
+
+// NewQuotaDefault is a function that returns a new instance of QuotaDefault, allowing limit requests
+// per key and UTC day, 0 for no quota
+func NewQuotaDefault(rp internal.QuotaRepository, limit int) *QuotaDefault {
+	return &QuotaDefault{rp: rp, limit: limit}
+}
+
+// QuotaDefault is a struct that represents the default service for daily quotas
+type QuotaDefault struct {
+	// rp is the repository that will be used by the service
+	rp internal.QuotaRepository
+	// limit is the number of requests allowed per key and day
+	limit int
+}
+
+// Take is a method that counts a request of a key against its daily quota, returning the requests left,
+// -1 without a quota, and when the quota starts again
+func (s *QuotaDefault) Take(key string, now time.Time) (remaining int, reset time.Time, err error) {
+	now = now.UTC()
+	reset = time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	if s.limit <= 0 {
+		remaining = -1
+		return
+	}
+
+	count, err := s.rp.Increment(key, now.Format(time.DateOnly))
+	if err != nil {
+		return
+	}
+	if count > s.limit {
+		err = internal.ErrQuotaExceeded
+		return
+	}
+	remaining = s.limit - count
+	return
+}
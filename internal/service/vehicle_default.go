@@ -1,16 +1,62 @@
 package service
 
-import "app/internal"
+import (
+	"app/internal"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
 
-// NewVehicleDefault is a function that returns a new instance of VehicleDefault
-func NewVehicleDefault(rp internal.VehicleRepository) *VehicleDefault {
-	return &VehicleDefault{rp: rp}
+// NewVehicleDefault is a function that returns a new instance of VehicleDefault,
+// ct may be nil to skip the catalog checks and bus may be nil to skip publishing the writes
+func NewVehicleDefault(rp internal.VehicleRepository, ct internal.CatalogService, bus *internal.EventBus) *VehicleDefault {
+	return &VehicleDefault{
+		rp:       rp,
+		ct:       ct,
+		bus:      bus,
+		locks:    new([64]sync.Mutex),
+		creating: new(sync.RWMutex),
+		logger:   slog.Default(),
+		tenant:   internal.DefaultTenant,
+		actor:    "anonymous",
+	}
 }
 
 // VehicleDefault is a struct that represents the default service for vehicles
 type VehicleDefault struct {
 	// rp is the repository that will be used by the service
 	rp internal.VehicleRepository
+	// ct is the catalog service the attributes of the vehicles are checked against
+	ct internal.CatalogService
+	// bus is the bus every write is published to, once done
+	bus *internal.EventBus
+	// locks is the lock of each shard of vehicle ids, held from a write until it is published
+	// so the events of a vehicle are published in the order of its writes
+	locks *[64]sync.Mutex
+	// creating is the lock taken to lock a shard, held exclusively by a create allocating an id
+	// from its write until it locks the shard of the id, so no write on the vehicle is published before it
+	creating *sync.RWMutex
+	// logger is the logger of the failures that follow a write done, which are not the caller's
+	logger *slog.Logger
+	// tenant is the tenant the vehicles belong to, published with every write
+	tenant string
+	// actor is who the writes are made on behalf of
+	actor string
+}
+
+// WithActor is a method that returns a copy of the service whose writes are made on behalf of actor
+func (s *VehicleDefault) WithActor(actor string) internal.VehicleService {
+	sv := *s
+	sv.actor = actor
+	return &sv
+}
+
+// WithTenant is a method that returns a copy of the service whose vehicles belong to tenant
+func (s *VehicleDefault) WithTenant(tenant string) *VehicleDefault {
+	sv := *s
+	sv.tenant = tenant
+	return &sv
 }
 
 // FindAll is a method that returns a map of all vehicles
@@ -19,9 +65,72 @@ func (s *VehicleDefault) FindAll() (v map[int]internal.Vehicle, err error) {
 	return
 }
 
-// Create is a method that creates a vehicle
-func (s *VehicleDefault) Create(v internal.Vehicle) (err error) {
-	err = s.rp.Create(v)
+// FindByUID is a method that returns a vehicle by its string id
+func (s *VehicleDefault) FindByUID(uid string) (v internal.Vehicle, err error) {
+	vehicles, err := s.rp.FindAll()
+	if err != nil {
+		return
+	}
+	for _, value := range vehicles {
+		if uid != "" && value.UID == uid {
+			v = value
+			return
+		}
+	}
+	err = internal.ErrVehicleNotFound
+	return
+}
+
+// FindAsOf is a method that returns a map of all vehicles as they were at a moment
+func (s *VehicleDefault) FindAsOf(at time.Time) (v map[int]internal.Vehicle, err error) {
+	v, err = s.rp.FindAsOf(at)
+	return
+}
+
+// FindByID is a method that returns a vehicle by its id
+func (s *VehicleDefault) FindByID(id int) (v internal.Vehicle, err error) {
+	v, err = s.rp.FindByID(id)
+	return
+}
+
+// Replace is a method that replaces every attribute of a vehicle, if its version is the given one (0 for any),
+// and returns it as stored
+func (s *VehicleDefault) Replace(v internal.Vehicle, version int) (replaced internal.Vehicle, err error) {
+	if err = s.check(v); err != nil {
+		return
+	}
+	defer s.lock(v.Id)()
+	before, _ := s.rp.FindByID(v.Id)
+	replaced, err = s.rp.Replace(v, version)
+	if err != nil {
+		return
+	}
+	s.register(replaced)
+	s.record(internal.AuditReplace, v.Id, &before, &replaced)
+	return
+}
+
+// Create is a method that creates a vehicle, allocating its id when 0, and returns it as stored
+func (s *VehicleDefault) Create(v internal.Vehicle) (created internal.Vehicle, err error) {
+	if err = s.check(v); err != nil {
+		return
+	}
+	if v.Id != 0 {
+		defer s.lock(v.Id)()
+		created, err = s.rp.Create(v)
+	} else {
+		s.creating.Lock()
+		created, err = s.rp.Create(v)
+		if err == nil {
+			defer s.lockShards(created.Id)()
+		}
+		s.creating.Unlock()
+	}
+	if err != nil {
+		return
+	}
+	s.register(created)
+	s.record(internal.AuditCreate, created.Id, nil, &created)
 	return
 }
 
@@ -43,15 +152,46 @@ func (s *VehicleDefault) GetAverageSpeedByBrand(brand string) (averageSpeed floa
 	return
 }
 
-// CreateMultiple is a method that creates multiple vehicles
-func (s *VehicleDefault) CreateMultiple(v []internal.Vehicle) (err error) {
-	err = s.rp.CreateMultiple(v)
+// CreateMultiple is a method that creates multiple vehicles, allocating the ids that are 0, and returns them as stored
+func (s *VehicleDefault) CreateMultiple(v []internal.Vehicle) (created []internal.Vehicle, err error) {
+	for _, vehicle := range v {
+		if err = s.check(vehicle); err != nil {
+			return
+		}
+	}
+	s.creating.Lock()
+	created, err = s.rp.CreateMultiple(v)
+	if err != nil {
+		s.creating.Unlock()
+		return
+	}
+	ids := make([]int, len(created))
+	for i, vehicle := range created {
+		ids[i] = vehicle.Id
+	}
+	defer s.lockShards(ids...)()
+	s.creating.Unlock()
+	for _, vehicle := range created {
+		s.register(vehicle)
+		s.record(internal.AuditCreate, vehicle.Id, nil, &vehicle)
+	}
 	return
 }
 
-// UpdateSpeed is a method that updates the speed of a vehicle
-func (s *VehicleDefault) Update(id int, fields map[string]any) (err error) {
-	err = s.rp.Update(id, fields)
+// UpdateSpeed is a method that updates the speed of a vehicle, and returns it as stored
+func (s *VehicleDefault) Update(id int, fields map[string]any, version int) (updated internal.Vehicle, err error) {
+	fuelType, _ := fields["fuel_type"].(string)
+	if err = s.check(internal.Vehicle{VehicleAttributes: internal.VehicleAttributes{FuelType: fuelType}}); err != nil {
+		return
+	}
+	defer s.lock(id)()
+	before, _ := s.rp.FindByID(id)
+	updated, err = s.rp.Update(id, fields, version)
+	if err != nil {
+		return
+	}
+	s.register(internal.Vehicle{VehicleAttributes: internal.VehicleAttributes{FuelType: fuelType}})
+	s.record(internal.AuditUpdate, id, &before, &updated)
 	return
 }
 
@@ -62,8 +202,74 @@ func (s *VehicleDefault) GetByFuelType(fuelType string) (v map[int]internal.Vehi
 }
 
 // Delete is a method that deletes a vehicle
-func (s *VehicleDefault) Delete(id int) (err error) {
-	err = s.rp.Delete(id)
+func (s *VehicleDefault) Delete(id int, version int) (err error) {
+	defer s.lock(id)()
+	before, _ := s.rp.FindByID(id)
+	err = s.rp.Delete(id, version)
+	if err != nil {
+		return
+	}
+	s.record(internal.AuditDelete, id, &before, nil)
+	return
+}
+
+// FindTrash is a method that returns a map of the vehicles in the trash
+func (s *VehicleDefault) FindTrash() (v map[int]internal.TrashedVehicle, err error) {
+	v, err = s.rp.FindTrash()
+	return
+}
+
+// Restore is a method that moves a vehicle back from the trash, and returns it as stored
+func (s *VehicleDefault) Restore(id int) (restored internal.Vehicle, err error) {
+	defer s.lock(id)()
+	restored, err = s.rp.Restore(id)
+	if err != nil {
+		return
+	}
+	s.record(internal.AuditRestore, id, nil, &restored)
+	return
+}
+
+// Purge is a method that deletes a vehicle for good, from the store if its version is the given one (0 for any) or from the trash
+func (s *VehicleDefault) Purge(id int, version int) (err error) {
+	defer s.lock(id)()
+	before, e := s.rp.FindByID(id)
+	if e != nil {
+		// the vehicle may be in the trash
+		if trash, e := s.rp.FindTrash(); e == nil {
+			before = trash[id].Vehicle
+		}
+	}
+	err = s.rp.Purge(id, version)
+	if err != nil {
+		return
+	}
+	s.record(internal.AuditPurge, id, &before, nil)
+	return
+}
+
+// PurgeTrash is a method that deletes for good the vehicles moved to the trash before a moment
+func (s *VehicleDefault) PurgeTrash(before time.Time) (ids []int, err error) {
+	trash, _ := s.rp.FindTrash()
+	trashed := make([]int, 0, len(trash))
+	for id := range trash {
+		trashed = append(trashed, id)
+	}
+	defer s.lock(trashed...)()
+	ids, err = s.rp.PurgeTrash(before)
+	if err != nil {
+		return
+	}
+	for _, id := range ids {
+		vehicle := trash[id].Vehicle
+		s.record(internal.AuditPurge, id, &vehicle, nil)
+	}
+	return
+}
+
+// PurgeHistory is a method that forgets the revisions superseded before a moment, which becomes the start of the history
+func (s *VehicleDefault) PurgeHistory(before time.Time) (err error) {
+	err = s.rp.PurgeHistory(before)
 	return
 }
 
@@ -90,3 +296,118 @@ func (s *VehicleDefault) GetByWeight(weight map[string]float64) (v map[int]inter
 	v, err = s.rp.GetByWeight(weight)
 	return
 }
+
+// Aggregate is a method that returns the metrics of the vehicles matching a filter, by group
+func (s *VehicleDefault) Aggregate(q internal.AggregateQuery) (groups []internal.AggregateGroup, err error) {
+	groups, err = s.rp.Aggregate(q)
+	return
+}
+
+// Summary is a method that returns the figures of the whole fleet
+func (s *VehicleDefault) Summary() (summary internal.FleetSummary, err error) {
+	summary, err = s.rp.Summary()
+	return
+}
+
+// Search is a method that returns the vehicles matching every word of a text query, most relevant first
+func (s *VehicleDefault) Search(query string) (results []internal.SearchResult, err error) {
+	results, err = s.rp.Search(query)
+	return
+}
+
+// CreateSnapshot is a method that saves under a name the fleet as it was at a moment
+func (s *VehicleDefault) CreateSnapshot(name string, at time.Time) (snapshot internal.Snapshot, err error) {
+	snapshot, err = s.rp.CreateSnapshot(name, at)
+	return
+}
+
+// FindSnapshots is a method that returns the snapshots saved, oldest first
+func (s *VehicleDefault) FindSnapshots() (snapshots []internal.Snapshot, err error) {
+	snapshots, err = s.rp.FindSnapshots()
+	return
+}
+
+// FindSnapshot is a method that returns a snapshot by its name
+func (s *VehicleDefault) FindSnapshot(name string) (snapshot internal.Snapshot, err error) {
+	snapshot, err = s.rp.FindSnapshot(name)
+	return
+}
+
+// check is a method that validates the attributes of a vehicle against the catalogs, if any
+func (s *VehicleDefault) check(v internal.Vehicle) (err error) {
+	if s.ct == nil {
+		return
+	}
+	err = s.ct.Check(v)
+	return
+}
+
+// register is a method that adds the attributes of a vehicle written to the catalogs, if any.
+// The write is already done by then, so a failure is only reported
+func (s *VehicleDefault) register(v internal.Vehicle) {
+	if s.ct == nil {
+		return
+	}
+	if err := s.ct.Register(v); err != nil {
+		s.logger.Error("register the attributes of a vehicle written in the catalogs", "tenant", s.tenant, "vehicle_id", v.Id, "error", err)
+	}
+}
+
+// lock is a method that locks the shards of some vehicle ids and returns the function that unlocks them,
+// waiting for a create allocating an id to lock the shard of its own
+func (s *VehicleDefault) lock(ids ...int) (unlock func()) {
+	s.creating.RLock()
+	defer s.creating.RUnlock()
+
+	unlock = s.lockShards(ids...)
+	return
+}
+
+// lockShards is a method that locks the shards of some vehicle ids, in order, and returns the function that unlocks them
+func (s *VehicleDefault) lockShards(ids ...int) (unlock func()) {
+	shards := make(map[int]bool)
+	for _, id := range ids {
+		shard := id % len(s.locks)
+		if shard < 0 {
+			shard += len(s.locks)
+		}
+		shards[shard] = true
+	}
+	order := make([]int, 0, len(shards))
+	for shard := range shards {
+		order = append(order, shard)
+	}
+	sort.Ints(order)
+	for _, shard := range order {
+		s.locks[shard].Lock()
+	}
+	return func() {
+		for _, shard := range order {
+			s.locks[shard].Unlock()
+		}
+	}
+}
+
+// record is a method that publishes a write to the bus, if any, with the vehicle before and after the write,
+// nil for none. The write is already done by then, so the failures of the subscribers are only logged
+func (s *VehicleDefault) record(operation string, id int, before *internal.Vehicle, after *internal.Vehicle) {
+	if s.bus == nil {
+		return
+	}
+
+	var err error
+	meta := internal.EventMeta{Tenant: s.tenant, Timestamp: time.Now().UTC(), Actor: s.actor, Operation: operation}
+	switch {
+	case after != nil && operation == internal.AuditRestore:
+		err = s.bus.Publish(internal.VehicleRestored{EventMeta: meta, Vehicle: *after})
+	case after != nil && before == nil:
+		err = s.bus.Publish(internal.VehicleCreated{EventMeta: meta, Vehicle: *after})
+	case after != nil:
+		err = s.bus.Publish(internal.VehicleUpdated{EventMeta: meta, Before: *before, After: *after, Changes: internal.Diff(before, after)})
+	case before != nil:
+		err = s.bus.Publish(internal.VehicleDeleted{EventMeta: meta, Vehicle: *before})
+	}
+	if err != nil {
+		s.logger.Error("publish a vehicle written", "tenant", s.tenant, "vehicle_id", id, "operation", operation, "error", err)
+	}
+}
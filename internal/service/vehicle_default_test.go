@@ -0,0 +1,68 @@
+package service_test
+
+import (
+	"app/internal"
+	"app/internal/repository"
+	"app/internal/service"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestVehicleDefault_Create(t *testing.T) {
+	rp := repository.NewVehicleMap(map[int]internal.Vehicle{})
+	seq, err := repository.NewVehicleSequenceFile(t.TempDir() + "/vehicles.seq")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, err := repository.NewVehicleIDs(rp, seq, repository.VehicleIDSequence, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bus := internal.NewEventBus(1, nil)
+	defer bus.Close()
+	var mu sync.Mutex
+	var events []internal.DomainEvent
+	internal.Subscribe(bus, func(e internal.DomainEvent) error {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+		return nil
+	})
+	// a subscriber failing after the write does not fail it
+	internal.Subscribe(bus, func(e internal.VehicleCreated) error { return errors.New("audit log unavailable") })
+	sv := service.NewVehicleDefault(ids, nil, bus)
+
+	// the writes on a vehicle created concurrently are published after its create
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			created, err := sv.Create(internal.Vehicle{VehicleAttributes: internal.VehicleAttributes{Brand: "Ford"}})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if _, err = sv.Update(created.Id, map[string]any{"speed": 100.0}, 0); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	created := make(map[int]bool)
+	for _, e := range events {
+		switch e := e.(type) {
+		case internal.VehicleCreated:
+			created[e.Vehicle.Id] = true
+		case internal.VehicleUpdated:
+			if !created[e.After.Id] {
+				t.Fatalf("got the update of vehicle %d published before its create", e.After.Id)
+			}
+		}
+	}
+	if len(created) != 20 {
+		t.Errorf("got %d vehicles created, want 20", len(created))
+	}
+}
@@ -0,0 +1,55 @@
+package service
+
+import "app/internal"
+
+// NewVehicleEventDefault is a function that returns a new instance of VehicleEventDefault, with the feed of each tenant
+func NewVehicleEventDefault(rps map[string]internal.VehicleEventRepository) *VehicleEventDefault {
+	return &VehicleEventDefault{rps: rps}
+}
+
+// VehicleEventDefault is a struct that represents the default service for vehicle events
+type VehicleEventDefault struct {
+	// rps is the repository of the feed of each tenant, by tenant id
+	rps map[string]internal.VehicleEventRepository
+}
+
+// Subscribe is a method that returns the events of the feed of a tenant after an id (0 for none) and a channel
+// with the events published from then on, closed when the subscriber falls behind or cancel is called.
+// Only the events match reports are returned and queued, every one when it is nil
+func (s *VehicleEventDefault) Subscribe(tenant string, after int64, match func(e internal.VehicleEvent) bool) (backlog []internal.VehicleEvent, events <-chan internal.VehicleEvent, cancel func(), err error) {
+	rp, ok := s.rps[tenant]
+	if !ok {
+		err = internal.ErrTenantNotFound
+		return
+	}
+	backlog, events, cancel, err = rp.Subscribe(after, match)
+	return
+}
+
+// Record is a method that adds a write published on the event bus to the change feed, as a synchronous subscriber
+func (s *VehicleEventDefault) Record(e internal.DomainEvent) (err error) {
+	meta := e.Meta()
+	event := internal.VehicleEvent{
+		Tenant:    meta.Tenant,
+		Type:      meta.Operation,
+		Timestamp: meta.Timestamp,
+		VehicleID: e.EventVehicleID(),
+	}
+	switch e := e.(type) {
+	case internal.VehicleCreated:
+		event.After = &e.Vehicle
+	case internal.VehicleRestored:
+		event.After = &e.Vehicle
+	case internal.VehicleUpdated:
+		event.Before, event.After = &e.Before, &e.After
+	case internal.VehicleDeleted:
+		event.Before = &e.Vehicle
+	}
+	rp, ok := s.rps[meta.Tenant]
+	if !ok {
+		err = internal.ErrTenantNotFound
+		return
+	}
+	err = rp.Publish(event)
+	return
+}
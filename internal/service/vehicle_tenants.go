@@ -0,0 +1,35 @@
+package service
+
+import (
+	"app/internal"
+	"sort"
+)
+
+// NewVehicleTenants is a function that returns a new instance of VehicleTenants
+func NewVehicleTenants(services map[string]internal.VehicleService) *VehicleTenants {
+	return &VehicleTenants{services: services}
+}
+
+// VehicleTenants is a struct that represents the vehicle services of the tenants, by tenant id
+type VehicleTenants struct {
+	// services is the vehicle service of each tenant
+	services map[string]internal.VehicleService
+}
+
+// Tenants is a method that returns the ids of the tenants, sorted
+func (s *VehicleTenants) Tenants() (tenants []string) {
+	for id := range s.services {
+		tenants = append(tenants, id)
+	}
+	sort.Strings(tenants)
+	return
+}
+
+// Tenant is a method that returns the vehicle service of a tenant
+func (s *VehicleTenants) Tenant(id string) (sv internal.VehicleService, err error) {
+	sv, ok := s.services[id]
+	if !ok {
+		err = internal.ErrTenantNotFound
+	}
+	return
+}
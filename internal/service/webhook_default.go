@@ -0,0 +1,428 @@
+package service
+
+import (
+	"app/internal"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// NewWebhookDefault is a function that returns a new instance of WebhookDefault, which delivers each event
+// up to maxAttempts times, waiting backoff before the first retry and twice as long before each next one
+func NewWebhookDefault(rp internal.WebhookRepository, dl internal.WebhookDeadLetterRepository, ev internal.VehicleEventService, client *http.Client, maxAttempts int, backoff time.Duration, logger *slog.Logger) *WebhookDefault {
+	return &WebhookDefault{
+		rp:          rp,
+		dl:          dl,
+		ev:          ev,
+		client:      client,
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+		logger:      logger,
+		wake:        make(chan struct{}, 1),
+		inFlight:    make(map[int64]bool),
+	}
+}
+
+// NewWebhookClient is a function that returns the client the webhooks are posted with, which refuses to connect
+// to the addresses of the network of the server, even when a public name resolves to one or a webhook redirects to one
+func NewWebhookClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !internal.PublicIP(ip) {
+				return fmt.Errorf("webhook address %s is not public", host)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// WebhookDefault is a struct that represents the default service for webhooks
+type WebhookDefault struct {
+	// rp is the repository of the webhooks and their deliveries
+	rp internal.WebhookRepository
+	// dl is the repository of the deliveries that failed for good
+	dl internal.WebhookDeadLetterRepository
+	// ev is the service the events delivered are received from
+	ev internal.VehicleEventService
+	// client is the client the events are posted with
+	client *http.Client
+	// maxAttempts is the number of times an event is posted before it goes to the dead-letter queue
+	maxAttempts int
+	// backoff is the wait before the first retry, doubled on each attempt
+	backoff time.Duration
+	// logger is the logger of the errors of the deliveries
+	logger *slog.Logger
+	// wake is signaled when a delivery is pending sooner than the deliverer waits for
+	wake chan struct{}
+	// mu is the lock of the deliveries in flight
+	mu sync.Mutex
+	// inFlight is whether each delivery pending is being attempted, by id
+	inFlight map[int64]bool
+	// attempts is the group of the attempts in flight, waited for when the deliverer stops
+	attempts sync.WaitGroup
+}
+
+// FindAll is a method that returns the webhooks of a tenant, by id
+func (s *WebhookDefault) FindAll(tenant string) (w []internal.Webhook, err error) {
+	webhooks, err := s.rp.FindAll()
+	if err != nil {
+		return
+	}
+	for _, value := range webhooks {
+		if value.Tenant == tenant {
+			w = append(w, value)
+		}
+	}
+	return
+}
+
+// FindByID is a method that returns a webhook of a tenant by its id, the webhooks of other tenants are not found
+func (s *WebhookDefault) FindByID(tenant string, id int) (w internal.Webhook, err error) {
+	w, err = s.rp.FindByID(id)
+	if err == nil && w.Tenant != tenant {
+		w, err = internal.Webhook{}, internal.ErrWebhookNotFound
+	}
+	return
+}
+
+// Create is a method that creates a webhook, generating its secret when empty
+func (s *WebhookDefault) Create(w internal.Webhook) (created internal.Webhook, err error) {
+	if err = w.Validate(); err != nil {
+		return
+	}
+	if w.Secret == "" {
+		key := make([]byte, 32)
+		if _, err = rand.Read(key); err != nil {
+			return
+		}
+		w.Secret = hex.EncodeToString(key)
+	}
+	w.CreatedAt = time.Now().UTC()
+	created, err = s.rp.Create(w)
+	return
+}
+
+// Update is a method that replaces the url, secret and events of a webhook of its tenant, keeping the secret when empty
+func (s *WebhookDefault) Update(w internal.Webhook) (err error) {
+	if err = w.Validate(); err != nil {
+		return
+	}
+	current, err := s.FindByID(w.Tenant, w.ID)
+	if err != nil {
+		return
+	}
+	if w.Secret == "" {
+		w.Secret = current.Secret
+	}
+	err = s.rp.Update(w)
+	return
+}
+
+// Delete is a method that deletes a webhook of a tenant and its deliveries
+func (s *WebhookDefault) Delete(tenant string, id int) (err error) {
+	if _, err = s.FindByID(tenant, id); err != nil {
+		return
+	}
+	err = s.rp.Delete(id)
+	return
+}
+
+// FindDeliveries is a method that returns the deliveries of a webhook of a tenant, oldest first
+func (s *WebhookDefault) FindDeliveries(tenant string, webhookID int) (d []internal.WebhookDelivery, err error) {
+	if _, err = s.FindByID(tenant, webhookID); err != nil {
+		return
+	}
+	d, err = s.rp.FindDeliveries(webhookID)
+	return
+}
+
+// FindDeadLetters is a method that returns the deliveries of a tenant that failed for good, oldest first
+func (s *WebhookDefault) FindDeadLetters(tenant string) (d []internal.WebhookDelivery, err error) {
+	deliveries, err := s.dl.FindAll()
+	if err != nil {
+		return
+	}
+	for _, value := range deliveries {
+		// the deliveries queued before tenants belong to the default one
+		if value.Tenant == tenant || (value.Tenant == "" && tenant == internal.DefaultTenant) {
+			d = append(d, value)
+		}
+	}
+	if len(d) == 0 {
+		err = internal.ErrWebhookDeliveriesNotFound
+	}
+	return
+}
+
+// Dispatch is a method that queues the events of the change feed of a tenant for the webhooks that want them, until ctx is done.
+// The deliveries are kept pending until Deliver posts them, so they survive a restart, and the events lost
+// behind the feed are sent to the dead-letter queue of every webhook of the tenant
+func (s *WebhookDefault) Dispatch(ctx context.Context, tenant string) {
+	var last int64
+	for {
+		backlog, events, cancel, err := s.ev.Subscribe(tenant, last, nil)
+		if err != nil && !errors.Is(err, internal.ErrVehicleEventsExpired) {
+			s.logger.Error("webhooks: subscribe to the change feed", "tenant", tenant, "error", err)
+			return
+		}
+		if err != nil && len(backlog) > 0 {
+			s.lose(tenant, last+1, backlog[0].ID-1)
+		}
+		for _, e := range backlog {
+			s.dispatch(e)
+			last = e.ID
+		}
+		// the channel is closed when the dispatch falls behind the feed, then it resumes from the last event
+		for open := true; open; {
+			select {
+			case <-ctx.Done():
+				cancel()
+				return
+			case e, ok := <-events:
+				if !ok {
+					open = false
+					continue
+				}
+				s.dispatch(e)
+				last = e.ID
+			}
+		}
+		cancel()
+	}
+}
+
+// lose is a method that sends to the dead-letter queue of the webhooks of a tenant the events lost behind the feed
+func (s *WebhookDefault) lose(tenant string, first int64, last int64) {
+	s.logger.Warn("webhooks: events lost behind the change feed", "tenant", tenant, "first", first, "last", last)
+	webhooks, err := s.rp.FindAll()
+	if err != nil {
+		s.logger.Error("webhooks: find the webhooks", "error", err)
+		return
+	}
+	for _, w := range webhooks {
+		if w.Tenant != tenant {
+			continue
+		}
+		d := internal.WebhookDelivery{
+			Tenant:    w.Tenant,
+			WebhookID: w.ID,
+			EventID:   first,
+			Error:     fmt.Sprintf("events %d to %d lost behind the change feed", first, last),
+			Timestamp: time.Now().UTC(),
+		}
+		if err = s.dl.Append(d); err != nil {
+			s.logger.Error("webhooks: append to the dead-letter queue", "webhook_id", w.ID, "error", err)
+		}
+	}
+}
+
+// dispatch is a method that queues the delivery of an event to the webhooks that want it
+func (s *WebhookDefault) dispatch(e internal.VehicleEvent) {
+	webhooks, err := s.rp.FindAll()
+	if err != nil {
+		s.logger.Error("webhooks: find the webhooks", "error", err)
+		return
+	}
+	payload, err := payloadOf(e)
+	if err != nil {
+		s.logger.Error("webhooks: encode the event", "event_id", e.ID, "error", err)
+		return
+	}
+	for _, w := range webhooks {
+		if w.Tenant != e.Tenant || !w.Wants(e.Type) {
+			continue
+		}
+		d, err := s.rp.SaveDelivery(internal.WebhookDelivery{
+			Tenant:    w.Tenant,
+			WebhookID: w.ID,
+			EventID:   e.ID,
+			Event:     e.Type,
+			VehicleID: e.VehicleID,
+			Payload:   payload,
+			Timestamp: time.Now().UTC(),
+		})
+		if err != nil {
+			s.logger.Error("webhooks: save the delivery", "webhook_id", w.ID, "event_id", e.ID, "error", err)
+			continue
+		}
+		d.NextAttempt = d.Timestamp
+		if err = s.rp.SavePending(d); err != nil {
+			s.logger.Error("webhooks: queue the delivery", "webhook_id", w.ID, "event_id", e.ID, "error", err)
+		}
+	}
+	s.signal()
+}
+
+// signal is a method that wakes the deliverer up, unless it is already signaled
+func (s *WebhookDefault) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// payloadOf is a function that returns the JSON body posted for an event
+func payloadOf(e internal.VehicleEvent) (payload []byte, err error) {
+	body := map[string]any{
+		"id":         e.ID,
+		"tenant":     e.Tenant,
+		"type":       e.Type,
+		"timestamp":  e.Timestamp,
+		"vehicle_id": e.VehicleID,
+		"before":     nil,
+		"after":      nil,
+	}
+	if e.Before != nil {
+		body["before"] = e.Before.Fields()
+	}
+	if e.After != nil {
+		body["after"] = e.After.Fields()
+	}
+	payload, err = json.Marshal(body)
+	return
+}
+
+// Deliver is a method that posts the deliveries pending once due, until ctx is done, those left from before a restart first.
+// Each attempt runs on its own, so a slow webhook does not hold the others back, and the receivers order
+// the events by their id. Once ctx is done it returns after the attempts in flight end
+func (s *WebhookDefault) Deliver(ctx context.Context) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			s.attempts.Wait()
+			return
+		case <-timer.C:
+		case <-s.wake:
+		}
+
+		pending, err := s.rp.FindPending()
+		if err != nil {
+			s.logger.Error("webhooks: find the deliveries pending", "error", err)
+		}
+		// the next wake up is the soonest delivery pending not in flight, a retry signals when it is queued again
+		wait := time.Hour
+		now := time.Now()
+		s.mu.Lock()
+		for _, d := range pending {
+			if s.inFlight[d.ID] {
+				continue
+			}
+			if d.NextAttempt.After(now) {
+				wait = min(wait, d.NextAttempt.Sub(now))
+				continue
+			}
+			s.inFlight[d.ID] = true
+			s.attempts.Add(1)
+			go func(d internal.WebhookDelivery) {
+				defer s.attempts.Done()
+				s.attempt(d)
+			}(d)
+		}
+		s.mu.Unlock()
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+	}
+}
+
+// attempt is a method that posts a delivery pending to its webhook, queuing it again with exponential backoff
+// when it fails, and sending it to the dead-letter queue when every attempt failed
+func (s *WebhookDefault) attempt(d internal.WebhookDelivery) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.inFlight, d.ID)
+		s.mu.Unlock()
+		s.signal()
+	}()
+
+	// the webhook may be changed or deleted in between
+	w, err := s.rp.FindByID(d.WebhookID)
+	if err != nil {
+		if err = s.rp.DeletePending(d.ID); err != nil {
+			s.logger.Error("webhooks: drop the delivery", "delivery_id", d.ID, "error", err)
+		}
+		return
+	}
+
+	d.Attempts++
+	d.Timestamp = time.Now().UTC()
+	d.StatusCode, d.Error = s.post(w, d)
+	d.Delivered = d.Error == ""
+	if _, err = s.rp.SaveDelivery(d); err != nil {
+		s.logger.Error("webhooks: save the delivery", "delivery_id", d.ID, "error", err)
+	}
+
+	switch {
+	case d.Delivered:
+	case d.Attempts < s.maxAttempts:
+		d.NextAttempt = d.Timestamp.Add(s.backoff << (d.Attempts - 1))
+		if err = s.rp.SavePending(d); err != nil {
+			s.logger.Error("webhooks: queue the delivery", "delivery_id", d.ID, "error", err)
+		}
+		return
+	default:
+		s.logger.Warn("webhooks: delivery failed for good", "delivery_id", d.ID, "webhook_id", d.WebhookID, "event_id", d.EventID, "error", d.Error)
+		if err = s.dl.Append(d); err != nil {
+			s.logger.Error("webhooks: append to the dead-letter queue", "delivery_id", d.ID, "error", err)
+			return
+		}
+	}
+	if err = s.rp.DeletePending(d.ID); err != nil {
+		s.logger.Error("webhooks: drop the delivery", "delivery_id", d.ID, "error", err)
+	}
+}
+
+// post is a method that makes an attempt to post an event to a webhook, signed with its secret
+func (s *WebhookDefault) post(w internal.Webhook, d internal.WebhookDelivery) (statusCode int, errMessage string) {
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err.Error()
+	}
+	mac := hmac.New(sha256.New, []byte(w.Secret))
+	mac.Write(d.Payload)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", d.Event)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatInt(d.EventID, 10))
+	req.Header.Set("X-Webhook-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return 0, err.Error()
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64*1024))
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return res.StatusCode, res.Status
+	}
+	return res.StatusCode, ""
+}
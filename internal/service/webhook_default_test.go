@@ -0,0 +1,233 @@
+package service_test
+
+import (
+	"app/internal"
+	"app/internal/repository"
+	"app/internal/service"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newWebhookService is a function that returns a webhook service of the default tenant saving to a directory,
+// with its change feed and the repositories of its webhooks and dead letters
+func newWebhookService(t *testing.T, dir string) (*service.WebhookDefault, internal.VehicleEventRepository, *repository.WebhookFile, *repository.WebhookDeadLetterFile) {
+	t.Helper()
+	rp, err := repository.NewWebhookFile(repository.NewWebhookMap(10), filepath.Join(dir, "webhooks.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dl, err := repository.NewWebhookDeadLetterFile(filepath.Join(dir, "dead_letters.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev := repository.NewVehicleEventRing(16, 16)
+	svEvent := service.NewVehicleEventDefault(map[string]internal.VehicleEventRepository{internal.DefaultTenant: ev})
+	sv := service.NewWebhookDefault(rp, dl, svEvent, &http.Client{Timeout: time.Second}, 3, 10*time.Millisecond, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	return sv, ev, rp, dl
+}
+
+// receiver is a function that returns a server answering the status codes in turn, the last one for ever,
+// and a channel with the bodies of the requests correctly signed
+func receiver(t *testing.T, secret string, statuses ...int) (*httptest.Server, <-chan []byte) {
+	t.Helper()
+	bodies := make(chan []byte, 16)
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		if r.Header.Get("X-Webhook-Signature") == "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+			bodies <- body
+		}
+		w.WriteHeader(statuses[min(int(calls.Add(1)), len(statuses))-1])
+	}))
+	t.Cleanup(srv.Close)
+	return srv, bodies
+}
+
+// wait is a function that returns the next value of a channel, failing after a second
+func wait[T any](t *testing.T, ch <-chan T) T {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	panic("unreachable")
+}
+
+func TestWebhookDefault_Deliver(t *testing.T) {
+	sv, ev, rp, dl := newWebhookService(t, t.TempDir())
+	srv, bodies := receiver(t, "secret", http.StatusInternalServerError, http.StatusOK)
+	// the receiver is local, so it is created bypassing the validation of the url
+	w, err := rp.Create(internal.Webhook{Tenant: internal.DefaultTenant, URL: srv.URL, Secret: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go sv.Dispatch(ctx, internal.DefaultTenant)
+	go sv.Deliver(ctx)
+	time.Sleep(10 * time.Millisecond)
+
+	v := internal.Vehicle{Id: 1}
+	if err = ev.Publish(internal.VehicleEvent{Tenant: internal.DefaultTenant, Type: internal.AuditCreate, VehicleID: 1, After: &v}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the first attempt fails and the retry delivers it
+	wait(t, bodies)
+	wait(t, bodies)
+	time.Sleep(10 * time.Millisecond)
+	d, err := rp.FindDeliveries(w.ID)
+	if err != nil || len(d) != 1 || !d[0].Delivered || d[0].Attempts != 2 {
+		t.Fatalf("got %+v and %v, want one delivery delivered on the second attempt", d, err)
+	}
+	if pending, _ := rp.FindPending(); len(pending) != 0 {
+		t.Errorf("got %d deliveries pending, want 0", len(pending))
+	}
+	if _, err = dl.FindAll(); !errors.Is(err, internal.ErrWebhookDeliveriesNotFound) {
+		t.Errorf("got %v, want an empty dead-letter queue", err)
+	}
+}
+
+func TestWebhookDefault_DeliverDeadLetter(t *testing.T) {
+	sv, ev, rp, dl := newWebhookService(t, t.TempDir())
+	srv, bodies := receiver(t, "secret", http.StatusInternalServerError)
+	if _, err := rp.Create(internal.Webhook{Tenant: internal.DefaultTenant, URL: srv.URL, Secret: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go sv.Dispatch(ctx, internal.DefaultTenant)
+	go sv.Deliver(ctx)
+	time.Sleep(10 * time.Millisecond)
+
+	v := internal.Vehicle{Id: 1}
+	if err := ev.Publish(internal.VehicleEvent{Tenant: internal.DefaultTenant, Type: internal.AuditCreate, VehicleID: 1, After: &v}); err != nil {
+		t.Fatal(err)
+	}
+
+	// every attempt fails, so the delivery goes to the dead-letter queue
+	for i := 0; i < 3; i++ {
+		wait(t, bodies)
+	}
+	time.Sleep(10 * time.Millisecond)
+	d, err := dl.FindAll()
+	if err != nil || len(d) != 1 || d[0].Attempts != 3 || d[0].StatusCode != http.StatusInternalServerError {
+		t.Fatalf("got %+v and %v, want one delivery failed after 3 attempts", d, err)
+	}
+}
+
+func TestWebhookDefault_DeliverAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	srv, bodies := receiver(t, "secret", http.StatusOK)
+
+	// a delivery pending is left by the previous run
+	_, _, rp, _ := newWebhookService(t, dir)
+	w, err := rp.Create(internal.Webhook{Tenant: internal.DefaultTenant, URL: srv.URL, Secret: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := internal.WebhookDelivery{ID: 1, Tenant: w.Tenant, WebhookID: w.ID, EventID: 7, Payload: []byte(`{"id":7}`), Attempts: 1, NextAttempt: time.Now()}
+	if err = rp.SavePending(d); err != nil {
+		t.Fatal(err)
+	}
+
+	// the next run delivers it
+	sv, _, rp, _ := newWebhookService(t, dir)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go sv.Deliver(ctx)
+	if body := wait(t, bodies); string(body) != `{"id":7}` {
+		t.Fatalf("got %s, want the payload of the delivery pending", body)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if pending, _ := rp.FindPending(); len(pending) != 0 {
+		t.Errorf("got %d deliveries pending, want 0", len(pending))
+	}
+}
+
+func TestWebhookDefault_DeliverStops(t *testing.T) {
+	dir := t.TempDir()
+	// the receiver holds the attempt until released
+	release := make(chan struct{})
+	received := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		<-release
+	}))
+	t.Cleanup(srv.Close)
+
+	sv, _, rp, _ := newWebhookService(t, dir)
+	w, err := rp.Create(internal.Webhook{Tenant: internal.DefaultTenant, URL: srv.URL, Secret: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := internal.WebhookDelivery{ID: 1, Tenant: w.Tenant, WebhookID: w.ID, EventID: 7, Payload: []byte(`{"id":7}`), NextAttempt: time.Now()}
+	if err = rp.SavePending(d); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		sv.Deliver(ctx)
+		close(stopped)
+	}()
+	wait(t, received)
+
+	// the deliverer waits for the attempt in flight before it returns
+	cancel()
+	select {
+	case <-stopped:
+		t.Fatal("the deliverer returned with an attempt in flight")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	wait(t, stopped)
+}
+
+func TestWebhookDefault_Create(t *testing.T) {
+	sv, _, _, _ := newWebhookService(t, t.TempDir())
+
+	// the urls of the network of the server are rejected
+	for _, url := range []string{
+		"http://127.0.0.1/hook",
+		"http://localhost:8080/hook",
+		"http://10.0.0.1/hook",
+		"http://192.168.1.1/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://metadata.google.internal/computeMetadata",
+		"http://[::1]/hook",
+		"http://[fe80::1]/hook",
+	} {
+		if _, err := sv.Create(internal.Webhook{Tenant: internal.DefaultTenant, URL: url}); !errors.Is(err, internal.ErrFieldsMissing) {
+			t.Errorf("%s: got %v, want ErrFieldsMissing", url, err)
+		}
+	}
+	if _, err := sv.Create(internal.Webhook{Tenant: internal.DefaultTenant, URL: "https://hooks.example.com/vehicles"}); err != nil {
+		t.Errorf("got %v, want a public url accepted", err)
+	}
+}
+
+func TestNewWebhookClient(t *testing.T) {
+	srv, _ := receiver(t, "secret", http.StatusOK)
+
+	// a public name may resolve to the network of the server, so the client refuses to connect to it
+	if _, err := service.NewWebhookClient(time.Second).Post(srv.URL, "application/json", nil); err == nil {
+		t.Fatal("got nil, want the local address refused")
+	}
+}
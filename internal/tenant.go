@@ -0,0 +1,16 @@
+package internal
+
+// DefaultTenant is the tenant of the requests that name none, and of every vehicle of a single-tenant deployment
+const DefaultTenant = "default"
+
+// VehicleTenantService is an interface that represents the vehicle services of the tenants, each one isolated
+// from the others: its own vehicles, ids, trash, history and snapshots
+type VehicleTenantService interface {
+	// Tenants is a method that returns the ids of the tenants, sorted
+	Tenants() (tenants []string)
+	// Tenant is a method that returns the vehicle service of a tenant
+	Tenant(id string) (sv VehicleService, err error)
+}
+
+// ErrTenantNotFound is the error returned when a tenant does not exist
+var ErrTenantNotFound = NewError("tenant_not_found")
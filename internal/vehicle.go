@@ -1,6 +1,8 @@
 package internal
 
-import "errors"
+import (
+	"time"
+)
 
 // Dimensions is a struct that represents a dimension in 3d
 type Dimensions struct {
@@ -42,25 +44,42 @@ type VehicleAttributes struct {
 type Vehicle struct {
 	// Id is the unique identifier of the vehicle
 	Id int
+	// Version is the number of writes of the vehicle, set by the repository
+	Version int
+	// UID is the string identifier of the vehicle, a UUIDv7 or ULID allocated by the repository with those strategies
+	UID string
 
 	// VehicleAttribue is the attributes of a vehicle
 	VehicleAttributes
 }
 
+// TrashedVehicle is a struct that represents a vehicle deleted that can still be restored
+type TrashedVehicle struct {
+	// Vehicle is the vehicle as it was when deleted
+	Vehicle
+	// DeletedAt is the moment the vehicle was deleted
+	DeletedAt time.Time
+}
+
 // Var for different types of errors and messages
 var (
 	//messages
-	MesgVehicleCreated      = "201 Created: Vehículo creado exitosamente."
-	MesgVehicleUpdatedSpeed = "200 OK: Velocidad del vehículo actualizada exitosamente."
-	MesgVehicleDeleted      = "204 No Content: Vehículo eliminado exitosamente."
-	MesgVehicleUpdateFuel   = "200 OK: Tipo de combustible del vehículo actualizado exitosamente."
+	MesgVehicleCreated      = "vehicle_created"
+	MesgVehicleUpdatedSpeed = "vehicle_speed_updated"
+	MesgVehicleUpdateFuel   = "vehicle_fuel_updated"
+	MesgVehicleRestored     = "vehicle_restored"
 
 	// errors
-	ErrVehicleAlreadyExists          = errors.New("409 Conflict: Identificador del vehículo ya existente.")
-	ErrFieldsMissing                 = errors.New("400 Bad Request: Datos del vehículo mal formados o incompletos.")
-	ErrVehicleNotFound               = errors.New("404 Not Found: No se encontraron vehículos con esos criterios.")
-	ErrVehicleNotFoundByBrand        = errors.New("404 Not Found: No se encontraron vehículos de esa marca.")
-	ErrVehicleNotFoundByTransmission = errors.New("404 Not Found: No se encontraron vehiculos con ese tipo de transmisión.")
-	ErrVehicleNotFoundByDimensions   = errors.New("404 Not Found: No se encontraron vehículos con esas dimensiones.")
-	ErrVehicleNotFoundByWeight       = errors.New("404 Not Found: No se encontraron vehículos con ese peso.")
+	ErrVehicleAlreadyExists          = NewError("vehicle_already_exists")
+	ErrFieldsMissing                 = NewError("fields_missing")
+	ErrVehicleNotFound               = NewError("vehicle_not_found")
+	ErrVehicleNotFoundByBrand        = NewError("vehicle_not_found_by_brand")
+	ErrVehicleNotFoundByTransmission = NewError("vehicle_not_found_by_transmission")
+	ErrVehicleNotFoundByDimensions   = NewError("vehicle_not_found_by_dimensions")
+	ErrVehicleNotFoundByWeight       = NewError("vehicle_not_found_by_weight")
+	ErrVehicleVersionMismatch        = NewError("vehicle_version_mismatch")
+	ErrVehicleIDAssigned             = NewError("vehicle_id_assigned")
+	ErrInvalidYearRange              = NewError("invalid_year_range")
+	ErrInvalidSpeed                  = NewError("invalid_speed")
+	ErrInternal                      = NewError("internal_error")
 )
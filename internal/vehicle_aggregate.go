@@ -0,0 +1,165 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VehicleFilter is a struct that represents optional criteria a vehicle has to match, zero values match any vehicle
+type VehicleFilter struct {
+	// Brand is the brand of the vehicle
+	Brand string
+	// Model is the model of the vehicle
+	Model string
+	// Color is the color of the vehicle
+	Color string
+	// FuelType is the fuel type of the vehicle
+	FuelType string
+	// Transmission is the transmission of the vehicle
+	Transmission string
+	// MinYear is the minimum fabrication year of the vehicle
+	MinYear int
+	// MaxYear is the maximum fabrication year of the vehicle
+	MaxYear int
+	// MinWeight is the minimum weight of the vehicle
+	MinWeight float64
+	// MaxWeight is the maximum weight of the vehicle
+	MaxWeight float64
+	// MinLength is the minimum length of the vehicle
+	MinLength float64
+	// MaxLength is the maximum length of the vehicle
+	MaxLength float64
+	// MinWidth is the minimum width of the vehicle
+	MinWidth float64
+	// MaxWidth is the maximum width of the vehicle
+	MaxWidth float64
+}
+
+// Match is a method that reports whether a vehicle matches the filter, comparing categorical attributes by SameValue
+func (f VehicleFilter) Match(v Vehicle) bool {
+	switch {
+	case f.Brand != "" && !SameValue("brand", v.Brand, f.Brand),
+		f.Model != "" && !SameValue("model", v.Model, f.Model),
+		f.Color != "" && !SameValue("color", v.Color, f.Color),
+		f.FuelType != "" && !SameValue("fuel_type", v.FuelType, f.FuelType),
+		f.Transmission != "" && !SameValue("transmission", v.Transmission, f.Transmission),
+		f.MinYear != 0 && v.FabricationYear < f.MinYear,
+		f.MaxYear != 0 && v.FabricationYear > f.MaxYear,
+		f.MinWeight != 0 && v.Weight < f.MinWeight,
+		f.MaxWeight != 0 && v.Weight > f.MaxWeight,
+		f.MinLength != 0 && v.Length < f.MinLength,
+		f.MaxLength != 0 && v.Length > f.MaxLength,
+		f.MinWidth != 0 && v.Width < f.MinWidth,
+		f.MaxWidth != 0 && v.Width > f.MaxWidth:
+		return false
+	}
+	return true
+}
+
+// Metric is a struct that represents an aggregation function over a numeric field
+type Metric struct {
+	// Func is the aggregation function: count, sum, avg, min, max or pNN (a percentile between 1 and 99)
+	Func string
+	// Field is the numeric field the function is applied to, empty for count
+	Field string
+}
+
+// String is a method that returns the metric as written in a query, e.g. avg(max_speed)
+func (m Metric) String() string {
+	if m.Field == "" {
+		return m.Func
+	}
+	return fmt.Sprintf("%s(%s)", m.Func, m.Field)
+}
+
+// Percentile is a method that returns the percentile of a pNN metric and whether the metric is one
+func (m Metric) Percentile() (p int, ok bool) {
+	if !strings.HasPrefix(m.Func, "p") {
+		return
+	}
+	p, err := strconv.Atoi(m.Func[1:])
+	ok = err == nil && p > 0 && p < 100
+	return
+}
+
+// AggregateQuery is a struct that represents an aggregation over the vehicles
+type AggregateQuery struct {
+	// GroupBy is the list of categorical fields the vehicles are grouped by, none for a single group
+	GroupBy []string
+	// Metrics is the list of metrics computed for every group
+	Metrics []Metric
+	// Filter is the criteria the vehicles have to match to be aggregated
+	Filter VehicleFilter
+	// AsOf is the moment of the fleet the vehicles are aggregated at, zero for now
+	AsOf time.Time
+}
+
+// AggregateGroup is a struct that represents the result of an aggregation for a group
+type AggregateGroup struct {
+	// Key is the value of each group by field for the group
+	Key map[string]string
+	// Values is the value of each metric for the group, keyed by Metric.String
+	Values map[string]float64
+}
+
+// VehicleNumericFields is a map of the numeric fields of a vehicle that can be aggregated, by JSON name
+var VehicleNumericFields = map[string]func(v Vehicle) float64{
+	"id":         func(v Vehicle) float64 { return float64(v.Id) },
+	"year":       func(v Vehicle) float64 { return float64(v.FabricationYear) },
+	"passengers": func(v Vehicle) float64 { return float64(v.Capacity) },
+	"capacity":   func(v Vehicle) float64 { return float64(v.Capacity) },
+	"max_speed":  func(v Vehicle) float64 { return v.MaxSpeed },
+	"weight":     func(v Vehicle) float64 { return v.Weight },
+	"height":     func(v Vehicle) float64 { return v.Height },
+	"length":     func(v Vehicle) float64 { return v.Length },
+	"width":      func(v Vehicle) float64 { return v.Width },
+}
+
+// VehicleGroupFields is a map of the fields of a vehicle that can be used to group, by JSON name
+var VehicleGroupFields = map[string]func(v Vehicle) string{
+	"brand":        func(v Vehicle) string { return v.Brand },
+	"model":        func(v Vehicle) string { return v.Model },
+	"color":        func(v Vehicle) string { return v.Color },
+	"fuel_type":    func(v Vehicle) string { return v.FuelType },
+	"transmission": func(v Vehicle) string { return v.Transmission },
+	"year":         func(v Vehicle) string { return strconv.Itoa(v.FabricationYear) },
+}
+
+// ErrInvalidAggregate is the error returned when an aggregation uses unknown fields or functions
+var ErrInvalidAggregate = NewError("invalid_aggregate")
+
+// Validate is a method that checks that the query only uses known fields and functions
+func (q AggregateQuery) Validate() (err error) {
+	for _, field := range q.GroupBy {
+		if _, ok := VehicleGroupFields[field]; !ok {
+			err = errors.Join(ErrInvalidAggregate, NewError("aggregate_group_by", field))
+			return
+		}
+	}
+	if len(q.Metrics) == 0 {
+		err = errors.Join(ErrInvalidAggregate, NewError("aggregate_no_metrics"))
+		return
+	}
+	for _, m := range q.Metrics {
+		if m.Func == "count" && m.Field == "" {
+			continue
+		}
+		if _, ok := VehicleNumericFields[m.Field]; !ok {
+			err = errors.Join(ErrInvalidAggregate, NewError("aggregate_metric_field", m))
+			return
+		}
+		if _, ok := m.Percentile(); ok {
+			continue
+		}
+		switch m.Func {
+		case "count", "sum", "avg", "min", "max":
+		default:
+			err = errors.Join(ErrInvalidAggregate, NewError("aggregate_metric_func", m))
+			return
+		}
+	}
+	return
+}
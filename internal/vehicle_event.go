@@ -0,0 +1,50 @@
+package internal
+
+import (
+	"time"
+)
+
+// VehicleEvent is a struct that represents a write made on a vehicle, as published to the change feed
+type VehicleEvent struct {
+	// ID is the position of the event in the feed, increasing from 1
+	ID int64
+	// Tenant is the tenant of the vehicle written
+	Tenant string
+	// Type is the kind of write, one of the audit operations
+	Type string
+	// Timestamp is the moment of the write
+	Timestamp time.Time
+	// VehicleID is the id of the vehicle written
+	VehicleID int
+	// Before is the vehicle before the write, nil for a create
+	Before *Vehicle
+	// After is the vehicle after the write, nil for a delete
+	After *Vehicle
+}
+
+// Match is a method that reports whether the vehicle before or after the event matches a filter
+func (e VehicleEvent) Match(f VehicleFilter) bool {
+	return (e.Before != nil && f.Match(*e.Before)) || (e.After != nil && f.Match(*e.After))
+}
+
+// VehicleEventRepository is an interface that represents a bounded feed of vehicle events
+type VehicleEventRepository interface {
+	// Publish is a method that adds an event to the feed, assigning its id
+	Publish(e VehicleEvent) (err error)
+	// Subscribe is a method that returns the events of the feed after an id (0 for none) and a channel
+	// with the events published from then on, closed when the subscriber falls behind or cancel is called.
+	// Only the events match reports are returned and queued, every one when it is nil.
+	// It fails with ErrVehicleEventsExpired, still subscribing, when events after the id left the feed
+	Subscribe(after int64, match func(e VehicleEvent) bool) (backlog []VehicleEvent, events <-chan VehicleEvent, cancel func(), err error)
+}
+
+// VehicleEventService is an interface that represents a service of vehicle events
+type VehicleEventService interface {
+	// Subscribe is a method that returns the events of the feed of a tenant after an id (0 for none) and a channel
+	// with the events published from then on, closed when the subscriber falls behind or cancel is called.
+	// Only the events match reports are returned and queued, every one when it is nil
+	Subscribe(tenant string, after int64, match func(e VehicleEvent) bool) (backlog []VehicleEvent, events <-chan VehicleEvent, cancel func(), err error)
+}
+
+// ErrVehicleEventsExpired is the error returned when the events after an id are no longer in the feed
+var ErrVehicleEventsExpired = NewError("vehicle_events_expired")
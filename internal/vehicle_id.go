@@ -0,0 +1,9 @@
+package internal
+
+// VehicleSequence is an interface that represents a persistent sequence of vehicle ids, never handing out an id twice
+type VehicleSequence interface {
+	// Next is a method that returns the next id of the sequence
+	Next() (id int, err error)
+	// Advance is a method that moves the sequence past an id taken elsewhere, such as one chosen by a client
+	Advance(id int) (err error)
+}
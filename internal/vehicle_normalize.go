@@ -0,0 +1,62 @@
+package internal
+
+import (
+	"app/platform/tools"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Synonyms is the canonical value of the alternative spellings of an attribute, by attribute and folded spelling
+var Synonyms = map[string]map[string]string{
+	"fuel_type": {
+		"gas":        "gasoline",
+		"petrol":     "gasoline",
+		"bio-diesel": "biodiesel",
+		"bio diesel": "biodiesel",
+	},
+	"transmission": {
+		"auto":           "automatic",
+		"semi-auto":      "semi-automatic",
+		"semi auto":      "semi-automatic",
+		"semiautomatic":  "semi-automatic",
+		"semi automatic": "semi-automatic",
+		"stick":          "manual",
+	},
+}
+
+// Canonical is a function that returns the canonical form of a value of a categorical attribute:
+// trimmed, with single spaces and, for fuel_type and transmission, folded and without synonyms
+func Canonical(attribute string, value string) string {
+	value = strings.Join(strings.Fields(value), " ")
+	switch attribute {
+	case "fuel_type", "transmission":
+		value = tools.Fold(value)
+		if canonical, ok := Synonyms[attribute][value]; ok {
+			value = canonical
+		}
+	case "color":
+		value = tools.Fold(value)
+		// the first letter may take more than one byte
+		if first, size := utf8.DecodeRuneInString(value); size > 0 {
+			value = string(unicode.ToUpper(first)) + value[size:]
+		}
+	}
+	return value
+}
+
+// SameValue is a function that reports whether two values of a categorical attribute are the same
+// once canonical, ignoring case and accents
+func SameValue(attribute string, a string, b string) bool {
+	return tools.Fold(Canonical(attribute, a)) == tools.Fold(Canonical(attribute, b))
+}
+
+// Normalize is a method that returns the vehicle with its categorical attributes in canonical form
+func (v Vehicle) Normalize() Vehicle {
+	v.Brand = Canonical("brand", v.Brand)
+	v.Model = Canonical("model", v.Model)
+	v.Color = Canonical("color", v.Color)
+	v.FuelType = Canonical("fuel_type", v.FuelType)
+	v.Transmission = Canonical("transmission", v.Transmission)
+	return v
+}
@@ -1,25 +1,46 @@
 package internal
 
+import "time"
+
 // VehicleRepository is an interface that represents a vehicle repository
 type VehicleRepository interface {
 	// FindAll is a method that returns a map of all vehicles
 	FindAll() (v map[int]Vehicle, err error)
-	// Create is a method that creates a vehicle
-	Create(v Vehicle) (err error)
+	// FindAsOf is a method that returns a map of all vehicles as they were at a moment
+	FindAsOf(at time.Time) (v map[int]Vehicle, err error)
+	// FindByID is a method that returns a vehicle by its id
+	FindByID(id int) (v Vehicle, err error)
+	// Replace is a method that replaces every attribute of a vehicle, if its version is the given one (0 for any),
+	// and returns it as stored
+	Replace(v Vehicle, version int) (replaced Vehicle, err error)
+	// Create is a method that creates a vehicle, allocating its id when 0, and returns it as stored
+	Create(v Vehicle) (created Vehicle, err error)
 	// GetByColorAndYear is a method that returns a map of vehicles by color and year
 	GetByColorAndYear(color string, year int) (v map[int]Vehicle, err error)
 	// GetByBrandAndYearRange is a method that returns a map of vehicles by brand and year range
 	GetByBrandAndYearRange(brand string, startYear int, finishYear int) (v map[int]Vehicle, err error)
 	// GetAverageSpeedByBrand is a method that returns the average speed of vehicles by brand
 	GetAverageSpeedByBrand(brand string) (averageSpeed float64, err error)
-	// CreateMultiple is a method that creates multiple vehicles
-	CreateMultiple(v []Vehicle) (err error)
+	// CreateMultiple is a method that creates multiple vehicles, allocating the ids that are 0, and returns them as stored
+	CreateMultiple(v []Vehicle) (created []Vehicle, err error)
 	// Update is a method that updates tany field of a vehicle
-	Update(id int, fields map[string]any) (err error)
+	// with version 0 the update is unconditional, otherwise it fails with ErrVehicleVersionMismatch if the version differs.
+	// It returns the vehicle as stored
+	Update(id int, fields map[string]any, version int) (updated Vehicle, err error)
 	// GetByFuelType is a method that returns a map of vehicles by fuel type
 	GetByFuelType(fuelType string) (v map[int]Vehicle, err error)
-	// Delete is a method that deletes a vehicle
-	Delete(id int) (err error)
+	// Delete is a method that moves a vehicle to the trash, if its version is the given one (0 for any)
+	Delete(id int, version int) (err error)
+	// FindTrash is a method that returns a map of the vehicles in the trash
+	FindTrash() (v map[int]TrashedVehicle, err error)
+	// Restore is a method that moves a vehicle back from the trash, and returns it as stored
+	Restore(id int) (restored Vehicle, err error)
+	// Purge is a method that deletes a vehicle for good, from the store if its version is the given one (0 for any) or from the trash
+	Purge(id int, version int) (err error)
+	// PurgeTrash is a method that deletes for good the vehicles moved to the trash before a moment
+	PurgeTrash(before time.Time) (ids []int, err error)
+	// PurgeHistory is a method that forgets the revisions superseded before a moment, which becomes the start of the history
+	PurgeHistory(before time.Time) (err error)
 	// GetByTransmission is a method that returns a map of vehicles by transmission type
 	GetByTransmission(transmission string) (v map[int]Vehicle, err error)
 	// GetAverageCapacityByBrand is a method that returns the average capacity of vehicles by brand
@@ -28,4 +49,16 @@ type VehicleRepository interface {
 	GetByDimensions(dimensions map[string]float64) (v map[int]Vehicle, err error)
 	// GetByWeight is a method that returns a map of vehicles by weight
 	GetByWeight(weight map[string]float64) (v map[int]Vehicle, err error)
+	// Aggregate is a method that returns the metrics of the vehicles matching a filter, by group
+	Aggregate(q AggregateQuery) (groups []AggregateGroup, err error)
+	// Summary is a method that returns the figures of the whole fleet
+	Summary() (s FleetSummary, err error)
+	// Search is a method that returns the vehicles matching every word of a text query, most relevant first
+	Search(query string) (results []SearchResult, err error)
+	// CreateSnapshot is a method that saves under a name the fleet as it was at a moment
+	CreateSnapshot(name string, at time.Time) (snapshot Snapshot, err error)
+	// FindSnapshots is a method that returns the snapshots saved, oldest first
+	FindSnapshots() (snapshots []Snapshot, err error)
+	// FindSnapshot is a method that returns a snapshot by its name
+	FindSnapshot(name string) (snapshot Snapshot, err error)
 }
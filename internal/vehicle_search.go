@@ -0,0 +1,9 @@
+package internal
+
+// SearchResult is a struct that represents a vehicle found by a search and its relevance
+type SearchResult struct {
+	// Vehicle is the vehicle found
+	Vehicle
+	// Score is the relevance of the vehicle for the search, higher is better
+	Score float64
+}
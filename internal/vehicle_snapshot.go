@@ -0,0 +1,23 @@
+package internal
+
+import (
+	"time"
+)
+
+// Snapshot is a struct that represents the fleet as it was at a moment, saved under a name
+type Snapshot struct {
+	// Name is the name the snapshot is saved under
+	Name string
+	// CreatedAt is the moment the snapshot was created
+	CreatedAt time.Time
+	// AsOf is the moment of the fleet the snapshot holds
+	AsOf time.Time
+	// Vehicles is the map of vehicles of the fleet at AsOf
+	Vehicles map[int]Vehicle
+}
+
+var (
+	ErrSnapshotNotFound      = NewError("snapshot_not_found")
+	ErrSnapshotAlreadyExists = NewError("snapshot_already_exists")
+	ErrHistoryNotKept        = NewError("history_not_kept")
+)
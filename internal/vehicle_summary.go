@@ -0,0 +1,45 @@
+package internal
+
+import "maps"
+
+// FleetSummary is a struct that represents the figures of the whole fleet
+type FleetSummary struct {
+	// Total is the number of vehicles
+	Total int
+	// ByBrand is the number of vehicles by brand
+	ByBrand map[string]int
+	// ByFuelType is the number of vehicles by fuel type
+	ByFuelType map[string]int
+	// ByTransmission is the number of vehicles by transmission
+	ByTransmission map[string]int
+	// ByColor is the number of vehicles by color
+	ByColor map[string]int
+	// ByDecade is the number of vehicles by fabrication decade, e.g. 1990 for 1990-1999
+	ByDecade map[int]int
+	// Fastest is the vehicle with the highest maximum speed, nil for an empty fleet
+	Fastest *Vehicle
+	// Heaviest is the vehicle with the highest weight, nil for an empty fleet
+	Heaviest *Vehicle
+	// AverageCapacity is the average capacity of people of the vehicles
+	AverageCapacity float64
+	// TotalCapacity is the number of passenger seats of the fleet
+	TotalCapacity int
+}
+
+// Clone is a method that returns a copy of the summary that shares neither maps nor vehicles with it
+func (s FleetSummary) Clone() FleetSummary {
+	s.ByBrand = maps.Clone(s.ByBrand)
+	s.ByFuelType = maps.Clone(s.ByFuelType)
+	s.ByTransmission = maps.Clone(s.ByTransmission)
+	s.ByColor = maps.Clone(s.ByColor)
+	s.ByDecade = maps.Clone(s.ByDecade)
+	if s.Fastest != nil {
+		v := *s.Fastest
+		s.Fastest = &v
+	}
+	if s.Heaviest != nil {
+		v := *s.Heaviest
+		s.Heaviest = &v
+	}
+	return s
+}
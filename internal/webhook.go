@@ -0,0 +1,160 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Webhook is a struct that represents a subscription of a downstream system to the writes on vehicles
+type Webhook struct {
+	// ID is the identifier of the webhook
+	ID int
+	// Tenant is the tenant whose writes the webhook receives
+	Tenant string
+	// URL is the address the events are posted to
+	URL string
+	// Secret is the key the payloads are signed with, HMAC-SHA256
+	Secret string
+	// Events is the list of operations the webhook receives, empty for all of them
+	Events []string
+	// CreatedAt is the moment the webhook was created
+	CreatedAt time.Time
+}
+
+// Validate is a method that validates the url and the events of the webhook, the url must not point to
+// the network of the server: its host is neither a private, loopback or link-local address nor a metadata name
+func (w Webhook) Validate() (err error) {
+	u, e := url.Parse(w.URL)
+	if e != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		err = errors.Join(ErrFieldsMissing, NewError("webhook_url"))
+		return
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if ip := net.ParseIP(host); (ip != nil && !PublicIP(ip)) || host == "localhost" || strings.HasSuffix(host, ".localhost") ||
+		host == "metadata" || host == "metadata.google.internal" {
+		err = errors.Join(ErrFieldsMissing, NewError("webhook_url_private"))
+		return
+	}
+	for _, event := range w.Events {
+		switch event {
+		case AuditCreate, AuditUpdate, AuditReplace, AuditDelete, AuditRestore, AuditPurge:
+		default:
+			err = errors.Join(ErrFieldsMissing, NewError("webhook_event", event))
+			return
+		}
+	}
+	return
+}
+
+// sharedAddressSpace is the range of the carrier-grade NAT addresses, RFC 6598
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// PublicIP is a function that reports whether an address may be reached by the webhooks: it is neither private,
+// loopback, link-local, which holds the metadata services of the clouds, multicast nor unspecified
+func PublicIP(ip net.IP) bool {
+	return !(ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || sharedAddressSpace.Contains(ip))
+}
+
+// Wants is a method that reports whether the webhook receives an operation
+func (w Webhook) Wants(event string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is a struct that represents the delivery of an event to a webhook, with all its attempts
+type WebhookDelivery struct {
+	// ID is the identifier of the delivery
+	ID int64 `json:"id"`
+	// Tenant is the tenant of the webhook
+	Tenant string `json:"tenant,omitempty"`
+	// WebhookID is the id of the webhook the event is delivered to
+	WebhookID int `json:"webhook_id"`
+	// EventID is the id of the event in the change feed
+	EventID int64 `json:"event_id"`
+	// Event is the operation of the event
+	Event string `json:"event"`
+	// VehicleID is the id of the vehicle written
+	VehicleID int `json:"vehicle_id"`
+	// Payload is the body posted
+	Payload json.RawMessage `json:"payload"`
+	// Attempts is the number of attempts made
+	Attempts int `json:"attempts"`
+	// StatusCode is the status code of the last attempt, 0 when no response was received
+	StatusCode int `json:"status_code"`
+	// Error is the error of the last attempt, empty when delivered
+	Error string `json:"error,omitempty"`
+	// Delivered is whether a 2xx response was received
+	Delivered bool `json:"delivered"`
+	// Timestamp is the moment of the last attempt
+	Timestamp time.Time `json:"timestamp"`
+	// NextAttempt is the moment of the next attempt of a delivery pending, zero for none
+	NextAttempt time.Time `json:"-"`
+}
+
+// WebhookRepository is an interface that represents a repository of webhooks and their deliveries
+type WebhookRepository interface {
+	// FindAll is a method that returns the webhooks, by id
+	FindAll() (w []Webhook, err error)
+	// FindByID is a method that returns a webhook by its id
+	FindByID(id int) (w Webhook, err error)
+	// Create is a method that creates a webhook, assigning its id
+	Create(w Webhook) (created Webhook, err error)
+	// Update is a method that replaces the url, secret and events of a webhook
+	Update(w Webhook) (err error)
+	// Delete is a method that deletes a webhook and its deliveries
+	Delete(id int) (err error)
+	// SaveDelivery is a method that adds or replaces a delivery of the log, assigning its id when 0
+	SaveDelivery(d WebhookDelivery) (saved WebhookDelivery, err error)
+	// FindDeliveries is a method that returns the deliveries of a webhook, oldest first
+	FindDeliveries(webhookID int) (d []WebhookDelivery, err error)
+	// SavePending is a method that adds or replaces a delivery awaiting its next attempt, by its id
+	SavePending(d WebhookDelivery) (err error)
+	// DeletePending is a method that removes a delivery awaiting its next attempt, once delivered or given up
+	DeletePending(id int64) (err error)
+	// FindPending is a method that returns the deliveries awaiting their next attempt, the soonest first
+	FindPending() (d []WebhookDelivery, err error)
+}
+
+// WebhookDeadLetterRepository is an interface that represents a persisted queue of the deliveries that failed for good
+type WebhookDeadLetterRepository interface {
+	// Append is a method that adds a delivery to the queue
+	Append(d WebhookDelivery) (err error)
+	// FindAll is a method that returns the deliveries of the queue, oldest first
+	FindAll() (d []WebhookDelivery, err error)
+}
+
+// WebhookService is an interface that represents a service of webhooks, each one seen by its tenant only
+type WebhookService interface {
+	// FindAll is a method that returns the webhooks of a tenant, by id
+	FindAll(tenant string) (w []Webhook, err error)
+	// FindByID is a method that returns a webhook of a tenant by its id
+	FindByID(tenant string, id int) (w Webhook, err error)
+	// Create is a method that creates a webhook, generating its secret when empty
+	Create(w Webhook) (created Webhook, err error)
+	// Update is a method that replaces the url, secret and events of a webhook of its tenant
+	Update(w Webhook) (err error)
+	// Delete is a method that deletes a webhook of a tenant and its deliveries
+	Delete(tenant string, id int) (err error)
+	// FindDeliveries is a method that returns the deliveries of a webhook of a tenant, oldest first
+	FindDeliveries(tenant string, webhookID int) (d []WebhookDelivery, err error)
+	// FindDeadLetters is a method that returns the deliveries of a tenant that failed for good, oldest first
+	FindDeadLetters(tenant string) (d []WebhookDelivery, err error)
+}
+
+// Var for the errors of the webhooks
+var (
+	ErrWebhookNotFound           = NewError("webhook_not_found")
+	ErrWebhookDeliveriesNotFound = NewError("webhook_deliveries_not_found")
+)
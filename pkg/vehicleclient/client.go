@@ -0,0 +1,228 @@
+package vehicleclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Config is a struct that represents the configuration for Client
+type Config struct {
+	// BaseURL is the address of the vehicles API, e.g. http://localhost:8080
+	BaseURL string
+	// HTTPClient is the client used to send the requests
+	HTTPClient *http.Client
+	// MaxRetries is the number of times a failed idempotent request is retried
+	MaxRetries int
+	// RetryBackoff is the wait before the first retry, doubled on each attempt
+	RetryBackoff time.Duration
+	// APIKey is the key sent in the X-API-Key header, empty for none
+	APIKey string
+	// BearerToken is the token sent in the Authorization header, empty for none
+	BearerToken string
+	// TenantID is the tenant sent in the X-Tenant-ID header, empty for the one of the credentials or the default one
+	TenantID string
+}
+
+// NewClient is a function that returns a new instance of Client
+func NewClient(cfg *Config) *Client {
+	// default values
+	defaultConfig := &Config{
+		BaseURL:      "http://localhost:8080",
+		HTTPClient:   http.DefaultClient,
+		MaxRetries:   3,
+		RetryBackoff: 100 * time.Millisecond,
+	}
+	if cfg != nil {
+		if cfg.BaseURL != "" {
+			defaultConfig.BaseURL = cfg.BaseURL
+		}
+		if cfg.HTTPClient != nil {
+			defaultConfig.HTTPClient = cfg.HTTPClient
+		}
+		if cfg.MaxRetries > 0 {
+			defaultConfig.MaxRetries = cfg.MaxRetries
+		}
+		if cfg.RetryBackoff > 0 {
+			defaultConfig.RetryBackoff = cfg.RetryBackoff
+		}
+		defaultConfig.APIKey = cfg.APIKey
+		defaultConfig.BearerToken = cfg.BearerToken
+		defaultConfig.TenantID = cfg.TenantID
+	}
+
+	return &Client{
+		baseURL:      defaultConfig.BaseURL,
+		httpClient:   defaultConfig.HTTPClient,
+		maxRetries:   defaultConfig.MaxRetries,
+		retryBackoff: defaultConfig.RetryBackoff,
+		apiKey:       defaultConfig.APIKey,
+		bearerToken:  defaultConfig.BearerToken,
+		tenantID:     defaultConfig.TenantID,
+	}
+}
+
+// Client is a struct that represents a typed client for the vehicles API
+type Client struct {
+	// baseURL is the address of the vehicles API
+	baseURL string
+	// httpClient is the client used to send the requests
+	httpClient *http.Client
+	// maxRetries is the number of times a failed idempotent request is retried
+	maxRetries int
+	// retryBackoff is the wait before the first retry
+	retryBackoff time.Duration
+	// apiKey is the key sent in the X-API-Key header
+	apiKey string
+	// bearerToken is the token sent in the Authorization header
+	bearerToken string
+	// tenantID is the tenant sent in the X-Tenant-ID header
+	tenantID string
+}
+
+// envelope is a struct that represents the body returned by the API
+type envelope struct {
+	// Message is the message of the response
+	Message string `json:"message"`
+	// Code is the machine-readable code of an error response
+	Code string `json:"code"`
+	// Data is the payload of the response
+	Data json.RawMessage `json:"data"`
+}
+
+// do is a method that sends a request, retrying idempotent ones, and decodes the data into out
+func (c *Client) do(ctx context.Context, method string, path string, in any, out any) (err error) {
+	_, err = c.send(ctx, method, path, nil, in, out)
+	return
+}
+
+// send is a method that sends a request with some headers, retrying idempotent ones, decodes the data into out
+// and returns the headers of the response
+func (c *Client) send(ctx context.Context, method string, path string, header http.Header, in any, out any) (resHeader http.Header, err error) {
+	// request body
+	var body []byte
+	if in != nil {
+		body, err = json.Marshal(in)
+		if err != nil {
+			return
+		}
+	}
+
+	// POST is not idempotent, so it is sent only once
+	attempts := 1
+	if method != http.MethodPost {
+		attempts += c.maxRetries
+	}
+
+	var res *http.Response
+	backoff := c.retryBackoff
+	wait := backoff
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				err = ctx.Err()
+				return
+			case <-time.After(wait):
+			}
+			backoff *= 2
+			wait = backoff
+		}
+
+		var req *http.Request
+		req, err = http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(body))
+		if err != nil {
+			return
+		}
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		if in != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		c.authorize(req)
+
+		res, err = c.httpClient.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+		if !retryable(res.StatusCode) || attempt == attempts-1 {
+			break
+		}
+		// - the server may tell how long to wait before the next attempt
+		if after, ok := retryAfter(res.Header.Get("Retry-After"), time.Now()); ok {
+			wait = after
+		}
+		res.Body.Close()
+	}
+	if err != nil {
+		return
+	}
+	defer res.Body.Close()
+
+	// response
+	resHeader = res.Header
+	raw, err := io.ReadAll(res.Body)
+	if err != nil {
+		return
+	}
+	var env envelope
+	if len(raw) > 0 {
+		if e := json.Unmarshal(raw, &env); e != nil && res.StatusCode < 300 {
+			err = e
+			return
+		}
+	}
+	if res.StatusCode >= 300 {
+		err = newError(res.StatusCode, env.Message, env.Code)
+		return
+	}
+	if out != nil && len(env.Data) > 0 {
+		err = json.Unmarshal(env.Data, out)
+	}
+	return
+}
+
+// authorize is a method that sets the credentials and the tenant of a request, if any
+func (c *Client) authorize(req *http.Request) {
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+	if c.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.bearerToken)
+	}
+	if c.tenantID != "" {
+		req.Header.Set("X-Tenant-ID", c.tenantID)
+	}
+}
+
+// retryable is a function that reports whether a status code is worth retrying
+func retryable(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter is a function that returns the wait of a Retry-After header, in seconds or as an http date
+func retryAfter(value string, now time.Time) (wait time.Duration, ok bool) {
+	if value == "" {
+		return
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	at, err := http.ParseTime(value)
+	if err != nil {
+		return
+	}
+	return max(at.Sub(now), 0), true
+}
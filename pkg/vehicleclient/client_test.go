@@ -0,0 +1,79 @@
+package vehicleclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_Credentials(t *testing.T) {
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		w.Write([]byte(`{"message":"success","data":120}`))
+	}))
+	defer srv.Close()
+
+	cl := NewClient(&Config{BaseURL: srv.URL, APIKey: "key", BearerToken: "token", TenantID: "acme"})
+	if _, err := cl.GetAverageSpeedByBrand(context.Background(), "Ford"); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{"X-API-Key": "key", "Authorization": "Bearer token", "X-Tenant-ID": "acme"} {
+		if got := header.Get(name); got != want {
+			t.Errorf("%s: got %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestClient_RetryAfter(t *testing.T) {
+	// the backoff is far longer than the test, so only the Retry-After of the server lets the retry happen in time
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"message":"success","data":120}`))
+	}))
+	defer srv.Close()
+
+	cl := NewClient(&Config{BaseURL: srv.URL, MaxRetries: 1, RetryBackoff: time.Hour})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	speed, err := cl.GetAverageSpeedByBrand(ctx, "Ford")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if speed != 120 || attempts != 2 {
+		t.Errorf("got speed %v in %d attempts, want 120 in 2", speed, attempts)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name  string
+		value string
+		wait  time.Duration
+		ok    bool
+	}{
+		{name: "absent", value: ""},
+		{name: "seconds", value: "3", wait: 3 * time.Second, ok: true},
+		{name: "negative seconds", value: "-3"},
+		{name: "date", value: now.Add(time.Minute).Format(http.TimeFormat), wait: time.Minute, ok: true},
+		{name: "past date", value: now.Add(-time.Minute).Format(http.TimeFormat), wait: 0, ok: true},
+		{name: "malformed", value: "soon"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			wait, ok := retryAfter(c.value, now)
+			if wait != c.wait || ok != c.ok {
+				t.Errorf("got %v, %v, want %v, %v", wait, ok, c.wait, c.ok)
+			}
+		})
+	}
+}
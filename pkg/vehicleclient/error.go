@@ -0,0 +1,73 @@
+package vehicleclient
+
+import (
+	"app/internal"
+	"errors"
+	"net/http"
+)
+
+// Error is a struct that represents an error response of the API
+type Error struct {
+	// StatusCode is the http status code of the response
+	StatusCode int
+	// Message is the message returned by the API
+	Message string
+	// Code is the machine-readable code of the error returned by the API
+	Code string
+	// Err is the internal error the code corresponds to, if any
+	Err error
+}
+
+// Error is a method that returns the error message
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap is a method that returns the internal error so errors.Is works against the internal sentinels
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// sentinels is the list of internal errors the API may answer with
+var sentinels = []error{
+	internal.ErrVehicleAlreadyExists,
+	internal.ErrFieldsMissing,
+	internal.ErrVehicleNotFound,
+	internal.ErrVehicleNotFoundByBrand,
+	internal.ErrVehicleNotFoundByTransmission,
+	internal.ErrVehicleNotFoundByDimensions,
+	internal.ErrVehicleNotFoundByWeight,
+	internal.ErrVehicleVersionMismatch,
+	internal.ErrVehicleIDAssigned,
+	internal.ErrVehicleNotInCatalog,
+	internal.ErrInvalidYearRange,
+	internal.ErrInvalidSpeed,
+	internal.ErrIdempotencyKeyReused,
+	internal.ErrIdempotencyInProgress,
+	internal.ErrUnauthenticated,
+	internal.ErrForbidden,
+	internal.ErrTenantNotFound,
+	internal.ErrRateLimited,
+	internal.ErrQuotaExceeded,
+	internal.ErrInternal,
+}
+
+// newError is a function that builds an Error matching the code against the internal sentinels,
+// as the message is in the language of the request
+func newError(statusCode int, message string, code string) (err *Error) {
+	err = &Error{StatusCode: statusCode, Message: message, Code: code}
+	for _, sentinel := range sentinels {
+		var e *internal.Error
+		if errors.As(sentinel, &e) && e.Code == code {
+			err.Err = sentinel
+			return
+		}
+	}
+	return
+}
+
+// IsNotFound is a function that reports whether err is any of the not found errors of the API
+func IsNotFound(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
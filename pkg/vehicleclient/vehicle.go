@@ -0,0 +1,148 @@
+package vehicleclient
+
+import (
+	"app/internal/handler"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// FindAll is a method that returns a map of all vehicles
+func (c *Client) FindAll(ctx context.Context) (v map[int]handler.VehicleJSON, err error) {
+	err = c.do(ctx, http.MethodGet, "/vehicles", nil, &v)
+	return
+}
+
+// Create is a method that creates a vehicle and returns it as created
+func (c *Client) Create(ctx context.Context, v handler.VehicleJSON) (created handler.VehicleJSON, err error) {
+	err = c.do(ctx, http.MethodPost, "/vehicles", v, &created)
+	return
+}
+
+// GetByColorAndYear is a method that returns a map of vehicles by color and year
+func (c *Client) GetByColorAndYear(ctx context.Context, color string, year int) (v map[int]handler.VehicleJSON, err error) {
+	path := fmt.Sprintf("/vehicles/color/%s/year/%d", url.PathEscape(color), year)
+	err = c.do(ctx, http.MethodGet, path, nil, &v)
+	return
+}
+
+// GetByBrandAndYearRange is a method that returns a map of vehicles by brand and year range
+func (c *Client) GetByBrandAndYearRange(ctx context.Context, brand string, startYear int, finishYear int) (v map[int]handler.VehicleJSON, err error) {
+	path := fmt.Sprintf("/vehicles/brand/%s/between/%d/%d", url.PathEscape(brand), startYear, finishYear)
+	err = c.do(ctx, http.MethodGet, path, nil, &v)
+	return
+}
+
+// GetAverageSpeedByBrand is a method that returns the average speed of vehicles by brand
+func (c *Client) GetAverageSpeedByBrand(ctx context.Context, brand string) (averageSpeed float64, err error) {
+	err = c.do(ctx, http.MethodGet, "/vehicles/average_speed/brand/"+url.PathEscape(brand), nil, &averageSpeed)
+	return
+}
+
+// CreateMultiple is a method that creates multiple vehicles and returns them as created
+func (c *Client) CreateMultiple(ctx context.Context, v []handler.VehicleJSON) (created []handler.VehicleJSON, err error) {
+	// the batch endpoint expects an object of vehicles keyed by any name
+	body := make(map[string]handler.VehicleJSON, len(v))
+	for i, vehicle := range v {
+		body[strconv.Itoa(i)] = vehicle
+	}
+	err = c.do(ctx, http.MethodPost, "/vehicles/batch", body, &created)
+	return
+}
+
+// UpdateSpeed is a method that updates the speed of a vehicle and returns it as updated
+func (c *Client) UpdateSpeed(ctx context.Context, id int, speed float64) (v handler.VehicleJSON, err error) {
+	path := fmt.Sprintf("/vehicles/%d/update_speed", id)
+	err = c.do(ctx, http.MethodPatch, path, map[string]any{"speed": speed}, &v)
+	return
+}
+
+// GetByFuelType is a method that returns a map of vehicles by fuel type
+func (c *Client) GetByFuelType(ctx context.Context, fuelType string) (v map[int]handler.VehicleJSON, err error) {
+	err = c.do(ctx, http.MethodGet, "/vehicles/fuel_type/"+url.PathEscape(fuelType), nil, &v)
+	return
+}
+
+// Delete is a method that deletes a vehicle
+func (c *Client) Delete(ctx context.Context, id int) (err error) {
+	err = c.do(ctx, http.MethodDelete, fmt.Sprintf("/vehicles/%d", id), nil, nil)
+	return
+}
+
+// GetByTransmission is a method that returns a map of vehicles by transmission type
+func (c *Client) GetByTransmission(ctx context.Context, transmission string) (v map[int]handler.VehicleJSON, err error) {
+	err = c.do(ctx, http.MethodGet, "/vehicles/transmission/"+url.PathEscape(transmission), nil, &v)
+	return
+}
+
+// UpdateFuel is a method that updates the fuel type of a vehicle and returns it as updated
+func (c *Client) UpdateFuel(ctx context.Context, id int, fuelType string) (v handler.VehicleJSON, err error) {
+	path := fmt.Sprintf("/vehicles/%d/update_fuel", id)
+	err = c.do(ctx, http.MethodPatch, path, map[string]any{"fuel_type": fuelType}, &v)
+	return
+}
+
+// GetAverageCapacityByBrand is a method that returns the average capacity of vehicles by brand
+func (c *Client) GetAverageCapacityByBrand(ctx context.Context, brand string) (averageCapacity float64, err error) {
+	err = c.do(ctx, http.MethodGet, "/vehicles/average_capacity/brand/"+url.PathEscape(brand), nil, &averageCapacity)
+	return
+}
+
+// GetByDimensions is a method that returns a map of vehicles by dimensions
+// (keys: min_length, max_length, min_width, max_width)
+func (c *Client) GetByDimensions(ctx context.Context, dimensions map[string]float64) (v map[int]handler.VehicleJSON, err error) {
+	query := url.Values{}
+	if max, ok := dimensions["max_length"]; ok {
+		query.Set("length", formatRange(dimensions["min_length"], max))
+	}
+	if max, ok := dimensions["max_width"]; ok {
+		query.Set("width", formatRange(dimensions["min_width"], max))
+	}
+	err = c.do(ctx, http.MethodGet, "/vehicles/dimensions?"+query.Encode(), nil, &v)
+	return
+}
+
+// GetByWeight is a method that returns a map of vehicles by weight (keys: min, max)
+func (c *Client) GetByWeight(ctx context.Context, weight map[string]float64) (v map[int]handler.VehicleJSON, err error) {
+	query := url.Values{}
+	if min, ok := weight["min"]; ok {
+		query.Set("min", strconv.FormatFloat(min, 'f', -1, 64))
+	}
+	if max, ok := weight["max"]; ok {
+		query.Set("max", strconv.FormatFloat(max, 'f', -1, 64))
+	}
+	err = c.do(ctx, http.MethodGet, "/vehicles/weight?"+query.Encode(), nil, &v)
+	return
+}
+
+// GetByID is a method that returns a vehicle with its ETag
+func (c *Client) GetByID(ctx context.Context, id int) (v handler.VehicleJSON, etag string, err error) {
+	header, err := c.send(ctx, http.MethodGet, fmt.Sprintf("/vehicles/%d", id), nil, nil, &v)
+	if err != nil {
+		return
+	}
+	etag = header.Get("ETag")
+	return
+}
+
+// Replace is a method that replaces a vehicle and returns it as replaced with its new ETag,
+// only while it is at the version of etag when not empty, otherwise it fails with internal.ErrVehicleVersionMismatch
+func (c *Client) Replace(ctx context.Context, id int, v handler.VehicleJSON, etag string) (replaced handler.VehicleJSON, newETag string, err error) {
+	header := http.Header{}
+	if etag != "" {
+		header.Set("If-Match", etag)
+	}
+	header, err = c.send(ctx, http.MethodPut, fmt.Sprintf("/vehicles/%d", id), header, v, &replaced)
+	if err != nil {
+		return
+	}
+	newETag = header.Get("ETag")
+	return
+}
+
+// formatRange is a function that formats a range as {min}-{max}
+func formatRange(min float64, max float64) string {
+	return strconv.FormatFloat(min, 'f', -1, 64) + "-" + strconv.FormatFloat(max, 'f', -1, 64)
+}
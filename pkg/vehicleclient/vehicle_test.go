@@ -0,0 +1,134 @@
+package vehicleclient_test
+
+import (
+	"app/internal"
+	"app/internal/application"
+	"app/pkg/vehicleclient"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+// newTestClient is a function that returns a client of the router of the application, on top of the fleet of docs/db
+func newTestClient(t *testing.T) *vehicleclient.Client {
+	t.Helper()
+	dir := t.TempDir()
+	app := application.NewServerChi(&application.ConfigServerChi{
+		LoaderFilePath:            filepath.Join("..", "..", "docs", "db", "vehicles_100.json"),
+		CatalogFilePath:           filepath.Join(dir, "catalogs.json"),
+		AuditFilePath:             filepath.Join(dir, "audit.log"),
+		WebhookFilePath:           filepath.Join(dir, "webhooks.json"),
+		WebhookDeadLetterFilePath: filepath.Join(dir, "webhooks_dead_letters.log"),
+		QuotaFilePath:             filepath.Join(dir, "quotas.json"),
+		SequenceFilePath:          filepath.Join(dir, "vehicles.seq"),
+	})
+	h, release, err := app.Handler()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(h)
+	t.Cleanup(func() {
+		srv.Close()
+		release()
+	})
+	return vehicleclient.NewClient(&vehicleclient.Config{BaseURL: srv.URL})
+}
+
+func TestClient_Router(t *testing.T) {
+	cl := newTestClient(t)
+	ctx := context.Background()
+
+	// reads
+	all, err := cl.FindAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template, ok := all[1]
+	if !ok || template.Brand == "" || template.FabricationYear == 0 {
+		t.Fatalf("got vehicle 1 %+v", template)
+	}
+
+	// writes
+	template.ID = 0
+	template.Registration = "TEST-001"
+	created, err := cl.Create(ctx, template)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.ID == 0 || created.Registration != "TEST-001" {
+		t.Errorf("got created %+v", created)
+	}
+	updated, err := cl.UpdateSpeed(ctx, created.ID, created.MaxSpeed+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.MaxSpeed != created.MaxSpeed+1 {
+		t.Errorf("got speed %v, want %v", updated.MaxSpeed, created.MaxSpeed+1)
+	}
+
+	// the routes of filters
+	byFuel, err := cl.GetByFuelType(ctx, created.FuelType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := byFuel[created.ID]; got.Registration != "TEST-001" || got.FabricationYear != created.FabricationYear || got.Capacity != created.Capacity {
+		t.Errorf("got %+v, want %+v", got, updated)
+	}
+	byWeight, err := cl.GetByWeight(ctx, map[string]float64{"min": created.Weight, "max": created.Weight})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := byWeight[created.ID]; got.Weight != created.Weight || got.Width != created.Width {
+		t.Errorf("got %+v, want %+v", got, updated)
+	}
+
+	// errors
+	if _, err = cl.GetByTransmission(ctx, "none"); !errors.Is(err, internal.ErrVehicleNotFoundByTransmission) || !vehicleclient.IsNotFound(err) {
+		t.Errorf("got %v, want %v", err, internal.ErrVehicleNotFoundByTransmission)
+	}
+	if err = cl.Delete(ctx, 100000); !errors.Is(err, internal.ErrVehicleNotFound) {
+		t.Errorf("got %v, want %v", err, internal.ErrVehicleNotFound)
+	}
+}
+
+func TestClient_ReplaceIfMatch(t *testing.T) {
+	cl := newTestClient(t)
+	ctx := context.Background()
+
+	v, etag, err := cl.GetByID(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.ID != 1 || etag == "" {
+		t.Fatalf("got vehicle %+v with etag %q", v, etag)
+	}
+
+	// the version read is the current one
+	v.Registration = "TEST-002"
+	replaced, newETag, err := cl.Replace(ctx, 1, v, etag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replaced.Registration != "TEST-002" || newETag == "" || newETag == etag {
+		t.Errorf("got vehicle %+v with etag %q, read with %q", replaced, newETag, etag)
+	}
+	if _, got, err := cl.GetByID(ctx, 1); err != nil || got != newETag {
+		t.Errorf("got etag %q, %v, want %q", got, err, newETag)
+	}
+
+	// the version read is no longer the current one
+	v.Registration = "TEST-003"
+	if _, _, err = cl.Replace(ctx, 1, v, etag); !errors.Is(err, internal.ErrVehicleVersionMismatch) {
+		t.Errorf("got %v, want %v", err, internal.ErrVehicleVersionMismatch)
+	}
+	// without an etag the replace is unconditional
+	if replaced, _, err = cl.Replace(ctx, 1, v, ""); err != nil || replaced.Registration != "TEST-003" {
+		t.Errorf("got %+v, %v", replaced, err)
+	}
+
+	if _, _, err = cl.GetByID(ctx, 100000); !errors.Is(err, internal.ErrVehicleNotFound) {
+		t.Errorf("got %v, want %v", err, internal.ErrVehicleNotFound)
+	}
+}
@@ -0,0 +1,709 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrTooDeep is the error returned when the selections of an operation are nested deeper than the limit
+	ErrTooDeep = errors.New("graphql: query is too deep")
+	// ErrTooComplex is the error returned when the complexity of an operation is higher than the limit
+	ErrTooComplex = errors.New("graphql: query is too complex")
+)
+
+// Request is a struct that represents a GraphQL request, as sent in the body of a POST
+type Request struct {
+	// Query is the document with the operations
+	Query string `json:"query"`
+	// OperationName is the name of the operation to execute, empty when the document has one
+	OperationName string `json:"operationName"`
+	// Variables is the value of each variable of the operation
+	Variables map[string]any `json:"variables"`
+}
+
+// Response is a struct that represents the result of a GraphQL request
+type Response struct {
+	// Data is the result of the operation, absent when it could not be executed
+	Data any `json:"data,omitempty"`
+	// Errors is the list of the errors raised by the request
+	Errors []*Error `json:"errors,omitempty"`
+}
+
+// Error is a struct that represents an error of a GraphQL request
+type Error struct {
+	// Message is the description of the error
+	Message string `json:"message"`
+	// Locations is the list of the positions of the document the error is about
+	Locations []Location `json:"locations,omitempty"`
+	// Path is the path of the field of the response the error is about
+	Path []any `json:"path,omitempty"`
+	// Extensions is additional information about the error
+	Extensions map[string]any `json:"extensions,omitempty"`
+	// Err is the error raised by a resolver or a limit, nil for errors of the document
+	Err error `json:"-"`
+}
+
+// Error is a method that returns the error message
+func (e *Error) Error() string { return e.Message }
+
+// Unwrap is a method that returns the error raised by a resolver or a limit
+func (e *Error) Unwrap() error { return e.Err }
+
+// Limits is a struct that represents the bounds of the operations a schema executes, zero for no bound.
+// The introspection fields are not measured, since their cost is bounded by the size of the schema
+type Limits struct {
+	// MaxDepth is the deepest an operation may nest its fields
+	MaxDepth int
+	// MaxComplexity is the highest complexity an operation may have, the sum of the complexity of its fields
+	MaxComplexity int
+}
+
+// execution is a struct that represents the state of the execution of an operation
+type execution struct {
+	ctx       context.Context
+	schema    *Schema
+	doc       *Document
+	variables map[string]any
+	errors    []*Error
+}
+
+// Execute is a method that executes the operation of a request, within limits
+func (s *Schema) Execute(ctx context.Context, req Request, limits Limits) (res *Response) {
+	res = &Response{}
+	fail := func(err *Error) *Response {
+		res.Errors = append(res.Errors, err)
+		return res
+	}
+
+	// parse document and pick operation
+	doc, err := Parse(req.Query)
+	if err != nil {
+		var syntax *SyntaxError
+		if errors.As(err, &syntax) {
+			return fail(&Error{Message: syntax.Message, Locations: []Location{syntax.Location}})
+		}
+		return fail(&Error{Message: err.Error()})
+	}
+	if name := doc.cycle(); name != "" {
+		return fail(&Error{Message: fmt.Sprintf("fragment %s spreads itself", name)})
+	}
+	op, err := doc.Operation(req.OperationName)
+	if err != nil {
+		return fail(&Error{Message: err.Error()})
+	}
+	var root *Object
+	switch op.Type {
+	case "query":
+		root = s.Query
+	case "mutation":
+		root = s.Mutation
+	}
+	if root == nil {
+		return fail(&Error{Message: fmt.Sprintf("the schema does not support %s operations", op.Type)})
+	}
+
+	// coerce variables
+	e := &execution{ctx: ctx, schema: s, doc: doc, variables: make(map[string]any)}
+	for _, v := range op.Variables {
+		t, err := s.inputType(v.Type)
+		if err != nil {
+			return fail(&Error{Message: fmt.Sprintf("variable $%s: %v", v.Name, err)})
+		}
+		value, ok := req.Variables[v.Name]
+		if !ok && v.Default != nil {
+			value, ok = e.literal(v.Default), true
+		}
+		if !ok {
+			if _, required := t.(*NonNull); required {
+				return fail(&Error{Message: fmt.Sprintf("variable $%s of type %s is required", v.Name, t)})
+			}
+			continue
+		}
+		if _, err = coerce(value, t); err != nil {
+			return fail(&Error{Message: fmt.Sprintf("variable $%s: %v", v.Name, err)})
+		}
+		e.variables[v.Name] = value
+	}
+
+	// validate selections and limits
+	complexity, depth := e.measure(root, op.Selections, map[string]bool{})
+	if len(e.errors) > 0 {
+		res.Errors = e.errors
+		return
+	}
+	if limits.MaxDepth > 0 && depth > limits.MaxDepth {
+		return fail(&Error{
+			Message: fmt.Sprintf("query depth %d exceeds the limit of %d", depth, limits.MaxDepth),
+			Err:     ErrTooDeep,
+		})
+	}
+	if limits.MaxComplexity > 0 && complexity > limits.MaxComplexity {
+		return fail(&Error{
+			Message: fmt.Sprintf("query complexity %d exceeds the limit of %d", complexity, limits.MaxComplexity),
+			Err:     ErrTooComplex,
+		})
+	}
+
+	// execute
+	data, ok := e.selectionSet(root, nil, op.Selections, nil)
+	res.Data, res.Errors = data, e.errors
+	if !ok {
+		res.Data = json.RawMessage("null")
+	}
+	return
+}
+
+// Operation is a method that returns an operation of the document by its name, empty for the only one
+func (d *Document) Operation(name string) (op *Operation, err error) {
+	for _, o := range d.Operations {
+		if name == "" && len(d.Operations) == 1 || name != "" && o.Name == name {
+			return o, nil
+		}
+	}
+	if name == "" {
+		err = errors.New("operationName is required for a document with several operations")
+		return
+	}
+	err = fmt.Errorf("unknown operation %q", name)
+	return
+}
+
+// cycle is a method that returns the name of a fragment that spreads itself, directly or not, empty when there is none
+func (d *Document) cycle() string {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int)
+	var visit func(name string) string
+	var walk func(selections []Selection) string
+	visit = func(name string) string {
+		f, ok := d.Fragments[name]
+		if !ok || state[name] == done {
+			return ""
+		}
+		if state[name] == visiting {
+			return name
+		}
+		state[name] = visiting
+		if cyclic := walk(f.Selections); cyclic != "" {
+			return cyclic
+		}
+		state[name] = done
+		return ""
+	}
+	walk = func(selections []Selection) string {
+		for _, s := range selections {
+			var cyclic string
+			switch n := s.(type) {
+			case *Field:
+				cyclic = walk(n.Selections)
+			case *InlineFragment:
+				cyclic = walk(n.Selections)
+			case *FragmentSpread:
+				cyclic = visit(n.Name)
+			}
+			if cyclic != "" {
+				return cyclic
+			}
+		}
+		return ""
+	}
+	for name := range d.Fragments {
+		if cyclic := visit(name); cyclic != "" {
+			return cyclic
+		}
+	}
+	return ""
+}
+
+// inputType is a method that returns the input type of the schema a variable is declared with
+func (s *Schema) inputType(ref *TypeRef) (t Type, err error) {
+	if ref.Elem != nil {
+		if t, err = s.inputType(ref.Elem); err != nil {
+			return
+		}
+		t = NewList(t)
+	} else {
+		switch named := s.types[ref.Name].(type) {
+		case *Scalar, *Enum, *InputObject:
+			t = named
+		default:
+			err = fmt.Errorf("unknown input type %s", ref.Name)
+			return
+		}
+	}
+	if ref.NonNull {
+		t = NewNonNull(t)
+	}
+	return
+}
+
+// collected is a struct that represents the fields of a selection set sharing a key of the response
+type collected struct {
+	key   string
+	nodes []*Field
+}
+
+// collect is a method that returns the fields of a selection set for an object type, with the fragments expanded,
+// merged by key and without the ones skipped by directives
+func (e *execution) collect(t *Object, selections []Selection, visiting map[string]bool) (fields []*collected) {
+	index := make(map[string]*collected)
+	var walk func(selections []Selection)
+	walk = func(selections []Selection) {
+		for _, s := range selections {
+			switch n := s.(type) {
+			case *Field:
+				if !e.included(n.Directives) {
+					continue
+				}
+				c, ok := index[n.Key()]
+				if !ok {
+					c = &collected{key: n.Key()}
+					index[n.Key()] = c
+					fields = append(fields, c)
+				}
+				c.nodes = append(c.nodes, n)
+			case *InlineFragment:
+				if !e.included(n.Directives) || n.TypeCondition != "" && n.TypeCondition != t.Name {
+					continue
+				}
+				walk(n.Selections)
+			case *FragmentSpread:
+				if !e.included(n.Directives) || visiting[n.Name] {
+					continue
+				}
+				f, ok := e.doc.Fragments[n.Name]
+				if !ok {
+					e.errors = append(e.errors, &Error{Message: fmt.Sprintf("unknown fragment %s", n.Name), Locations: []Location{n.Location}})
+					continue
+				}
+				if f.TypeCondition != t.Name {
+					continue
+				}
+				visiting[n.Name] = true
+				walk(f.Selections)
+				delete(visiting, n.Name)
+			}
+		}
+	}
+	walk(selections)
+	return
+}
+
+// included is a method that reports whether the @skip and @include directives keep a selection
+func (e *execution) included(directives []*Directive) bool {
+	for _, d := range directives {
+		if d.Name != "skip" && d.Name != "include" {
+			continue
+		}
+		args, err := e.arguments(directiveIf, d.Arguments)
+		if err != nil {
+			continue
+		}
+		if cond, _ := args["if"].(bool); cond == (d.Name == "skip") {
+			return false
+		}
+	}
+	return true
+}
+
+// field is a method that returns the definition of a field of an object type, including the meta fields
+func (e *execution) field(t *Object, name string) *FieldDefinition {
+	switch {
+	case name == "__typename":
+		return typenameField
+	case t == e.schema.Query && name == "__schema":
+		return schemaField
+	case t == e.schema.Query && name == "__type":
+		return typeField
+	}
+	return t.Field(name)
+}
+
+// measure is a method that validates the fields of a selection set and returns its complexity and depth
+func (e *execution) measure(t *Object, selections []Selection, visiting map[string]bool) (complexity int, depth int) {
+	for _, c := range e.collect(t, selections, visiting) {
+		n := c.nodes[0]
+		def := e.field(t, n.Name)
+		if def == nil {
+			e.errors = append(e.errors, &Error{Message: fmt.Sprintf("cannot query field %q on type %q", n.Name, t.Name), Locations: []Location{n.Location}})
+			continue
+		}
+		args, err := e.arguments(def.Args, n.Arguments)
+		if err != nil {
+			e.errors = append(e.errors, &Error{Message: fmt.Sprintf("field %q: %v", n.Name, err), Locations: []Location{n.Location}})
+			continue
+		}
+
+		// - objects require a selection set and leaves forbid it
+		var children, childDepth int
+		obj, isObject := namedType(def.Type).(*Object)
+		switch {
+		case isObject && len(n.Selections) == 0:
+			e.errors = append(e.errors, &Error{Message: fmt.Sprintf("field %q of type %q requires a selection of subfields", n.Name, def.Type), Locations: []Location{n.Location}})
+			continue
+		case !isObject && len(n.Selections) > 0:
+			e.errors = append(e.errors, &Error{Message: fmt.Sprintf("field %q of type %q has no subfields", n.Name, def.Type), Locations: []Location{n.Location}})
+			continue
+		case isObject:
+			children, childDepth = e.measure(obj, merged(c.nodes), visiting)
+		}
+
+		// - the introspection fields are not measured
+		if strings.HasPrefix(n.Name, "__") {
+			continue
+		}
+		cost := 1 + children
+		if def.Complexity != nil {
+			cost = def.Complexity(args, children)
+		}
+		complexity += cost
+		depth = max(depth, 1+childDepth)
+	}
+	return
+}
+
+// merged is a function that returns the selection sets of the fields sharing a key, as one
+func merged(nodes []*Field) (selections []Selection) {
+	for _, n := range nodes {
+		selections = append(selections, n.Selections...)
+	}
+	return
+}
+
+// selectionSet is a method that returns the value of a selection set for an object,
+// or false when a non-null field is null and the object has to be null
+func (e *execution) selectionSet(t *Object, source any, selections []Selection, path []any) (obj *object, ok bool) {
+	obj = &object{}
+	for _, c := range e.collect(t, selections, map[string]bool{}) {
+		n := c.nodes[0]
+		def := e.field(t, n.Name)
+		fieldPath := append(append([]any{}, path...), c.key)
+
+		// - resolve field
+		value, err := e.resolve(t, def, source, n)
+		if err != nil {
+			e.errors = append(e.errors, &Error{Message: err.Error(), Locations: []Location{n.Location}, Path: fieldPath, Err: err})
+			if _, required := def.Type.(*NonNull); required {
+				return nil, false
+			}
+			obj.set(c.key, nil)
+			continue
+		}
+
+		// - complete value
+		result, completed := e.complete(def.Type, c.nodes, value, fieldPath)
+		if !completed {
+			if _, required := def.Type.(*NonNull); required {
+				return nil, false
+			}
+			result = nil
+		}
+		obj.set(c.key, result)
+	}
+	return obj, true
+}
+
+// resolve is a method that returns the value of a field of an object
+func (e *execution) resolve(t *Object, def *FieldDefinition, source any, n *Field) (value any, err error) {
+	if err = e.ctx.Err(); err != nil {
+		return
+	}
+	args, err := e.arguments(def.Args, n.Arguments)
+	if err != nil {
+		return
+	}
+	switch {
+	case def == typenameField:
+		return t.Name, nil
+	case def == schemaField, def == typeField:
+		source = e.schema
+	}
+	if def.Resolve == nil {
+		if m, ok := source.(map[string]any); ok {
+			value = m[def.Name]
+		}
+		return
+	}
+	return def.Resolve(ResolveParams{Context: e.ctx, Source: source, Args: args})
+}
+
+// complete is a method that returns the value of a field in the response for its type,
+// or false when the value is null for a non-null type and the null has to propagate
+func (e *execution) complete(t Type, nodes []*Field, value any, path []any) (result any, ok bool) {
+	if nn, required := t.(*NonNull); required {
+		result, ok = e.complete(nn.OfType, nodes, value, path)
+		if ok && result == nil {
+			e.errors = append(e.errors, &Error{Message: fmt.Sprintf("cannot return null for non-null field %s", nodes[0].Name), Locations: []Location{nodes[0].Location}, Path: path})
+			ok = false
+		}
+		return
+	}
+	if isNil(value) {
+		return nil, true
+	}
+
+	switch n := t.(type) {
+	case *List:
+		rv := reflect.ValueOf(value)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			e.errors = append(e.errors, &Error{Message: fmt.Sprintf("field %s expects a list", nodes[0].Name), Path: path})
+			return nil, false
+		}
+		items := make([]any, rv.Len())
+		for i := range items {
+			item, completed := e.complete(n.OfType, nodes, rv.Index(i).Interface(), append(append([]any{}, path...), i))
+			if !completed {
+				if _, required := n.OfType.(*NonNull); required {
+					return nil, false
+				}
+				item = nil
+			}
+			items[i] = item
+		}
+		return items, true
+	case *Scalar:
+		v, err := n.Serialize(value)
+		if err != nil {
+			e.errors = append(e.errors, &Error{Message: fmt.Sprintf("cannot serialize %v as %s", value, n.Name), Path: path, Err: err})
+			return nil, false
+		}
+		return v, true
+	case *Enum:
+		name, found := n.name(value)
+		if !found {
+			e.errors = append(e.errors, &Error{Message: fmt.Sprintf("cannot serialize %v as %s", value, n.Name), Path: path})
+			return nil, false
+		}
+		return name, true
+	case *Object:
+		obj, completed := e.selectionSet(n, value, merged(nodes), path)
+		if !completed {
+			return nil, false
+		}
+		return obj, true
+	}
+	return nil, false
+}
+
+// isNil is a function that reports whether a value is nil, including typed nil pointers, slices and maps
+func isNil(value any) bool {
+	if value == nil {
+		return true
+	}
+	switch rv := reflect.ValueOf(value); rv.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// arguments is a method that returns the value of each argument of a field, coerced to its type
+func (e *execution) arguments(defs []*ArgumentDefinition, nodes []*Argument) (args map[string]any, err error) {
+	args = make(map[string]any, len(defs))
+	given := make(map[string]*Value, len(nodes))
+	for _, n := range nodes {
+		given[n.Name] = n.Value
+	}
+	for name := range given {
+		if !hasArgument(defs, name) {
+			err = fmt.Errorf("unknown argument %q", name)
+			return
+		}
+	}
+	for _, def := range defs {
+		value, ok := given[def.Name]
+		if ok && value.Kind == ValueVariable {
+			_, ok = e.variables[value.Raw]
+		}
+		if !ok {
+			if def.Default != nil {
+				args[def.Name] = def.Default
+			} else if _, required := def.Type.(*NonNull); required {
+				err = fmt.Errorf("argument %q of type %s is required", def.Name, def.Type)
+				return
+			}
+			continue
+		}
+		if args[def.Name], err = coerce(e.literal(value), def.Type); err != nil {
+			err = fmt.Errorf("argument %q: %w", def.Name, err)
+			return
+		}
+	}
+	return
+}
+
+// hasArgument is a function that reports whether a list of arguments has one by name
+func hasArgument(defs []*ArgumentDefinition, name string) bool {
+	for _, def := range defs {
+		if def.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// literal is a method that returns a value of a document as a JSON value, with its variables replaced
+// and the fields of objects whose variables are not given left out
+func (e *execution) literal(v *Value) any {
+	switch v.Kind {
+	case ValueVariable:
+		return e.variables[v.Raw]
+	case ValueInt:
+		if n, err := strconv.Atoi(v.Raw); err == nil {
+			return n
+		}
+		n, _ := strconv.ParseFloat(v.Raw, 64)
+		return n
+	case ValueFloat:
+		n, _ := strconv.ParseFloat(v.Raw, 64)
+		return n
+	case ValueString, ValueEnum:
+		return v.Raw
+	case ValueBoolean:
+		return v.Raw == "true"
+	case ValueList:
+		items := make([]any, 0, len(v.List))
+		for _, item := range v.List {
+			items = append(items, e.literal(item))
+		}
+		return items
+	case ValueObject:
+		fields := make(map[string]any, len(v.Fields))
+		for _, f := range v.Fields {
+			if f.Value.Kind == ValueVariable {
+				if _, ok := e.variables[f.Value.Raw]; !ok {
+					continue
+				}
+			}
+			fields[f.Name] = e.literal(f.Value)
+		}
+		return fields
+	}
+	return nil
+}
+
+// coerce is a function that returns a JSON value as a value of an input type
+func coerce(value any, t Type) (result any, err error) {
+	if nn, required := t.(*NonNull); required {
+		if value == nil {
+			err = fmt.Errorf("expected a non-null %s", nn.OfType)
+			return
+		}
+		return coerce(value, nn.OfType)
+	}
+	if value == nil {
+		return
+	}
+
+	switch n := t.(type) {
+	case *List:
+		items, ok := value.([]any)
+		if !ok {
+			// - a single value is a list of one item
+			items = []any{value}
+		}
+		list := make([]any, 0, len(items))
+		for _, item := range items {
+			var v any
+			if v, err = coerce(item, n.OfType); err != nil {
+				return
+			}
+			list = append(list, v)
+		}
+		result = list
+	case *Scalar:
+		if result, err = n.Parse(value); err != nil {
+			err = fmt.Errorf("expected a %s, got %s", n.Name, describe(value))
+		}
+	case *Enum:
+		name, _ := value.(string)
+		var ok bool
+		if result, ok = n.value(name); !ok {
+			err = fmt.Errorf("expected a value of %s, got %s", n.Name, describe(value))
+		}
+	case *InputObject:
+		fields, ok := value.(map[string]any)
+		if !ok {
+			err = fmt.Errorf("expected a %s object, got %s", n.Name, describe(value))
+			return
+		}
+		for name := range fields {
+			if !hasArgument(n.Fields, name) {
+				err = fmt.Errorf("unknown field %q of %s", name, n.Name)
+				return
+			}
+		}
+		object := make(map[string]any, len(n.Fields))
+		for _, f := range n.Fields {
+			v, given := fields[f.Name]
+			if !given {
+				if f.Default != nil {
+					object[f.Name] = f.Default
+				} else if _, required := f.Type.(*NonNull); required {
+					err = fmt.Errorf("field %q of %s is required", f.Name, n.Name)
+					return
+				}
+				continue
+			}
+			if object[f.Name], err = coerce(v, f.Type); err != nil {
+				err = fmt.Errorf("field %q of %s: %w", f.Name, n.Name, err)
+				return
+			}
+		}
+		result = object
+	default:
+		err = fmt.Errorf("%s is not an input type", t)
+	}
+	return
+}
+
+// describe is a function that returns a JSON value as text for an error message
+func describe(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// object is a struct that represents an object of the response, whose keys keep the order of the selection set
+type object struct {
+	keys   []string
+	values []any
+}
+
+// set is a method that sets the value of a key
+func (o *object) set(key string, value any) {
+	o.keys = append(o.keys, key)
+	o.values = append(o.values, value)
+}
+
+// MarshalJSON is a method that returns the object in JSON, keys in order
+func (o *object) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		b.Write(k)
+		b.WriteByte(':')
+		v, err := json.Marshal(o.values[i])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(v)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
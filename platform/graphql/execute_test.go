@@ -0,0 +1,159 @@
+package graphql_test
+
+import (
+	"app/platform/graphql"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// errNotFound is the error the resolver of a vehicle raises for an unknown id
+var errNotFound = errors.New("vehicle not found")
+
+// newTestSchema is a function that returns a schema of vehicles with the brand of vehicle 1 kept in brand
+func newTestSchema(t *testing.T, brand *string) *graphql.Schema {
+	t.Helper()
+	vehicle := &graphql.Object{Name: "Vehicle", Fields: []*graphql.FieldDefinition{
+		{Name: "id", Type: graphql.NewNonNull(graphql.Int)},
+		{Name: "brand", Type: graphql.String},
+	}}
+	find := func(p graphql.ResolveParams) (any, error) {
+		if p.Args["id"] != 1 {
+			return nil, errNotFound
+		}
+		return map[string]any{"id": 1, "brand": *brand}, nil
+	}
+	query := &graphql.Object{Name: "Query", Fields: []*graphql.FieldDefinition{
+		{Name: "hello", Type: graphql.String, Args: []*graphql.ArgumentDefinition{{Name: "name", Type: graphql.String, Default: "world"}},
+			Resolve: func(p graphql.ResolveParams) (any, error) { return "hello " + p.Args["name"].(string), nil }},
+		{Name: "vehicle", Type: vehicle, Args: []*graphql.ArgumentDefinition{{Name: "id", Type: graphql.NewNonNull(graphql.Int)}}, Resolve: find},
+		{Name: "required", Type: graphql.NewNonNull(vehicle), Args: []*graphql.ArgumentDefinition{{Name: "id", Type: graphql.NewNonNull(graphql.Int)}}, Resolve: find},
+	}}
+	mutation := &graphql.Object{Name: "Mutation", Fields: []*graphql.FieldDefinition{
+		{Name: "rename", Type: vehicle, Args: []*graphql.ArgumentDefinition{{Name: "brand", Type: graphql.NewNonNull(graphql.String)}},
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				*brand = p.Args["brand"].(string)
+				return map[string]any{"id": 1, "brand": *brand}, nil
+			}},
+	}}
+	schema, err := graphql.NewSchema(query, mutation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
+
+// marshal is a function that returns a response in JSON
+func marshal(t *testing.T, res *graphql.Response) string {
+	t.Helper()
+	data, err := json.Marshal(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestSchema_Execute(t *testing.T) {
+	brand := "Ford"
+	schema := newTestSchema(t, &brand)
+	cases := []struct {
+		name string
+		req  graphql.Request
+		want string
+	}{
+		{
+			name: "field selection",
+			req:  graphql.Request{Query: `{ vehicle(id: 1) { brand id } }`},
+			want: `{"data":{"vehicle":{"brand":"Ford","id":1}}}`,
+		},
+		{
+			name: "aliases and default arguments",
+			req:  graphql.Request{Query: `{ a: hello b: hello(name: "ana") }`},
+			want: `{"data":{"a":"hello world","b":"hello ana"}}`,
+		},
+		{
+			name: "variables",
+			req:  graphql.Request{Query: `query ($id: Int!) { vehicle(id: $id) { id } }`, Variables: map[string]any{"id": 1}},
+			want: `{"data":{"vehicle":{"id":1}}}`,
+		},
+		{
+			name: "fragments and directives",
+			req:  graphql.Request{Query: `query ($full: Boolean!) { vehicle(id: 1) { ...parts brand @include(if: $full) } } fragment parts on Vehicle { id }`, Variables: map[string]any{"full": false}},
+			want: `{"data":{"vehicle":{"id":1}}}`,
+		},
+		{
+			name: "resolver error on a nullable field",
+			req:  graphql.Request{Query: `{ vehicle(id: 2) { id } hello }`},
+			want: `{"data":{"vehicle":null,"hello":"hello world"},"errors":[{"message":"vehicle not found","locations":[{"line":1,"column":3}],"path":["vehicle"]}]}`,
+		},
+		{
+			name: "resolver error on a non-null field",
+			req:  graphql.Request{Query: `{ required(id: 2) { id } hello }`},
+			want: `{"data":null,"errors":[{"message":"vehicle not found","locations":[{"line":1,"column":3}],"path":["required"]}]}`,
+		},
+		{
+			name: "unknown field",
+			req:  graphql.Request{Query: `{ vehicle(id: 1) { color } }`},
+			want: `{"errors":[{"message":"cannot query field \"color\" on type \"Vehicle\"","locations":[{"line":1,"column":20}]}]}`,
+		},
+		{
+			name: "missing variable",
+			req:  graphql.Request{Query: `query ($id: Int!) { vehicle(id: $id) { id } }`},
+			want: `{"errors":[{"message":"variable $id of type Int! is required"}]}`,
+		},
+		{
+			name: "syntax error",
+			req:  graphql.Request{Query: `{ vehicle(id: 1) { id }`},
+			want: `{"errors":[{"message":"unexpected end of document","locations":[{"line":1,"column":24}]}]}`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := marshal(t, schema.Execute(context.Background(), c.req, graphql.Limits{})); got != c.want {
+				t.Errorf("got %s, want %s", got, c.want)
+			}
+		})
+	}
+}
+
+func TestSchema_ExecuteMutation(t *testing.T) {
+	brand := "Ford"
+	schema := newTestSchema(t, &brand)
+
+	res := schema.Execute(context.Background(), graphql.Request{Query: `mutation { rename(brand: "Kia") { brand } }`}, graphql.Limits{})
+	if got, want := marshal(t, res), `{"data":{"rename":{"brand":"Kia"}}}`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if brand != "Kia" {
+		t.Errorf("got brand %s, want the mutation applied", brand)
+	}
+}
+
+func TestSchema_ExecuteLimits(t *testing.T) {
+	brand := "Ford"
+	schema := newTestSchema(t, &brand)
+	cases := []struct {
+		name   string
+		limits graphql.Limits
+		err    error
+	}{
+		{name: "too deep", limits: graphql.Limits{MaxDepth: 1}, err: graphql.ErrTooDeep},
+		{name: "too complex", limits: graphql.Limits{MaxComplexity: 2}, err: graphql.ErrTooComplex},
+		{name: "within the limits", limits: graphql.Limits{MaxDepth: 2, MaxComplexity: 3}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			res := schema.Execute(context.Background(), graphql.Request{Query: `{ vehicle(id: 1) { id brand } }`}, c.limits)
+			if c.err == nil {
+				if len(res.Errors) != 0 {
+					t.Fatalf("got %v, want no errors", res.Errors)
+				}
+				return
+			}
+			if len(res.Errors) != 1 || !errors.Is(res.Errors[0], c.err) || res.Data != nil {
+				t.Errorf("got %s, want the error %v and no data", marshal(t, res), c.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,306 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// directive is a struct that represents a directive the executor supports
+type directive struct {
+	name        string
+	description string
+	locations   []string
+	args        []*ArgumentDefinition
+}
+
+// directiveIf is the list of the arguments of the @skip and @include directives
+var directiveIf = []*ArgumentDefinition{{Name: "if", Type: NewNonNull(Boolean)}}
+
+// directives is the list of the directives the executor supports
+var directives = []*directive{
+	{
+		name:        "include",
+		description: "Directs the executor to include this field or fragment only when the `if` argument is true.",
+		locations:   []string{"FIELD", "FRAGMENT_SPREAD", "INLINE_FRAGMENT"},
+		args:        directiveIf,
+	},
+	{
+		name:        "skip",
+		description: "Directs the executor to skip this field or fragment when the `if` argument is true.",
+		locations:   []string{"FIELD", "FRAGMENT_SPREAD", "INLINE_FRAGMENT"},
+		args:        directiveIf,
+	},
+}
+
+// Types of the introspection system, defined by the GraphQL specification
+var (
+	introspectionSchema     = &Object{Name: "__Schema", Description: "A GraphQL Schema defines the capabilities of a GraphQL server."}
+	introspectionType       = &Object{Name: "__Type", Description: "The fundamental unit of any GraphQL Schema is the type."}
+	introspectionField      = &Object{Name: "__Field", Description: "Object and Interface types are described by a list of Fields, each of which has a name, potentially a list of arguments, and a return type."}
+	introspectionInputValue = &Object{Name: "__InputValue", Description: "Arguments provided to Fields or Directives and the input fields of an InputObject are represented as Input Values which describe their type and optionally a default value."}
+	introspectionEnumValue  = &Object{Name: "__EnumValue", Description: "One possible value for a given Enum."}
+	introspectionDirective  = &Object{Name: "__Directive", Description: "A Directive provides a way to describe alternate runtime execution and type validation behavior in a GraphQL document."}
+	introspectionTypeKind   = &Enum{Name: "__TypeKind", Description: "An enum describing what kind of type a given `__Type` is.", Values: enumValues(
+		"SCALAR", "OBJECT", "INTERFACE", "UNION", "ENUM", "INPUT_OBJECT", "LIST", "NON_NULL",
+	)}
+	introspectionDirectiveLocation = &Enum{Name: "__DirectiveLocation", Description: "A Directive can be adjacent to many parts of the GraphQL language.", Values: enumValues(
+		"QUERY", "MUTATION", "SUBSCRIPTION", "FIELD", "FRAGMENT_DEFINITION", "FRAGMENT_SPREAD", "INLINE_FRAGMENT",
+	)}
+)
+
+// Meta fields the executor resolves on every object or on the root query
+var (
+	typenameField = &FieldDefinition{Name: "__typename", Type: NewNonNull(String)}
+	schemaField   = &FieldDefinition{Name: "__schema", Type: NewNonNull(introspectionSchema), Resolve: func(p ResolveParams) (any, error) {
+		return p.Source, nil
+	}}
+	typeField = &FieldDefinition{Name: "__type", Type: introspectionType, Args: []*ArgumentDefinition{{Name: "name", Type: NewNonNull(String)}}, Resolve: func(p ResolveParams) (any, error) {
+		return p.Source.(*Schema).Type(p.Args["name"].(string)), nil
+	}}
+)
+
+// enumValues is a function that returns the values of an enum whose names are their values
+func enumValues(names ...string) (values []*EnumValue) {
+	for _, name := range names {
+		values = append(values, &EnumValue{Name: name})
+	}
+	return
+}
+
+// optional is a function that returns a string, or nil when it is empty
+func optional(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// includeDeprecated is the argument of the introspection fields that list members which may be deprecated
+var includeDeprecated = []*ArgumentDefinition{{Name: "includeDeprecated", Type: Boolean, Default: false}}
+
+func init() {
+	introspectionSchema.Fields = []*FieldDefinition{
+		{Name: "description", Type: String, Resolve: func(p ResolveParams) (any, error) { return nil, nil }},
+		{Name: "types", Type: NewNonNull(NewList(NewNonNull(introspectionType))), Resolve: func(p ResolveParams) (any, error) {
+			s := p.Source.(*Schema)
+			types := make([]Type, 0, len(s.names))
+			for _, name := range s.names {
+				types = append(types, s.types[name])
+			}
+			return types, nil
+		}},
+		{Name: "queryType", Type: NewNonNull(introspectionType), Resolve: func(p ResolveParams) (any, error) {
+			return p.Source.(*Schema).Query, nil
+		}},
+		{Name: "mutationType", Type: introspectionType, Resolve: func(p ResolveParams) (any, error) {
+			return p.Source.(*Schema).Mutation, nil
+		}},
+		{Name: "subscriptionType", Type: introspectionType, Resolve: func(p ResolveParams) (any, error) { return nil, nil }},
+		{Name: "directives", Type: NewNonNull(NewList(NewNonNull(introspectionDirective))), Resolve: func(p ResolveParams) (any, error) {
+			return directives, nil
+		}},
+	}
+
+	introspectionType.Fields = []*FieldDefinition{
+		{Name: "kind", Type: NewNonNull(introspectionTypeKind), Resolve: func(p ResolveParams) (any, error) {
+			switch p.Source.(type) {
+			case *Scalar:
+				return "SCALAR", nil
+			case *Object:
+				return "OBJECT", nil
+			case *Enum:
+				return "ENUM", nil
+			case *InputObject:
+				return "INPUT_OBJECT", nil
+			case *List:
+				return "LIST", nil
+			case *NonNull:
+				return "NON_NULL", nil
+			}
+			return nil, fmt.Errorf("unknown type %v", p.Source)
+		}},
+		{Name: "name", Type: String, Resolve: func(p ResolveParams) (any, error) {
+			return optional(typeName(p.Source.(Type))), nil
+		}},
+		{Name: "description", Type: String, Resolve: func(p ResolveParams) (any, error) {
+			switch t := p.Source.(type) {
+			case *Scalar:
+				return optional(t.Description), nil
+			case *Object:
+				return optional(t.Description), nil
+			case *Enum:
+				return optional(t.Description), nil
+			case *InputObject:
+				return optional(t.Description), nil
+			}
+			return nil, nil
+		}},
+		{Name: "specifiedByURL", Type: String, Resolve: func(p ResolveParams) (any, error) { return nil, nil }},
+		{Name: "fields", Type: NewList(NewNonNull(introspectionField)), Args: includeDeprecated, Resolve: func(p ResolveParams) (any, error) {
+			t, ok := p.Source.(*Object)
+			if !ok {
+				return nil, nil
+			}
+			fields := make([]*FieldDefinition, 0, len(t.Fields))
+			for _, f := range t.Fields {
+				if f.DeprecationReason == "" || p.Args["includeDeprecated"] == true {
+					fields = append(fields, f)
+				}
+			}
+			return fields, nil
+		}},
+		{Name: "interfaces", Type: NewList(NewNonNull(introspectionType)), Resolve: func(p ResolveParams) (any, error) {
+			if _, ok := p.Source.(*Object); ok {
+				return []Type{}, nil
+			}
+			return nil, nil
+		}},
+		{Name: "possibleTypes", Type: NewList(NewNonNull(introspectionType)), Resolve: func(p ResolveParams) (any, error) { return nil, nil }},
+		{Name: "enumValues", Type: NewList(NewNonNull(introspectionEnumValue)), Args: includeDeprecated, Resolve: func(p ResolveParams) (any, error) {
+			if t, ok := p.Source.(*Enum); ok {
+				return t.Values, nil
+			}
+			return nil, nil
+		}},
+		{Name: "inputFields", Type: NewList(NewNonNull(introspectionInputValue)), Args: includeDeprecated, Resolve: func(p ResolveParams) (any, error) {
+			if t, ok := p.Source.(*InputObject); ok {
+				return t.Fields, nil
+			}
+			return nil, nil
+		}},
+		{Name: "ofType", Type: introspectionType, Resolve: func(p ResolveParams) (any, error) {
+			switch t := p.Source.(type) {
+			case *List:
+				return t.OfType, nil
+			case *NonNull:
+				return t.OfType, nil
+			}
+			return nil, nil
+		}},
+		{Name: "isOneOf", Type: Boolean, Resolve: func(p ResolveParams) (any, error) {
+			if _, ok := p.Source.(*InputObject); ok {
+				return false, nil
+			}
+			return nil, nil
+		}},
+	}
+
+	introspectionField.Fields = []*FieldDefinition{
+		{Name: "name", Type: NewNonNull(String), Resolve: func(p ResolveParams) (any, error) {
+			return p.Source.(*FieldDefinition).Name, nil
+		}},
+		{Name: "description", Type: String, Resolve: func(p ResolveParams) (any, error) {
+			return optional(p.Source.(*FieldDefinition).Description), nil
+		}},
+		{Name: "args", Type: NewNonNull(NewList(NewNonNull(introspectionInputValue))), Args: includeDeprecated, Resolve: func(p ResolveParams) (any, error) {
+			return append([]*ArgumentDefinition{}, p.Source.(*FieldDefinition).Args...), nil
+		}},
+		{Name: "type", Type: NewNonNull(introspectionType), Resolve: func(p ResolveParams) (any, error) {
+			return p.Source.(*FieldDefinition).Type, nil
+		}},
+		{Name: "isDeprecated", Type: NewNonNull(Boolean), Resolve: func(p ResolveParams) (any, error) {
+			return p.Source.(*FieldDefinition).DeprecationReason != "", nil
+		}},
+		{Name: "deprecationReason", Type: String, Resolve: func(p ResolveParams) (any, error) {
+			return optional(p.Source.(*FieldDefinition).DeprecationReason), nil
+		}},
+	}
+
+	introspectionInputValue.Fields = []*FieldDefinition{
+		{Name: "name", Type: NewNonNull(String), Resolve: func(p ResolveParams) (any, error) {
+			return p.Source.(*ArgumentDefinition).Name, nil
+		}},
+		{Name: "description", Type: String, Resolve: func(p ResolveParams) (any, error) {
+			return optional(p.Source.(*ArgumentDefinition).Description), nil
+		}},
+		{Name: "type", Type: NewNonNull(introspectionType), Resolve: func(p ResolveParams) (any, error) {
+			return p.Source.(*ArgumentDefinition).Type, nil
+		}},
+		{Name: "defaultValue", Type: String, Resolve: func(p ResolveParams) (any, error) {
+			arg := p.Source.(*ArgumentDefinition)
+			if arg.Default == nil {
+				return nil, nil
+			}
+			return printValue(arg.Default, arg.Type), nil
+		}},
+		{Name: "isDeprecated", Type: NewNonNull(Boolean), Resolve: func(p ResolveParams) (any, error) { return false, nil }},
+		{Name: "deprecationReason", Type: String, Resolve: func(p ResolveParams) (any, error) { return nil, nil }},
+	}
+
+	introspectionEnumValue.Fields = []*FieldDefinition{
+		{Name: "name", Type: NewNonNull(String), Resolve: func(p ResolveParams) (any, error) {
+			return p.Source.(*EnumValue).Name, nil
+		}},
+		{Name: "description", Type: String, Resolve: func(p ResolveParams) (any, error) {
+			return optional(p.Source.(*EnumValue).Description), nil
+		}},
+		{Name: "isDeprecated", Type: NewNonNull(Boolean), Resolve: func(p ResolveParams) (any, error) { return false, nil }},
+		{Name: "deprecationReason", Type: String, Resolve: func(p ResolveParams) (any, error) { return nil, nil }},
+	}
+
+	introspectionDirective.Fields = []*FieldDefinition{
+		{Name: "name", Type: NewNonNull(String), Resolve: func(p ResolveParams) (any, error) {
+			return p.Source.(*directive).name, nil
+		}},
+		{Name: "description", Type: String, Resolve: func(p ResolveParams) (any, error) {
+			return optional(p.Source.(*directive).description), nil
+		}},
+		{Name: "locations", Type: NewNonNull(NewList(NewNonNull(introspectionDirectiveLocation))), Resolve: func(p ResolveParams) (any, error) {
+			return p.Source.(*directive).locations, nil
+		}},
+		{Name: "args", Type: NewNonNull(NewList(NewNonNull(introspectionInputValue))), Args: includeDeprecated, Resolve: func(p ResolveParams) (any, error) {
+			return p.Source.(*directive).args, nil
+		}},
+		{Name: "isRepeatable", Type: NewNonNull(Boolean), Resolve: func(p ResolveParams) (any, error) { return false, nil }},
+	}
+}
+
+// printValue is a function that returns a value of an input type as written in a document
+func printValue(value any, t Type) string {
+	if nn, ok := t.(*NonNull); ok {
+		t = nn.OfType
+	}
+	if value == nil {
+		return "null"
+	}
+	switch n := t.(type) {
+	case *List:
+		rv := reflect.ValueOf(value)
+		if rv.Kind() != reflect.Slice {
+			return printValue(value, n.OfType)
+		}
+		items := make([]string, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			items = append(items, printValue(rv.Index(i).Interface(), n.OfType))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case *Enum:
+		if name, ok := n.name(value); ok {
+			return name
+		}
+	case *InputObject:
+		fields, _ := value.(map[string]any)
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		items := make([]string, 0, len(names))
+		for _, name := range names {
+			for _, f := range n.Fields {
+				if f.Name == name {
+					items = append(items, name+": "+printValue(fields[name], f.Type))
+				}
+			}
+		}
+		return "{" + strings.Join(items, ", ") + "}"
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
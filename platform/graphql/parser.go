@@ -0,0 +1,770 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Document is a struct that represents a parsed GraphQL request document
+type Document struct {
+	// Operations is the list of the operations of the document
+	Operations []*Operation
+	// Fragments is the fragments of the document, by name
+	Fragments map[string]*Fragment
+}
+
+// Operation is a struct that represents a query or a mutation
+type Operation struct {
+	// Type is the type of the operation: query or mutation
+	Type string
+	// Name is the name of the operation, empty for an anonymous one
+	Name string
+	// Variables is the list of the variables the operation declares
+	Variables []*VariableDefinition
+	// Selections is the selection set of the operation
+	Selections []Selection
+}
+
+// VariableDefinition is a struct that represents a variable declared by an operation
+type VariableDefinition struct {
+	// Name is the name of the variable, without $
+	Name string
+	// Type is the type of the variable
+	Type *TypeRef
+	// Default is the default value of the variable, nil for none
+	Default *Value
+}
+
+// TypeRef is a struct that represents a type written in a document, e.g. [Int!]!
+type TypeRef struct {
+	// Name is the name of a named type, empty for a list
+	Name string
+	// Elem is the type of the items of a list
+	Elem *TypeRef
+	// NonNull is whether the type is non-null
+	NonNull bool
+}
+
+// String is a method that returns the type as written in a document
+func (t *TypeRef) String() string {
+	s := t.Name
+	if t.Elem != nil {
+		s = "[" + t.Elem.String() + "]"
+	}
+	if t.NonNull {
+		s += "!"
+	}
+	return s
+}
+
+// Selection is an interface that represents a field, a fragment spread or an inline fragment
+type Selection interface {
+	selection()
+}
+
+// Field is a struct that represents a field selected in a document
+type Field struct {
+	// Alias is the key of the field in the response, empty to use its name
+	Alias string
+	// Name is the name of the field
+	Name string
+	// Arguments is the list of the arguments of the field
+	Arguments []*Argument
+	// Directives is the list of the directives of the field
+	Directives []*Directive
+	// Selections is the selection set of the field, empty for a leaf
+	Selections []Selection
+	// Location is where the field starts in the document
+	Location Location
+}
+
+// Key is a method that returns the key of the field in the response
+func (f *Field) Key() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+// FragmentSpread is a struct that represents a spread of a named fragment
+type FragmentSpread struct {
+	// Name is the name of the fragment
+	Name string
+	// Directives is the list of the directives of the spread
+	Directives []*Directive
+	// Location is where the spread starts in the document
+	Location Location
+}
+
+// InlineFragment is a struct that represents an inline fragment
+type InlineFragment struct {
+	// TypeCondition is the type the fragment applies to, empty for any
+	TypeCondition string
+	// Directives is the list of the directives of the fragment
+	Directives []*Directive
+	// Selections is the selection set of the fragment
+	Selections []Selection
+}
+
+// Fragment is a struct that represents a named fragment
+type Fragment struct {
+	// Name is the name of the fragment
+	Name string
+	// TypeCondition is the type the fragment applies to
+	TypeCondition string
+	// Selections is the selection set of the fragment
+	Selections []Selection
+}
+
+func (*Field) selection()          {}
+func (*FragmentSpread) selection() {}
+func (*InlineFragment) selection() {}
+
+// Argument is a struct that represents an argument of a field or a directive
+type Argument struct {
+	// Name is the name of the argument
+	Name string
+	// Value is the value of the argument
+	Value *Value
+}
+
+// Directive is a struct that represents a directive, e.g. @include(if: $flag)
+type Directive struct {
+	// Name is the name of the directive, without @
+	Name string
+	// Arguments is the list of the arguments of the directive
+	Arguments []*Argument
+}
+
+// Kinds of the values written in a document
+const (
+	ValueVariable = "variable"
+	ValueInt      = "int"
+	ValueFloat    = "float"
+	ValueString   = "string"
+	ValueBoolean  = "boolean"
+	ValueNull     = "null"
+	ValueEnum     = "enum"
+	ValueList     = "list"
+	ValueObject   = "object"
+)
+
+// Value is a struct that represents a value written in a document
+type Value struct {
+	// Kind is the kind of the value
+	Kind string
+	// Raw is the text of a scalar or enum value, or the name of a variable
+	Raw string
+	// List is the items of a list
+	List []*Value
+	// Fields is the list of the fields of an object
+	Fields []*Argument
+}
+
+// Location is a struct that represents a position in a document
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Token kinds of the lexer
+const (
+	tokenEOF = iota
+	tokenPunct
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+// token is a struct that represents a lexical token of a document
+type token struct {
+	kind  int
+	value string
+	loc   Location
+}
+
+// SyntaxError is a struct that represents an error of the syntax of a document
+type SyntaxError struct {
+	// Message is the description of the error
+	Message string
+	// Location is where the error is in the document
+	Location Location
+}
+
+// Error is a method that returns the error message
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at %d:%d: %s", e.Location.Line, e.Location.Column, e.Message)
+}
+
+// lexer is a struct that splits a document in tokens
+type lexer struct {
+	src  string
+	pos  int
+	line int
+	col  int
+}
+
+// next is a method that returns the next token of the document
+func (l *lexer) next() (t token, err error) {
+	l.skip()
+	t.loc = Location{Line: l.line, Column: l.col}
+	if l.pos >= len(l.src) {
+		t.kind = tokenEOF
+		return
+	}
+	c := l.src[l.pos]
+	switch {
+	case strings.HasPrefix(l.src[l.pos:], "..."):
+		t.kind, t.value = tokenPunct, "..."
+		l.advance(3)
+	case strings.IndexByte("!$():=@[]{}|&", c) >= 0:
+		t.kind, t.value = tokenPunct, string(c)
+		l.advance(1)
+	case c == '_' || isLetter(c):
+		start := l.pos
+		for l.pos < len(l.src) && (l.src[l.pos] == '_' || isLetter(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.advance(1)
+		}
+		t.kind, t.value = tokenName, l.src[start:l.pos]
+	case c == '-' || isDigit(c):
+		t.kind, t.value, err = l.number()
+	case c == '"':
+		t.kind = tokenString
+		t.value, err = l.string()
+	default:
+		err = &SyntaxError{Message: fmt.Sprintf("unexpected character %q", c), Location: t.loc}
+	}
+	return
+}
+
+// advance is a method that moves n bytes forward, keeping track of the line and column
+func (l *lexer) advance(n int) {
+	for i := 0; i < n && l.pos < len(l.src); i++ {
+		if l.src[l.pos] == '\n' {
+			l.line++
+			l.col = 1
+		} else {
+			l.col++
+		}
+		l.pos++
+	}
+}
+
+// skip is a method that moves past whitespace, commas and comments
+func (l *lexer) skip() {
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			l.advance(1)
+		case c == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.advance(1)
+			}
+		case strings.HasPrefix(l.src[l.pos:], "\uFEFF"):
+			l.pos += len("\uFEFF")
+		default:
+			return
+		}
+	}
+}
+
+// number is a method that reads an int or float token
+func (l *lexer) number() (kind int, value string, err error) {
+	start, loc := l.pos, Location{Line: l.line, Column: l.col}
+	kind = tokenInt
+	if l.src[l.pos] == '-' {
+		l.advance(1)
+	}
+	digits := func() int {
+		n := 0
+		for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			l.advance(1)
+			n++
+		}
+		return n
+	}
+	if digits() == 0 {
+		err = &SyntaxError{Message: "invalid number", Location: loc}
+		return
+	}
+	if l.pos < len(l.src) && l.src[l.pos] == '.' {
+		kind = tokenFloat
+		l.advance(1)
+		if digits() == 0 {
+			err = &SyntaxError{Message: "invalid number", Location: loc}
+			return
+		}
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		kind = tokenFloat
+		l.advance(1)
+		if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+			l.advance(1)
+		}
+		if digits() == 0 {
+			err = &SyntaxError{Message: "invalid number", Location: loc}
+			return
+		}
+	}
+	value = l.src[start:l.pos]
+	return
+}
+
+// string is a method that reads a string or block string token, unescaped
+func (l *lexer) string() (value string, err error) {
+	loc := Location{Line: l.line, Column: l.col}
+	if strings.HasPrefix(l.src[l.pos:], `"""`) {
+		l.advance(3)
+		end := strings.Index(l.src[l.pos:], `"""`)
+		if end < 0 {
+			err = &SyntaxError{Message: "unterminated string", Location: loc}
+			return
+		}
+		value = strings.TrimSpace(strings.ReplaceAll(l.src[l.pos:l.pos+end], `\"""`, `"""`))
+		l.advance(end + 3)
+		return
+	}
+
+	l.advance(1)
+	var b strings.Builder
+	for {
+		if l.pos >= len(l.src) || l.src[l.pos] == '\n' {
+			err = &SyntaxError{Message: "unterminated string", Location: loc}
+			return
+		}
+		c := l.src[l.pos]
+		switch {
+		case c == '"':
+			l.advance(1)
+			value = b.String()
+			return
+		case c == '\\' && l.pos+1 < len(l.src):
+			escape := l.src[l.pos+1]
+			switch escape {
+			case 'u':
+				if l.pos+6 > len(l.src) {
+					err = &SyntaxError{Message: "invalid unicode escape", Location: loc}
+					return
+				}
+				code, e := strconv.ParseUint(l.src[l.pos+2:l.pos+6], 16, 32)
+				if e != nil {
+					err = &SyntaxError{Message: "invalid unicode escape", Location: loc}
+					return
+				}
+				b.WriteRune(rune(code))
+				l.advance(6)
+				continue
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case '"', '\\', '/':
+				b.WriteByte(escape)
+			default:
+				err = &SyntaxError{Message: fmt.Sprintf("invalid escape \\%c", escape), Location: loc}
+				return
+			}
+			l.advance(2)
+		default:
+			r, size := utf8.DecodeRuneInString(l.src[l.pos:])
+			b.WriteRune(r)
+			l.advance(size)
+		}
+	}
+}
+
+// isLetter is a function that reports whether a byte is an ASCII letter
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// isDigit is a function that reports whether a byte is an ASCII digit
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// parser is a struct that builds a document from the tokens of a lexer
+type parser struct {
+	lex *lexer
+	tok token
+}
+
+// Parse is a function that parses a GraphQL request document
+func Parse(src string) (doc *Document, err error) {
+	p := &parser{lex: &lexer{src: src, line: 1, col: 1}}
+	if err = p.advance(); err != nil {
+		return
+	}
+	doc = &Document{Fragments: make(map[string]*Fragment)}
+	for p.tok.kind != tokenEOF {
+		switch {
+		case p.peek(tokenPunct, "{"):
+			op := &Operation{Type: "query"}
+			if op.Selections, err = p.selectionSet(); err != nil {
+				return
+			}
+			doc.Operations = append(doc.Operations, op)
+		case p.peek(tokenName, "query"), p.peek(tokenName, "mutation"), p.peek(tokenName, "subscription"):
+			var op *Operation
+			if op, err = p.operation(); err != nil {
+				return
+			}
+			doc.Operations = append(doc.Operations, op)
+		case p.peek(tokenName, "fragment"):
+			var f *Fragment
+			if f, err = p.fragment(); err != nil {
+				return
+			}
+			if _, ok := doc.Fragments[f.Name]; ok {
+				err = &SyntaxError{Message: fmt.Sprintf("duplicate fragment %s", f.Name), Location: p.tok.loc}
+				return
+			}
+			doc.Fragments[f.Name] = f
+		default:
+			err = p.unexpected()
+			return
+		}
+	}
+	if len(doc.Operations) == 0 {
+		err = &SyntaxError{Message: "no operation", Location: p.tok.loc}
+	}
+	return
+}
+
+// advance is a method that moves to the next token
+func (p *parser) advance() (err error) {
+	p.tok, err = p.lex.next()
+	return
+}
+
+// peek is a method that reports whether the current token is of a kind and value
+func (p *parser) peek(kind int, value string) bool {
+	return p.tok.kind == kind && p.tok.value == value
+}
+
+// expect is a method that moves past a punctuator, failing when it is another token
+func (p *parser) expect(value string) (err error) {
+	if !p.peek(tokenPunct, value) {
+		return p.unexpected()
+	}
+	return p.advance()
+}
+
+// name is a method that reads a name
+func (p *parser) name() (name string, err error) {
+	if p.tok.kind != tokenName {
+		err = p.unexpected()
+		return
+	}
+	name = p.tok.value
+	err = p.advance()
+	return
+}
+
+// unexpected is a method that returns the error of an unexpected token
+func (p *parser) unexpected() error {
+	if p.tok.kind == tokenEOF {
+		return &SyntaxError{Message: "unexpected end of document", Location: p.tok.loc}
+	}
+	return &SyntaxError{Message: fmt.Sprintf("unexpected %q", p.tok.value), Location: p.tok.loc}
+}
+
+// operation is a method that reads an operation definition
+func (p *parser) operation() (op *Operation, err error) {
+	op = &Operation{Type: p.tok.value}
+	if err = p.advance(); err != nil {
+		return
+	}
+	if p.tok.kind == tokenName {
+		if op.Name, err = p.name(); err != nil {
+			return
+		}
+	}
+	if p.peek(tokenPunct, "(") {
+		if err = p.advance(); err != nil {
+			return
+		}
+		for !p.peek(tokenPunct, ")") {
+			v := &VariableDefinition{}
+			if err = p.expect("$"); err != nil {
+				return
+			}
+			if v.Name, err = p.name(); err != nil {
+				return
+			}
+			if err = p.expect(":"); err != nil {
+				return
+			}
+			if v.Type, err = p.typeRef(); err != nil {
+				return
+			}
+			if p.peek(tokenPunct, "=") {
+				if err = p.advance(); err != nil {
+					return
+				}
+				if v.Default, err = p.value(true); err != nil {
+					return
+				}
+			}
+			op.Variables = append(op.Variables, v)
+		}
+		if err = p.advance(); err != nil {
+			return
+		}
+	}
+	if _, err = p.directives(); err != nil {
+		return
+	}
+	op.Selections, err = p.selectionSet()
+	return
+}
+
+// fragment is a method that reads a fragment definition
+func (p *parser) fragment() (f *Fragment, err error) {
+	f = &Fragment{}
+	if err = p.advance(); err != nil {
+		return
+	}
+	if f.Name, err = p.name(); err != nil {
+		return
+	}
+	if !p.peek(tokenName, "on") {
+		err = p.unexpected()
+		return
+	}
+	if err = p.advance(); err != nil {
+		return
+	}
+	if f.TypeCondition, err = p.name(); err != nil {
+		return
+	}
+	if _, err = p.directives(); err != nil {
+		return
+	}
+	f.Selections, err = p.selectionSet()
+	return
+}
+
+// typeRef is a method that reads a type
+func (p *parser) typeRef() (t *TypeRef, err error) {
+	t = &TypeRef{}
+	if p.peek(tokenPunct, "[") {
+		if err = p.advance(); err != nil {
+			return
+		}
+		if t.Elem, err = p.typeRef(); err != nil {
+			return
+		}
+		if err = p.expect("]"); err != nil {
+			return
+		}
+	} else if t.Name, err = p.name(); err != nil {
+		return
+	}
+	if p.peek(tokenPunct, "!") {
+		t.NonNull = true
+		err = p.advance()
+	}
+	return
+}
+
+// selectionSet is a method that reads a selection set
+func (p *parser) selectionSet() (selections []Selection, err error) {
+	if err = p.expect("{"); err != nil {
+		return
+	}
+	for !p.peek(tokenPunct, "}") {
+		var s Selection
+		if p.peek(tokenPunct, "...") {
+			s, err = p.fragmentSelection()
+		} else {
+			s, err = p.field()
+		}
+		if err != nil {
+			return
+		}
+		selections = append(selections, s)
+	}
+	if len(selections) == 0 {
+		err = &SyntaxError{Message: "empty selection set", Location: p.tok.loc}
+		return
+	}
+	err = p.advance()
+	return
+}
+
+// field is a method that reads a field selection
+func (p *parser) field() (f *Field, err error) {
+	f = &Field{Location: p.tok.loc}
+	if f.Name, err = p.name(); err != nil {
+		return
+	}
+	if p.peek(tokenPunct, ":") {
+		if err = p.advance(); err != nil {
+			return
+		}
+		f.Alias = f.Name
+		if f.Name, err = p.name(); err != nil {
+			return
+		}
+	}
+	if f.Arguments, err = p.arguments(false); err != nil {
+		return
+	}
+	if f.Directives, err = p.directives(); err != nil {
+		return
+	}
+	if p.peek(tokenPunct, "{") {
+		f.Selections, err = p.selectionSet()
+	}
+	return
+}
+
+// fragmentSelection is a method that reads a fragment spread or an inline fragment
+func (p *parser) fragmentSelection() (s Selection, err error) {
+	loc := p.tok.loc
+	if err = p.advance(); err != nil {
+		return
+	}
+	if p.tok.kind == tokenName && p.tok.value != "on" {
+		spread := &FragmentSpread{Location: loc}
+		if spread.Name, err = p.name(); err != nil {
+			return
+		}
+		spread.Directives, err = p.directives()
+		s = spread
+		return
+	}
+	inline := &InlineFragment{}
+	if p.peek(tokenName, "on") {
+		if err = p.advance(); err != nil {
+			return
+		}
+		if inline.TypeCondition, err = p.name(); err != nil {
+			return
+		}
+	}
+	if inline.Directives, err = p.directives(); err != nil {
+		return
+	}
+	inline.Selections, err = p.selectionSet()
+	s = inline
+	return
+}
+
+// arguments is a method that reads the arguments of a field or a directive, if any
+func (p *parser) arguments(constant bool) (args []*Argument, err error) {
+	if !p.peek(tokenPunct, "(") {
+		return
+	}
+	if err = p.advance(); err != nil {
+		return
+	}
+	for !p.peek(tokenPunct, ")") {
+		arg := &Argument{}
+		if arg.Name, err = p.name(); err != nil {
+			return
+		}
+		if err = p.expect(":"); err != nil {
+			return
+		}
+		if arg.Value, err = p.value(constant); err != nil {
+			return
+		}
+		args = append(args, arg)
+	}
+	err = p.advance()
+	return
+}
+
+// directives is a method that reads the directives of a definition or selection, if any
+func (p *parser) directives() (directives []*Directive, err error) {
+	for p.peek(tokenPunct, "@") {
+		if err = p.advance(); err != nil {
+			return
+		}
+		d := &Directive{}
+		if d.Name, err = p.name(); err != nil {
+			return
+		}
+		if d.Arguments, err = p.arguments(false); err != nil {
+			return
+		}
+		directives = append(directives, d)
+	}
+	return
+}
+
+// value is a method that reads a value, constant when variables are not allowed
+func (p *parser) value(constant bool) (v *Value, err error) {
+	t := p.tok
+	switch {
+	case t.kind == tokenPunct && t.value == "$" && !constant:
+		if err = p.advance(); err != nil {
+			return
+		}
+		v = &Value{Kind: ValueVariable}
+		v.Raw, err = p.name()
+		return
+	case t.kind == tokenPunct && t.value == "[":
+		if err = p.advance(); err != nil {
+			return
+		}
+		v = &Value{Kind: ValueList, List: []*Value{}}
+		for !p.peek(tokenPunct, "]") {
+			var item *Value
+			if item, err = p.value(constant); err != nil {
+				return
+			}
+			v.List = append(v.List, item)
+		}
+		err = p.advance()
+		return
+	case t.kind == tokenPunct && t.value == "{":
+		if err = p.advance(); err != nil {
+			return
+		}
+		v = &Value{Kind: ValueObject, Fields: []*Argument{}}
+		for !p.peek(tokenPunct, "}") {
+			field := &Argument{}
+			if field.Name, err = p.name(); err != nil {
+				return
+			}
+			if err = p.expect(":"); err != nil {
+				return
+			}
+			if field.Value, err = p.value(constant); err != nil {
+				return
+			}
+			v.Fields = append(v.Fields, field)
+		}
+		err = p.advance()
+		return
+	case t.kind == tokenInt:
+		v = &Value{Kind: ValueInt, Raw: t.value}
+	case t.kind == tokenFloat:
+		v = &Value{Kind: ValueFloat, Raw: t.value}
+	case t.kind == tokenString:
+		v = &Value{Kind: ValueString, Raw: t.value}
+	case t.kind == tokenName && (t.value == "true" || t.value == "false"):
+		v = &Value{Kind: ValueBoolean, Raw: t.value}
+	case t.kind == tokenName && t.value == "null":
+		v = &Value{Kind: ValueNull}
+	case t.kind == tokenName:
+		v = &Value{Kind: ValueEnum, Raw: t.value}
+	default:
+		err = p.unexpected()
+		return
+	}
+	err = p.advance()
+	return
+}
@@ -0,0 +1,111 @@
+package graphql_test
+
+import (
+	"app/platform/graphql"
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	doc, err := graphql.Parse(`
+		# a comment
+		query Vehicles($brand: String = "Ford", $ids: [Int!]!) {
+			vehicles(brand: $brand, ids: $ids) {
+				total
+				first: items(limit: 1) { ...parts }
+				... on Vehicle @include(if: true) { id }
+			}
+		}
+		mutation Rename { rename(id: 1, input: {brand: "Kia", tags: ["a", "b"]}) { id } }
+		fragment parts on Vehicle { id brand }
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(doc.Operations) != 2 || len(doc.Fragments) != 1 {
+		t.Fatalf("got %d operations and %d fragments, want 2 and 1", len(doc.Operations), len(doc.Fragments))
+	}
+	query, err := doc.Operation("Vehicles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query.Type != "query" || len(query.Variables) != 2 {
+		t.Fatalf("got a %s with %d variables, want a query with 2", query.Type, len(query.Variables))
+	}
+	if v := query.Variables[0]; v.Name != "brand" || v.Type.String() != "String" || v.Default == nil || v.Default.Raw != "Ford" {
+		t.Errorf("got variable %s: %s = %v, want brand: String = \"Ford\"", v.Name, v.Type, v.Default)
+	}
+	if v := query.Variables[1]; v.Name != "ids" || v.Type.String() != "[Int!]!" {
+		t.Errorf("got variable %s: %s, want ids: [Int!]!", v.Name, v.Type)
+	}
+
+	vehicles := query.Selections[0].(*graphql.Field)
+	if len(vehicles.Arguments) != 2 || vehicles.Arguments[0].Value.Kind != graphql.ValueVariable || len(vehicles.Selections) != 3 {
+		t.Fatalf("got field %+v, want vehicles with 2 variable arguments and 3 selections", vehicles)
+	}
+	if first := vehicles.Selections[1].(*graphql.Field); first.Key() != "first" || first.Name != "items" {
+		t.Errorf("got key %s of field %s, want the alias first of items", first.Key(), first.Name)
+	}
+	if spread := vehicles.Selections[1].(*graphql.Field).Selections[0].(*graphql.FragmentSpread); spread.Name != "parts" {
+		t.Errorf("got a spread of %s, want parts", spread.Name)
+	}
+	if inline := vehicles.Selections[2].(*graphql.InlineFragment); inline.TypeCondition != "Vehicle" || len(inline.Directives) != 1 {
+		t.Errorf("got an inline fragment on %s with %d directives, want one on Vehicle with 1", inline.TypeCondition, len(inline.Directives))
+	}
+	if f := doc.Fragments["parts"]; f.TypeCondition != "Vehicle" || len(f.Selections) != 2 {
+		t.Errorf("got fragment on %s with %d selections, want on Vehicle with 2", f.TypeCondition, len(f.Selections))
+	}
+
+	rename, err := doc.Operation("Rename")
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := rename.Selections[0].(*graphql.Field).Arguments[1].Value
+	if input.Kind != graphql.ValueObject || len(input.Fields) != 2 || input.Fields[1].Value.Kind != graphql.ValueList {
+		t.Errorf("got argument %+v, want an object with a list", input)
+	}
+	if _, err = doc.Operation(""); err == nil {
+		t.Errorf("got no error picking the only operation of a document with 2")
+	}
+}
+
+func TestParse_Shorthand(t *testing.T) {
+	doc, err := graphql.Parse(`{ vehicle(id: 1) { id } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	op, err := doc.Operation("")
+	if err != nil || op.Type != "query" || op.Name != "" {
+		t.Errorf("got %+v and %v, want an anonymous query", op, err)
+	}
+}
+
+func TestParse_Malformed(t *testing.T) {
+	cases := []struct {
+		name     string
+		src      string
+		location graphql.Location
+	}{
+		{name: "empty", src: ``, location: graphql.Location{Line: 1, Column: 1}},
+		{name: "unclosed selection set", src: `{ vehicle { id }`, location: graphql.Location{Line: 1, Column: 17}},
+		{name: "unterminated string", src: "{ vehicles(brand: \"Ford) { id } }", location: graphql.Location{Line: 1, Column: 19}},
+		{name: "variable without type", src: `query ($id) { vehicle(id: $id) { id } }`, location: graphql.Location{Line: 1, Column: 11}},
+		{name: "variable in a default", src: `query ($a: Int = $b) { a }`, location: graphql.Location{Line: 1, Column: 18}},
+		{name: "fragment without type condition", src: `{ a } fragment f { a }`, location: graphql.Location{Line: 1, Column: 18}},
+		{name: "duplicate fragment", src: "{ a }\nfragment f on Q { a }\nfragment f on Q { a }", location: graphql.Location{Line: 3, Column: 22}},
+		{name: "unexpected character", src: `{ a % }`, location: graphql.Location{Line: 1, Column: 5}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := graphql.Parse(c.src)
+			var syntax *graphql.SyntaxError
+			if !errors.As(err, &syntax) {
+				t.Fatalf("got %v, want a syntax error", err)
+			}
+			if syntax.Location != c.location {
+				t.Errorf("got the error %q at %+v, want at %+v", syntax.Message, syntax.Location, c.location)
+			}
+		})
+	}
+}
@@ -0,0 +1,402 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Type is an interface that represents a type of a schema: a scalar, enum, object, input object, list or non-null
+type Type interface {
+	// String is a method that returns the type as written in a document, e.g. [Int!]!
+	String() string
+}
+
+// ResolveParams is a struct that represents the input of a resolver
+type ResolveParams struct {
+	// Context is the context of the request
+	Context context.Context
+	// Source is the value of the parent object
+	Source any
+	// Args is the value of each argument of the field, coerced to its type and with the defaults applied
+	Args map[string]any
+}
+
+// ResolveFunc is a function that returns the value of a field
+type ResolveFunc func(p ResolveParams) (any, error)
+
+// ComplexityFunc is a function that returns the complexity of a field from its arguments and the complexity of its selection set
+type ComplexityFunc func(args map[string]any, children int) int
+
+// Scalar is a struct that represents a leaf type
+type Scalar struct {
+	// Name is the name of the type
+	Name string
+	// Description is the description of the type
+	Description string
+	// Serialize is a function that returns the value of a field in JSON
+	Serialize func(v any) (any, error)
+	// Parse is a function that returns an input value: an int, float64, string or bool of a literal or a variable
+	Parse func(v any) (any, error)
+}
+
+// String is a method that returns the name of the type
+func (t *Scalar) String() string { return t.Name }
+
+// EnumValue is a struct that represents a value of an enum
+type EnumValue struct {
+	// Name is the name of the value
+	Name string
+	// Description is the description of the value
+	Description string
+	// Value is the Go value the name stands for, the name itself when nil
+	Value any
+}
+
+// Enum is a struct that represents a leaf type with a closed set of values
+type Enum struct {
+	// Name is the name of the type
+	Name string
+	// Description is the description of the type
+	Description string
+	// Values is the list of the values of the type
+	Values []*EnumValue
+}
+
+// String is a method that returns the name of the type
+func (t *Enum) String() string { return t.Name }
+
+// value is a method that returns the Go value of a name and whether it is one of the type
+func (t *Enum) value(name string) (any, bool) {
+	for _, v := range t.Values {
+		if v.Name == name {
+			if v.Value == nil {
+				return v.Name, true
+			}
+			return v.Value, true
+		}
+	}
+	return nil, false
+}
+
+// name is a method that returns the name of a Go value and whether it is one of the type
+func (t *Enum) name(value any) (string, bool) {
+	for _, v := range t.Values {
+		if v.Value == nil && v.Name == value || v.Value != nil && v.Value == value {
+			return v.Name, true
+		}
+	}
+	return "", false
+}
+
+// Object is a struct that represents an output type with fields
+type Object struct {
+	// Name is the name of the type
+	Name string
+	// Description is the description of the type
+	Description string
+	// Fields is the list of the fields of the type
+	Fields []*FieldDefinition
+}
+
+// String is a method that returns the name of the type
+func (t *Object) String() string { return t.Name }
+
+// Field is a method that returns a field of the type by its name, nil when the type has none
+func (t *Object) Field(name string) *FieldDefinition {
+	for _, f := range t.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// FieldDefinition is a struct that represents a field of an object
+type FieldDefinition struct {
+	// Name is the name of the field
+	Name string
+	// Description is the description of the field
+	Description string
+	// Type is the type of the field
+	Type Type
+	// Args is the list of the arguments of the field
+	Args []*ArgumentDefinition
+	// Resolve is the function that returns the value of the field, a map[string]any source is read by name when nil
+	Resolve ResolveFunc
+	// Complexity is the function that returns the complexity of the field, 1 plus its selection set when nil
+	Complexity ComplexityFunc
+	// DeprecationReason is the reason the field is deprecated, empty when it is not
+	DeprecationReason string
+}
+
+// ArgumentDefinition is a struct that represents an argument of a field or a field of an input object
+type ArgumentDefinition struct {
+	// Name is the name of the argument
+	Name string
+	// Description is the description of the argument
+	Description string
+	// Type is the type of the argument, an input type
+	Type Type
+	// Default is the value the argument takes when it is not given, nil for none
+	Default any
+}
+
+// InputObject is a struct that represents an input type with fields
+type InputObject struct {
+	// Name is the name of the type
+	Name string
+	// Description is the description of the type
+	Description string
+	// Fields is the list of the fields of the type
+	Fields []*ArgumentDefinition
+}
+
+// String is a method that returns the name of the type
+func (t *InputObject) String() string { return t.Name }
+
+// List is a struct that represents a list of a type
+type List struct {
+	// OfType is the type of the items
+	OfType Type
+}
+
+// String is a method that returns the type as written in a document
+func (t *List) String() string { return "[" + t.OfType.String() + "]" }
+
+// NonNull is a struct that represents a type whose values cannot be null
+type NonNull struct {
+	// OfType is the type that cannot be null
+	OfType Type
+}
+
+// String is a method that returns the type as written in a document
+func (t *NonNull) String() string { return t.OfType.String() + "!" }
+
+// NewList is a function that returns a list of a type
+func NewList(t Type) *List { return &List{OfType: t} }
+
+// NewNonNull is a function that returns a non-null type
+func NewNonNull(t Type) *NonNull { return &NonNull{OfType: t} }
+
+// namedType is a function that returns the named type a list or non-null wraps
+func namedType(t Type) Type {
+	for {
+		switch w := t.(type) {
+		case *List:
+			t = w.OfType
+		case *NonNull:
+			t = w.OfType
+		default:
+			return t
+		}
+	}
+}
+
+// typeName is a function that returns the name of a named type
+func typeName(t Type) string {
+	switch n := t.(type) {
+	case *Scalar:
+		return n.Name
+	case *Enum:
+		return n.Name
+	case *Object:
+		return n.Name
+	case *InputObject:
+		return n.Name
+	}
+	return ""
+}
+
+// Schema is a struct that represents the types and the root operations of a GraphQL API
+type Schema struct {
+	// Query is the root type of queries
+	Query *Object
+	// Mutation is the root type of mutations, nil for none
+	Mutation *Object
+	// types is the named types of the schema, by name
+	types map[string]Type
+	// names is the list of the names of the types, in the order they were found
+	names []string
+}
+
+// NewSchema is a function that returns a schema with the types reachable from its root types
+func NewSchema(query *Object, mutation *Object) (s *Schema, err error) {
+	s = &Schema{Query: query, Mutation: mutation, types: make(map[string]Type)}
+	for _, t := range []Type{Int, Float, String, Boolean, ID, query} {
+		if err = s.add(t); err != nil {
+			return
+		}
+	}
+	if mutation != nil {
+		if err = s.add(mutation); err != nil {
+			return
+		}
+	}
+	err = s.add(introspectionSchema)
+	return
+}
+
+// add is a method that adds a type and the types of its fields to the schema
+func (s *Schema) add(t Type) (err error) {
+	t = namedType(t)
+	name := typeName(t)
+	if known, ok := s.types[name]; ok {
+		if known != t {
+			err = fmt.Errorf("graphql: two types named %s", name)
+		}
+		return
+	}
+	s.types[name] = t
+	s.names = append(s.names, name)
+
+	switch n := t.(type) {
+	case *Object:
+		for _, f := range n.Fields {
+			if err = s.add(f.Type); err != nil {
+				return
+			}
+			for _, arg := range f.Args {
+				if err = s.add(arg.Type); err != nil {
+					return
+				}
+			}
+		}
+	case *InputObject:
+		for _, f := range n.Fields {
+			if err = s.add(f.Type); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// Type is a method that returns a named type of the schema, nil when it has none
+func (s *Schema) Type(name string) Type {
+	return s.types[name]
+}
+
+// errCoerce is the error returned when an input value does not fit its type
+var errCoerce = errors.New("invalid value")
+
+// Built-in scalars
+var (
+	// Int is a signed 32-bit integer
+	Int = &Scalar{
+		Name:        "Int",
+		Description: "The `Int` scalar type represents non-fractional signed whole numeric values between -2^31 and 2^31-1.",
+		Serialize: func(v any) (any, error) {
+			switch n := v.(type) {
+			case int:
+				return n, nil
+			case int64:
+				return n, nil
+			case float64:
+				if n == math.Trunc(n) {
+					return int64(n), nil
+				}
+			}
+			return nil, errCoerce
+		},
+		Parse: func(v any) (any, error) {
+			switch n := v.(type) {
+			case int:
+				if n >= math.MinInt32 && n <= math.MaxInt32 {
+					return n, nil
+				}
+			case float64:
+				if n == math.Trunc(n) && n >= math.MinInt32 && n <= math.MaxInt32 {
+					return int(n), nil
+				}
+			}
+			return nil, errCoerce
+		},
+	}
+	// Float is a double-precision number
+	Float = &Scalar{
+		Name:        "Float",
+		Description: "The `Float` scalar type represents signed double-precision fractional values.",
+		Serialize: func(v any) (any, error) {
+			switch n := v.(type) {
+			case float64:
+				return n, nil
+			case int:
+				return float64(n), nil
+			}
+			return nil, errCoerce
+		},
+		Parse: func(v any) (any, error) {
+			switch n := v.(type) {
+			case float64:
+				return n, nil
+			case int:
+				return float64(n), nil
+			}
+			return nil, errCoerce
+		},
+	}
+	// String is a UTF-8 text
+	String = &Scalar{
+		Name:        "String",
+		Description: "The `String` scalar type represents textual data, represented as UTF-8 character sequences.",
+		Serialize: func(v any) (any, error) {
+			if s, ok := v.(string); ok {
+				return s, nil
+			}
+			return nil, errCoerce
+		},
+		Parse: func(v any) (any, error) {
+			if s, ok := v.(string); ok {
+				return s, nil
+			}
+			return nil, errCoerce
+		},
+	}
+	// Boolean is true or false
+	Boolean = &Scalar{
+		Name:        "Boolean",
+		Description: "The `Boolean` scalar type represents `true` or `false`.",
+		Serialize: func(v any) (any, error) {
+			if b, ok := v.(bool); ok {
+				return b, nil
+			}
+			return nil, errCoerce
+		},
+		Parse: func(v any) (any, error) {
+			if b, ok := v.(bool); ok {
+				return b, nil
+			}
+			return nil, errCoerce
+		},
+	}
+	// ID is a unique identifier, written as a string and accepted as a string or an int
+	ID = &Scalar{
+		Name:        "ID",
+		Description: "The `ID` scalar type represents a unique identifier, serialized as a string.",
+		Serialize: func(v any) (any, error) {
+			switch n := v.(type) {
+			case string:
+				return n, nil
+			case int:
+				return strconv.Itoa(n), nil
+			}
+			return nil, errCoerce
+		},
+		Parse: func(v any) (any, error) {
+			switch n := v.(type) {
+			case string:
+				return n, nil
+			case int:
+				return strconv.Itoa(n), nil
+			case float64:
+				if n == math.Trunc(n) {
+					return strconv.FormatInt(int64(n), 10), nil
+				}
+			}
+			return nil, errCoerce
+		},
+	}
+)
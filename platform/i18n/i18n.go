@@ -0,0 +1,64 @@
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Catalog is a map that represents the messages of each language, by language tag and message code
+type Catalog map[string]map[string]string
+
+// Text is a method that returns the message of a code in a language, formatted with args,
+// or in the fallback language when the language has no such message
+func (c Catalog) Text(lang string, fallback string, code string, args ...any) (text string, ok bool) {
+	text, ok = c[lang][code]
+	if !ok {
+		text, ok = c[fallback][code]
+	}
+	if ok && len(args) > 0 {
+		text = fmt.Sprintf(text, args...)
+	}
+	return
+}
+
+// Negotiate is a method that returns the language of the catalog preferred by an Accept-Language header,
+// matched by tag or by primary subtag, or the fallback language when none is accepted
+func (c Catalog) Negotiate(header string, fallback string) string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var accepted []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		w := weighted{tag: strings.ToLower(strings.TrimSpace(tag)), q: 1}
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			q, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			w.q = q
+		}
+		if w.tag == "" || w.q <= 0 {
+			continue
+		}
+		accepted = append(accepted, w)
+	}
+	sort.SliceStable(accepted, func(i, j int) bool { return accepted[i].q > accepted[j].q })
+
+	for _, w := range accepted {
+		if w.tag == "*" {
+			return fallback
+		}
+		if _, ok := c[w.tag]; ok {
+			return w.tag
+		}
+		primary, _, _ := strings.Cut(w.tag, "-")
+		if _, ok := c[primary]; ok {
+			return primary
+		}
+	}
+	return fallback
+}
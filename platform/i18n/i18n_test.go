@@ -0,0 +1,69 @@
+package i18n_test
+
+import (
+	"app/platform/i18n"
+	"testing"
+)
+
+// catalog is a catalog of the messages in Spanish, English and Brazilian Portuguese
+var catalog = i18n.Catalog{
+	"es":    {"hello": "hola %s", "bye": "adiós"},
+	"en":    {"hello": "hello %s"},
+	"pt-br": {"hello": "olá %s"},
+}
+
+func TestCatalog_Negotiate(t *testing.T) {
+	cases := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{name: "no header", header: "", want: "es"},
+		{name: "single tag", header: "en", want: "en"},
+		{name: "case", header: "EN", want: "en"},
+		{name: "primary subtag", header: "en-GB", want: "en"},
+		{name: "full tag", header: "pt-BR", want: "pt-br"},
+		{name: "order without weights", header: "en, es", want: "en"},
+		{name: "highest weight first", header: "es;q=0.5, en;q=0.9", want: "en"},
+		{name: "equal weights keep the order", header: "es;q=0.8, en;q=0.8", want: "es"},
+		{name: "spaces around the weight", header: "es ; q=0.5 , en ;q=0.7", want: "en"},
+		{name: "weight zero is refused", header: "en;q=0, fr", want: "es"},
+		{name: "invalid weight is ignored", header: "en;q=high, es;q=0.1", want: "es"},
+		{name: "unknown languages", header: "fr, de;q=0.5", want: "es"},
+		{name: "unknown before known", header: "fr, en;q=0.5", want: "en"},
+		{name: "wildcard", header: "*", want: "es"},
+		{name: "wildcard below a known language", header: "*;q=0.1, en", want: "en"},
+		{name: "wildcard above a known language", header: "*, en;q=0.5", want: "es"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := catalog.Negotiate(c.header, "es"); got != c.want {
+				t.Errorf("got %s, want %s", got, c.want)
+			}
+		})
+	}
+}
+
+func TestCatalog_Text(t *testing.T) {
+	cases := []struct {
+		name string
+		lang string
+		code string
+		args []any
+		want string
+		ok   bool
+	}{
+		{name: "formatted", lang: "en", code: "hello", args: []any{"ana"}, want: "hello ana", ok: true},
+		{name: "fallback language", lang: "en", code: "bye", want: "adiós", ok: true},
+		{name: "unknown language", lang: "fr", code: "hello", args: []any{"ana"}, want: "hola ana", ok: true},
+		{name: "unknown code", lang: "en", code: "nope", want: "", ok: false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, ok := catalog.Text(c.lang, "es", c.code, c.args...)
+			if got != c.want || ok != c.ok {
+				t.Errorf("got %q and %v, want %q and %v", got, ok, c.want, c.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrMalformed is the error returned when a token is not a compact JWS
+	ErrMalformed = errors.New("jwt: malformed token")
+	// ErrAlgorithm is the error returned when the algorithm of a token has no key configured
+	ErrAlgorithm = errors.New("jwt: unsupported algorithm")
+	// ErrSignature is the error returned when the signature of a token does not verify
+	ErrSignature = errors.New("jwt: invalid signature")
+	// ErrExpired is the error returned when a token is expired or not valid yet
+	ErrExpired = errors.New("jwt: token expired or not valid yet")
+	// ErrNoExpiry is the error returned when a token has no exp claim, as it would never expire
+	ErrNoExpiry = errors.New("jwt: token without expiry")
+	// ErrClaims is the error returned when the issuer or audience of a token are not the expected ones
+	ErrClaims = errors.New("jwt: unexpected issuer or audience")
+)
+
+// Keys is a struct that represents the keys tokens are verified with, a nil key disables its algorithm
+type Keys struct {
+	// HS256 is the secret of the HMAC-SHA256 tokens
+	HS256 []byte
+	// RS256 is the public key of the RSA-SHA256 tokens
+	RS256 *rsa.PublicKey
+	// Issuer is the iss claim required, empty for any
+	Issuer string
+	// Audience is the aud claim required, empty for any
+	Audience string
+	// Leeway is the clock skew tolerated on exp and nbf
+	Leeway time.Duration
+}
+
+// Claims is a map that represents the claims of a token
+type Claims map[string]any
+
+// String is a method that returns a string claim, empty when missing or of another type
+func (c Claims) String(name string) string {
+	s, _ := c[name].(string)
+	return s
+}
+
+// Verify is a function that verifies the signature and the time and audience claims of a token, and returns its claims.
+// The exp claim is required, a token that never expires is rejected
+func Verify(token string, keys Keys, now time.Time) (claims Claims, err error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		err = ErrMalformed
+		return
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err = decode(parts[0], &header); err != nil {
+		return
+	}
+	signature, e := base64.RawURLEncoding.DecodeString(parts[2])
+	if e != nil {
+		err = ErrMalformed
+		return
+	}
+
+	// signature
+	signed := []byte(parts[0] + "." + parts[1])
+	digest := sha256.Sum256(signed)
+	switch {
+	case header.Alg == "HS256" && keys.HS256 != nil:
+		mac := hmac.New(sha256.New, keys.HS256)
+		mac.Write(signed)
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			err = ErrSignature
+			return
+		}
+	case header.Alg == "RS256" && keys.RS256 != nil:
+		if rsa.VerifyPKCS1v15(keys.RS256, crypto.SHA256, digest[:], signature) != nil {
+			err = ErrSignature
+			return
+		}
+	default:
+		err = ErrAlgorithm
+		return
+	}
+
+	// claims
+	if err = decode(parts[1], &claims); err != nil {
+		return
+	}
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		err = ErrNoExpiry
+		return
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(keys.Leeway)) {
+		err = ErrExpired
+		return
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(keys.Leeway).Before(time.Unix(int64(nbf), 0)) {
+		err = ErrExpired
+		return
+	}
+	if keys.Issuer != "" && claims.String("iss") != keys.Issuer {
+		err = ErrClaims
+		return
+	}
+	if keys.Audience != "" && !hasAudience(claims["aud"], keys.Audience) {
+		err = ErrClaims
+		return
+	}
+	return
+}
+
+// Sign is a function that returns an HS256 token of some claims, for tools and tests
+func Sign(claims Claims, secret []byte) (token string, err error) {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return
+	}
+	signed := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signed))
+	token = signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	return
+}
+
+// decode is a function that decodes a base64url JSON part of a token
+func decode(part string, v any) (err error) {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return ErrMalformed
+	}
+	if json.Unmarshal(data, v) != nil {
+		return ErrMalformed
+	}
+	return
+}
+
+// hasAudience is a function that reports whether an aud claim, a string or a list of strings, holds an audience
+func hasAudience(aud any, audience string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == audience
+	case []any:
+		for _, a := range aud {
+			if a == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
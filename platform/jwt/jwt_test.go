@@ -0,0 +1,117 @@
+package jwt_test
+
+import (
+	"app/platform/jwt"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// token is a function that returns a token of a header and some claims, signed by sign
+func token(t *testing.T, header string, claims jwt.Claims, sign func(signed []byte) []byte) string {
+	t.Helper()
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := base64.RawURLEncoding.EncodeToString([]byte(header)) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign([]byte(signed)))
+}
+
+// hs256 is a function that returns a signer with HMAC-SHA256
+func hs256(secret []byte) func([]byte) []byte {
+	return func(signed []byte) []byte {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(signed)
+		return mac.Sum(nil)
+	}
+}
+
+// rs256 is a function that returns a signer with RSA-SHA256
+func rs256(t *testing.T, key *rsa.PrivateKey) func([]byte) []byte {
+	return func(signed []byte) []byte {
+		digest := sha256.Sum256(signed)
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signature
+	}
+}
+
+func TestVerify(t *testing.T) {
+	secret := []byte("secret")
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1_700_000_000, 0)
+	valid := jwt.Claims{"sub": "ana", "exp": now.Add(time.Minute).Unix()}
+	const hs, rs, none = `{"alg":"HS256"}`, `{"alg":"RS256"}`, `{"alg":"none"}`
+	both := jwt.Keys{HS256: secret, RS256: &key.PublicKey}
+	onlyRS := jwt.Keys{RS256: &key.PublicKey}
+	leeway := jwt.Keys{HS256: secret, Leeway: 30 * time.Second}
+
+	cases := []struct {
+		name  string
+		token string
+		keys  jwt.Keys
+		err   error
+	}{
+		{name: "hs256", token: token(t, hs, valid, hs256(secret)), keys: both},
+		{name: "rs256", token: token(t, rs, valid, rs256(t, key)), keys: both},
+		{name: "hs256 with another secret", token: token(t, hs, valid, hs256([]byte("other"))), keys: both, err: jwt.ErrSignature},
+		{name: "rs256 signed with hmac", token: token(t, rs, valid, hs256(secret)), keys: both, err: jwt.ErrSignature},
+		{name: "hs256 signed with the public key", token: token(t, hs, valid, hs256(public)), keys: onlyRS, err: jwt.ErrAlgorithm},
+		{name: "none", token: token(t, none, valid, func([]byte) []byte { return nil }), keys: both, err: jwt.ErrAlgorithm},
+		{name: "malformed", token: "a.b", keys: both, err: jwt.ErrMalformed},
+		{name: "missing exp", token: token(t, hs, jwt.Claims{"sub": "ana"}, hs256(secret)), keys: both, err: jwt.ErrNoExpiry},
+		{name: "expired", token: token(t, hs, jwt.Claims{"exp": now.Add(-time.Minute).Unix()}, hs256(secret)), keys: leeway, err: jwt.ErrExpired},
+		{name: "expired within the leeway", token: token(t, hs, jwt.Claims{"exp": now.Add(-10 * time.Second).Unix()}, hs256(secret)), keys: leeway},
+		{name: "not valid yet", token: token(t, hs, jwt.Claims{"exp": now.Add(time.Hour).Unix(), "nbf": now.Add(time.Minute).Unix()}, hs256(secret)), keys: leeway, err: jwt.ErrExpired},
+		{name: "not valid yet within the leeway", token: token(t, hs, jwt.Claims{"exp": now.Add(time.Hour).Unix(), "nbf": now.Add(10 * time.Second).Unix()}, hs256(secret)), keys: leeway},
+		{name: "issuer", token: token(t, hs, jwt.Claims{"exp": now.Add(time.Hour).Unix(), "iss": "other"}, hs256(secret)), keys: jwt.Keys{HS256: secret, Issuer: "us"}, err: jwt.ErrClaims},
+		{name: "audience", token: token(t, hs, jwt.Claims{"exp": now.Add(time.Hour).Unix(), "aud": []string{"a", "vehicles"}}, hs256(secret)), keys: jwt.Keys{HS256: secret, Audience: "vehicles"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			claims, err := jwt.Verify(c.token, c.keys, now)
+			if !errors.Is(err, c.err) {
+				t.Fatalf("got %v, want %v", err, c.err)
+			}
+			if c.err == nil && claims["exp"] == nil {
+				t.Errorf("got claims %v, want the claims of the token", claims)
+			}
+		})
+	}
+}
+
+func TestVerify_TamperedPayload(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Unix(1_700_000_000, 0)
+	signed, err := jwt.Sign(jwt.Claims{"sub": "ana", "role": "viewer", "exp": now.Add(time.Minute).Unix()}, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the payload is swapped for one with another role, keeping the signature
+	parts := strings.Split(signed, ".")
+	payload, _ := json.Marshal(jwt.Claims{"sub": "ana", "role": "admin", "exp": now.Add(time.Minute).Unix()})
+	parts[1] = base64.RawURLEncoding.EncodeToString(payload)
+	if _, err = jwt.Verify(strings.Join(parts, "."), jwt.Keys{HS256: secret}, now); !errors.Is(err, jwt.ErrSignature) {
+		t.Errorf("got %v, want %v", err, jwt.ErrSignature)
+	}
+}
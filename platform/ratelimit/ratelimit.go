@@ -0,0 +1,98 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// NewLimiter is a function that returns a new instance of Limiter, whose buckets hold up to burst tokens
+// and are refilled with burst tokens every window
+func NewLimiter(burst int, window time.Duration) *Limiter {
+	return &Limiter{
+		burst:   burst,
+		rate:    float64(burst) / window.Seconds(),
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Limiter is a struct that represents a token bucket rate limiter, with one bucket per key
+type Limiter struct {
+	// mu is the lock of the buckets
+	mu sync.Mutex
+	// burst is the capacity of each bucket
+	burst int
+	// rate is the number of tokens added to each bucket per second
+	rate float64
+	// buckets is the bucket of each key
+	buckets map[string]*bucket
+	// calls is the number of calls since the last sweep of the full buckets
+	calls int
+}
+
+// bucket is a struct that represents the tokens of a key
+type bucket struct {
+	// tokens is the number of tokens at the last refill
+	tokens float64
+	// last is the moment of the last refill
+	last time.Time
+}
+
+// Result is a struct that represents the outcome of taking a token
+type Result struct {
+	// Allowed is whether a token was taken
+	Allowed bool
+	// Limit is the capacity of the bucket
+	Limit int
+	// Remaining is the number of whole tokens left
+	Remaining int
+	// RetryAfter is the wait until a token is available, 0 when allowed
+	RetryAfter time.Duration
+	// Reset is the wait until the bucket is full again
+	Reset time.Duration
+}
+
+// Allow is a method that takes a token from the bucket of a key, if any
+func (l *Limiter) Allow(key string, now time.Time) (res Result) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.burst), last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(l.burst), b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	res.Limit = l.burst
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = l.wait(1 - b.tokens)
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = l.wait(float64(l.burst) - b.tokens)
+	return
+}
+
+// wait is a method that returns the time some tokens take to be refilled
+func (l *Limiter) wait(tokens float64) time.Duration {
+	return time.Duration(math.Ceil(tokens / l.rate * float64(time.Second)))
+}
+
+// sweep is a method that drops, every so many calls, the buckets that are full again, as new ones are alike
+func (l *Limiter) sweep(now time.Time) {
+	l.calls++
+	if l.calls < 1024 {
+		return
+	}
+	l.calls = 0
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= float64(l.burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
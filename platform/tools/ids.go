@@ -0,0 +1,50 @@
+package tools
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// crockford is the alphabet of the ULIDs, Crockford's base32
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewUUIDv7 is a function that returns a new RFC 9562 version 7 UUID, ordered by the moment it was made
+func NewUUIDv7(now time.Time) (id string, err error) {
+	var b [16]byte
+	if _, err = rand.Read(b[6:]); err != nil {
+		return
+	}
+	// - 48 bits of unix milliseconds, then the version and variant bits
+	ms := uint64(now.UnixMilli())
+	binary.BigEndian.PutUint16(b[0:2], uint16(ms>>32))
+	binary.BigEndian.PutUint32(b[2:6], uint32(ms))
+	b[6] = b[6]&0x0f | 0x70
+	b[8] = b[8]&0x3f | 0x80
+	id = fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+	return
+}
+
+// NewULID is a function that returns a new ULID, ordered by the moment it was made
+func NewULID(now time.Time) (id string, err error) {
+	var b [16]byte
+	if _, err = rand.Read(b[6:]); err != nil {
+		return
+	}
+	// - 48 bits of unix milliseconds, then 80 random bits
+	ms := uint64(now.UnixMilli())
+	binary.BigEndian.PutUint16(b[0:2], uint16(ms>>32))
+	binary.BigEndian.PutUint32(b[2:6], uint32(ms))
+
+	// - 128 bits in 26 characters of 5 bits, the first one holding the 3 leading bits
+	out := make([]byte, 26)
+	hi, lo := binary.BigEndian.Uint64(b[0:8]), binary.BigEndian.Uint64(b[8:16])
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	id = string(out)
+	return
+}
@@ -0,0 +1,416 @@
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Opcodes of the frames
+const (
+	OpContinuation = 0x0
+	OpText         = 0x1
+	OpBinary       = 0x2
+	OpClose        = 0x8
+	OpPing         = 0x9
+	OpPong         = 0xA
+)
+
+// Close codes
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	ClosePolicyViolation = 1008
+	CloseMessageTooBig   = 1009
+	CloseTryAgainLater   = 1013
+)
+
+// guid is the key suffix of the handshake, defined by RFC 6455
+const guid = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+var (
+	// ErrBadHandshake is the error returned when a request or response is not a valid websocket handshake
+	ErrBadHandshake = errors.New("websocket: bad handshake")
+	// ErrMessageTooBig is the error returned when a message is larger than the read limit
+	ErrMessageTooBig = errors.New("websocket: message too big")
+	// ErrProtocol is the error returned when the peer breaks the protocol
+	ErrProtocol = errors.New("websocket: protocol error")
+	// ErrBadOrigin is the error returned when the origin of a handshake is not allowed
+	ErrBadOrigin = errors.New("websocket: origin not allowed")
+)
+
+// CloseError is a struct that represents a close frame received from the peer
+type CloseError struct {
+	// Code is the status code of the close
+	Code int
+	// Reason is the reason of the close
+	Reason string
+}
+
+// Error is a method that returns the error message
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: closed %d %s", e.Code, e.Reason)
+}
+
+// Conn is a struct that represents a websocket connection
+type Conn struct {
+	// conn is the underlying connection
+	conn net.Conn
+	// br is the buffered reader of conn, which may hold bytes read during the handshake
+	br *bufio.Reader
+	// client is whether this end is the client, which masks the frames it writes
+	client bool
+	// mu is the lock that serializes the writes
+	mu sync.Mutex
+	// readLimit is the maximum size of a message read
+	readLimit int64
+	// idleTimeout is how long a read waits for any frame, zero for ever
+	idleTimeout time.Duration
+	// closeSent is whether a close frame was written
+	closeSent bool
+}
+
+// SetReadLimit is a method that sets the maximum size of a message read
+func (c *Conn) SetReadLimit(limit int64) {
+	c.readLimit = limit
+}
+
+// SetIdleTimeout is a method that sets how long a read waits for any frame, pongs included, zero for ever
+func (c *Conn) SetIdleTimeout(d time.Duration) {
+	c.idleTimeout = d
+}
+
+// Upgrade is a function that upgrades an http request to a websocket connection.
+// A request sent by a browser, with an Origin header, is rejected with 403 unless the origin is the host
+// of the request or one of origins, written as scheme://host[:port], where "*" allows any
+func Upgrade(w http.ResponseWriter, r *http.Request, origins []string) (c *Conn, err error) {
+	if r.Method != http.MethodGet ||
+		!headerContains(r.Header, "Connection", "upgrade") ||
+		!headerContains(r.Header, "Upgrade", "websocket") ||
+		r.Header.Get("Sec-Websocket-Version") != "13" {
+		http.Error(w, "websocket upgrade required", http.StatusUpgradeRequired)
+		err = ErrBadHandshake
+		return
+	}
+	if !originAllowed(r, origins) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		err = ErrBadOrigin
+		return
+	}
+	key := r.Header.Get("Sec-Websocket-Key")
+	if key == "" {
+		http.Error(w, "missing Sec-WebSocket-Key", http.StatusBadRequest)
+		err = ErrBadHandshake
+		return
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websocket not supported", http.StatusInternalServerError)
+		err = ErrBadHandshake
+		return
+	}
+
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return
+	}
+	_, err = fmt.Fprintf(conn, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n", accept(key))
+	if err != nil {
+		conn.Close()
+		return
+	}
+	c = &Conn{conn: conn, br: rw.Reader, readLimit: 1 << 20}
+	return
+}
+
+// Dial is a function that opens a websocket connection to a ws:// url
+func Dial(rawURL string, header http.Header) (c *Conn, err error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return
+	}
+	if u.Scheme != "ws" {
+		err = fmt.Errorf("websocket: unsupported scheme %s", u.Scheme)
+		return
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host += ":80"
+	}
+	conn, err := net.Dial("tcp", host)
+	if err != nil {
+		return
+	}
+
+	// handshake
+	nonce := make([]byte, 16)
+	if _, err = rand.Read(nonce); err != nil {
+		conn.Close()
+		return
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+	req := &http.Request{
+		Method:     http.MethodGet,
+		URL:        u,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Host:       u.Host,
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if err = req.Write(conn); err != nil {
+		conn.Close()
+		return
+	}
+	br := bufio.NewReader(conn)
+	res, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	if res.StatusCode != http.StatusSwitchingProtocols || res.Header.Get("Sec-Websocket-Accept") != accept(key) {
+		conn.Close()
+		err = ErrBadHandshake
+		return
+	}
+	c = &Conn{conn: conn, br: br, client: true, readLimit: 1 << 20}
+	return
+}
+
+// accept is a function that returns the accept key of a handshake key
+func accept(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + guid))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// originAllowed is a function that reports whether the origin of a request is allowed:
+// none, the host of the request or one of origins
+func originAllowed(r *http.Request, origins []string) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, o := range origins {
+		if o == "*" || strings.EqualFold(o, origin) {
+			return true
+		}
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// headerContains is a function that reports whether a comma separated header contains a token, ignoring case
+func headerContains(header http.Header, name string, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ReadMessage is a method that returns the next text or binary message, answering the pings and closes on the way.
+// A close from the peer is returned as a *CloseError
+func (c *Conn) ReadMessage() (op int, data []byte, err error) {
+	for {
+		if c.idleTimeout > 0 {
+			c.conn.SetReadDeadline(time.Now().Add(c.idleTimeout))
+		}
+		var fin bool
+		var frameOp int
+		var payload []byte
+		fin, frameOp, payload, err = c.readFrame()
+		if err != nil {
+			return
+		}
+
+		switch frameOp {
+		case OpPing:
+			if err = c.WriteMessage(OpPong, payload); err != nil {
+				return
+			}
+		case OpPong:
+		case OpClose:
+			closeErr := &CloseError{Code: CloseNormal}
+			if len(payload) >= 2 {
+				closeErr.Code = int(binary.BigEndian.Uint16(payload))
+				closeErr.Reason = string(payload[2:])
+			}
+			c.WriteClose(closeErr.Code, "")
+			err = closeErr
+			return
+		case OpText, OpBinary:
+			if op != 0 {
+				err = ErrProtocol
+				return
+			}
+			op, data = frameOp, payload
+		case OpContinuation:
+			if op == 0 {
+				err = ErrProtocol
+				return
+			}
+			data = append(data, payload...)
+		default:
+			err = ErrProtocol
+			return
+		}
+		if int64(len(data)) > c.readLimit {
+			c.WriteClose(CloseMessageTooBig, "")
+			err = ErrMessageTooBig
+			return
+		}
+		if op != 0 && fin && frameOp < OpClose {
+			return
+		}
+	}
+}
+
+// readFrame is a method that reads a frame, unmasking its payload
+func (c *Conn) readFrame() (fin bool, op int, payload []byte, err error) {
+	var head [2]byte
+	if _, err = io.ReadFull(c.br, head[:]); err != nil {
+		return
+	}
+	fin = head[0]&0x80 != 0
+	op = int(head[0] & 0x0F)
+	masked := head[1]&0x80 != 0
+	if head[0]&0x70 != 0 || masked == c.client {
+		// no extension is negotiated, and only the frames of the client are masked
+		err = ErrProtocol
+		return
+	}
+
+	length := int64(head[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		length = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		length = int64(binary.BigEndian.Uint64(ext[:]))
+	}
+	if op >= OpClose && (length > 125 || !fin) {
+		err = ErrProtocol
+		return
+	}
+	if length < 0 || length > c.readLimit {
+		c.WriteClose(CloseMessageTooBig, "")
+		err = ErrMessageTooBig
+		return
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err = io.ReadFull(c.br, mask[:]); err != nil {
+			return
+		}
+	}
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(c.br, payload); err != nil {
+		return
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return
+}
+
+// WriteMessage is a method that writes a message in a single frame, safe for concurrent use
+func (c *Conn) WriteMessage(op int, data []byte) (err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closeSent {
+		err = net.ErrClosed
+		return
+	}
+	err = c.writeFrame(op, data)
+	return
+}
+
+// WriteClose is a method that writes a close frame with a status code and a reason, once
+func (c *Conn) WriteClose(code int, reason string) (err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closeSent {
+		return
+	}
+	c.closeSent = true
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	err = c.writeFrame(OpClose, append(payload, reason...))
+	return
+}
+
+// writeFrame is a method that writes a final frame, the caller has to hold the lock
+func (c *Conn) writeFrame(op int, data []byte) (err error) {
+	frame := []byte{0x80 | byte(op), 0}
+	switch {
+	case len(data) < 126:
+		frame[1] = byte(len(data))
+	case len(data) <= 0xFFFF:
+		frame[1] = 126
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(data)))
+	default:
+		frame[1] = 127
+		frame = binary.BigEndian.AppendUint64(frame, uint64(len(data)))
+	}
+	if c.client {
+		var mask [4]byte
+		if _, err = rand.Read(mask[:]); err != nil {
+			return
+		}
+		frame[1] |= 0x80
+		frame = append(frame, mask[:]...)
+		start := len(frame)
+		frame = append(frame, data...)
+		for i := range frame[start:] {
+			frame[start+i] ^= mask[i%4]
+		}
+	} else {
+		frame = append(frame, data...)
+	}
+	_, err = c.conn.Write(frame)
+	return
+}
+
+// SetWriteDeadline is a method that sets the deadline of the writes, zero for none
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// Close is a method that closes the connection without a close frame
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}